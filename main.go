@@ -11,6 +11,7 @@ import (
 	"github.com/gofiber/template/html/v2"
 	"github.com/joho/godotenv"
 
+	"sports-excitement-team-management/src/config"
 	"sports-excitement-team-management/src/database"
 	"sports-excitement-team-management/src/handlers"
 	"sports-excitement-team-management/src/services"
@@ -66,6 +67,13 @@ func main() {
 	// Initialize database
 	database.Initialize()
 
+	// Import public holiday calendars from the configured path
+	if path := config.AppConfig.HolidayCalendars; path != "" {
+		if err := database.ImportHolidayCalendarFiles(path); err != nil {
+			utils.LogError("Failed to import holiday calendars from %s: %v", path, err)
+		}
+	}
+
 	// Initialize template engine
 	engine := html.New("./src/templates", ".html")
 	engine.Reload(true) // for development
@@ -91,11 +99,15 @@ func main() {
 
 	// Initialize Slack service with initial status sync
 	slackService := services.NewSlackService()
+	services.SetGlobalSlackService(slackService)
 	slackService.StartWithInitialSync()
 
 	// Initialize handlers
 	handlers.SetupRoutes(app, wsHub)
 
+	// Generate scheduled reports and prune the report archive
+	handlers.StartReportScheduler()
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -5,6 +5,7 @@ let ws = null;
 let dataTable = null;
 let statusChart = null;
 let progressChart = null;
+let trendChart = null;
 
 // Initialize dashboard when DOM is loaded
 $(document).ready(function() {
@@ -13,6 +14,15 @@ $(document).ready(function() {
         initializeDataTable();
         initializeCharts();
         initializeWebSocket();
+        loadAbsences();
+        loadViolations();
+        loadTrend();
+        loadHeatmap();
+        loadReportArchive();
+        loadExportFormats();
+        loadImportBatches();
+        loadTeamAnalytics();
+        loadProjects();
         
         // Auto-refresh every 30 seconds if WebSocket is not connected
         setInterval(function() {
@@ -43,6 +53,32 @@ function getTopPerformingUsers(users, minHours = 10, limit = 10) {
         .slice(0, limit);
 }
 
+// Helper function to get a user's weekly target hours from their contract
+function getWeeklyTarget(user) {
+    return typeof user.weekly_target === 'number' ? user.weekly_target : 20;
+}
+
+// Helper function to pick the progress color for hours against a target
+function getProgressColor(hours, target) {
+    if (target <= 0 || hours >= target) return '#28a745';
+    return hours >= target * 0.5 ? '#ffc107' : '#dc3545';
+}
+
+// Helper function to render the weekly hours cell with a progress bar against the user's target
+function renderWeeklyHoursCell(user) {
+    const target = getWeeklyTarget(user);
+    const completion = target > 0 ? (user.weekly_hours / target) * 100 : 100;
+    const barClass = completion >= 100 ? 'bg-success' : completion >= 50 ? 'bg-warning' : 'bg-danger';
+
+    return `<div class="d-flex align-items-center">
+            ${user.weekly_hours.toFixed(1)}h
+            <div class="progress ms-2" style="width: 60px; height: 8px;" title="Target: ${target.toFixed(1)}h">
+                <div class="progress-bar ${barClass}" 
+                     style="width: ${Math.min(completion, 100)}%"></div>
+            </div>
+        </div>`;
+}
+
 // Initialize DataTable
 function initializeDataTable() {
     if ($.fn.DataTable.isDataTable('#usersTable')) {
@@ -161,7 +197,7 @@ function initializeProgressChart() {
     
     const userNames = topUsers.map(u => u.name.split(' ')[0]); // First name only
     const weeklyHours = topUsers.map(u => u.weekly_hours);
-    const target = 20; // 20 hours per week target
+    const targets = topUsers.map(u => getWeeklyTarget(u)); // Contracted hours per user
     
     progressChart = new Chart(ctx, {
         type: 'bar',
@@ -171,16 +207,13 @@ function initializeProgressChart() {
                 {
                     label: 'Weekly Hours',
                     data: weeklyHours,
-                    backgroundColor: weeklyHours.map(hours => 
-                        hours >= target ? '#28a745' : 
-                        hours >= target * 0.5 ? '#ffc107' : '#dc3545'
-                    ),
+                    backgroundColor: weeklyHours.map((hours, i) => getProgressColor(hours, targets[i])),
                     borderColor: '#fff',
                     borderWidth: 1
                 },
                 {
-                    label: 'Target (20h)',
-                    data: new Array(userNames.length).fill(target),
+                    label: 'Target',
+                    data: targets,
                     type: 'line',
                     borderColor: '#007bff',
                     backgroundColor: 'transparent',
@@ -206,7 +239,7 @@ function initializeProgressChart() {
             scales: {
                 y: {
                     beginAtZero: true,
-                    max: Math.max(target + 5, Math.max(...weeklyHours) + 5),
+                    suggestedMax: Math.max(...targets, ...weeklyHours, 0) + 5,
                     ticks: {
                         callback: function(value) {
                             return value + 'h';
@@ -331,6 +364,7 @@ function updateDashboardData(data) {
         updateUserTable(data.users);
         updateAnalyticsCards();
         updateCharts();
+        loadTeamAnalytics();
         console.log('Dashboard updated with', data.users.length, 'users');
     } else {
         console.warn('No valid users array in dashboard data update:', data);
@@ -379,13 +413,7 @@ function updateUserTable(users) {
         user.is_currently_working ? 
             '<span class="badge bg-success"><i class="fas fa-circle me-1"></i>Working</span>' :
             '<span class="badge bg-secondary"><i class="fas fa-circle me-1"></i>Offline</span>',
-        `<div class="d-flex align-items-center">
-            ${user.weekly_hours.toFixed(1)}h
-            <div class="progress ms-2" style="width: 60px; height: 8px;">
-                <div class="progress-bar ${user.weekly_hours >= 20 ? 'bg-success' : user.weekly_hours >= 10 ? 'bg-warning' : 'bg-danger'}" 
-                     style="width: ${Math.min((user.weekly_hours / 20) * 100, 100)}%"></div>
-            </div>
-        </div>`,
+        renderWeeklyHoursCell(user),
         `${user.monthly_hours.toFixed(1)}h`,
         `${(user.total_working_time / 3600).toFixed(1)}h`,
         `<small class="text-muted">${new Date(user.last_activity).toLocaleDateString('en-US', {
@@ -421,13 +449,7 @@ function updateSingleUserInTable(user) {
         user.is_currently_working ? 
             '<span class="badge bg-success"><i class="fas fa-circle me-1"></i>Working</span>' :
             '<span class="badge bg-secondary"><i class="fas fa-circle me-1"></i>Offline</span>',
-        `<div class="d-flex align-items-center">
-            ${user.weekly_hours.toFixed(1)}h
-            <div class="progress ms-2" style="width: 60px; height: 8px;">
-                <div class="progress-bar ${user.weekly_hours >= 20 ? 'bg-success' : user.weekly_hours >= 10 ? 'bg-warning' : 'bg-danger'}" 
-                     style="width: ${Math.min((user.weekly_hours / 20) * 100, 100)}%"></div>
-            </div>
-        </div>`,
+        renderWeeklyHoursCell(user),
         `${user.monthly_hours.toFixed(1)}h`,
         `${(user.total_working_time / 3600).toFixed(1)}h`,
         `<small class="text-muted">${new Date(user.last_activity).toLocaleDateString('en-US', {
@@ -484,15 +506,12 @@ function updateProgressChart() {
     
     const userNames = topUsers.map(u => u.name.split(' ')[0]);
     const weeklyHours = topUsers.map(u => u.weekly_hours);
-    const target = 20;
+    const targets = topUsers.map(u => getWeeklyTarget(u));
     
     progressChart.data.labels = userNames;
     progressChart.data.datasets[0].data = weeklyHours;
-    progressChart.data.datasets[0].backgroundColor = weeklyHours.map(hours => 
-        hours >= target ? '#28a745' : 
-        hours >= target * 0.5 ? '#ffc107' : '#dc3545'
-    );
-    progressChart.data.datasets[1].data = new Array(userNames.length).fill(target);
+    progressChart.data.datasets[0].backgroundColor = weeklyHours.map((hours, i) => getProgressColor(hours, targets[i]));
+    progressChart.data.datasets[1].data = targets;
     progressChart.update();
 }
 
@@ -556,14 +575,14 @@ function refreshData() {
     });
 }
 
-// Export data
-function exportData(type) {
-    const exportUrl = `/api/export/excel?type=${type}`;
+// Export data as an .xlsx workbook or CSV
+function exportData(type, format = 'xlsx') {
+    const exportUrl = `/api/export/excel?type=${type}&format=${format}`;
     
     // Create temporary link and click it
     const link = document.createElement('a');
     link.href = exportUrl;
-    link.download = `time_tracker_${type}_${new Date().toISOString().split('T')[0]}.csv`;
+    link.download = `time_tracker_${type}_${new Date().toISOString().split('T')[0]}.${format}`;
     document.body.appendChild(link);
     link.click();
     document.body.removeChild(link);
@@ -571,6 +590,774 @@ function exportData(type) {
     showConnectionStatus(`${type.charAt(0).toUpperCase() + type.slice(1)} report exported`, 'success');
 }
 
+// Load the registered export formats into the export menu, each exporting the previous month
+function loadExportFormats() {
+    if (!$('#exportFormats').length) return;
+
+    $.ajax({
+        url: '/api/export/formats',
+        method: 'GET',
+        success: function(data) {
+            const menu = $('#exportFormats').empty();
+            const now = new Date();
+            const previous = new Date(now.getFullYear(), now.getMonth() - 1, 1);
+            const month = formatApiDate(previous).substring(0, 7);
+
+            data.formats.forEach(function(format) {
+                const item = $('<a class="dropdown-item"></a>')
+                    .attr('href', `/api/export?format=${encodeURIComponent(format.name)}&month=${month}`)
+                    .attr('title', format.description)
+                    .text(`${format.name} (${month})`);
+                menu.append($('<li></li>').append(item));
+            });
+        }
+    });
+}
+
+// Load the per-team analytics cards
+function loadTeamAnalytics() {
+    if (!$('#teamCards').length) return;
+
+    $.ajax({
+        url: '/api/teams/analytics',
+        method: 'GET',
+        success: function(data) {
+            renderTeamCards(data.teams || []);
+        }
+    });
+}
+
+// Render one card per team with members working now and hours against the team target
+function renderTeamCards(teams) {
+    const container = $('#teamCards').empty();
+
+    teams.forEach(function(team) {
+        const name = $('<div>').text(team.team).html();
+        const completion = Math.min(team.weekly_completion, 100);
+        const barClass = team.weekly_completion >= 100 ? 'bg-success' : team.weekly_completion >= 50 ? 'bg-warning' : 'bg-danger';
+
+        container.append(`<div class="col-md-3 mb-3">
+            <div class="card h-100">
+                <div class="card-body">
+                    <div class="d-flex justify-content-between">
+                        <h6 class="card-title mb-1"><i class="fas fa-people-group me-2"></i>${name}</h6>
+                        <span class="badge bg-success" title="Currently working">${team.active_users}/${team.total_users}</span>
+                    </div>
+                    <p class="card-text mb-1">${team.total_weekly_hours.toFixed(1)}h of ${team.weekly_target.toFixed(1)}h this week</p>
+                    <div class="progress mb-1" style="height: 6px;">
+                        <div class="progress-bar ${barClass}" style="width: ${completion}%"></div>
+                    </div>
+                    <small class="text-muted">${team.total_monthly_hours.toFixed(1)}h of ${team.monthly_target.toFixed(1)}h this month</small>
+                </div>
+            </div>
+        </div>`);
+    });
+}
+
+// Load absences into the absences table
+function loadAbsences() {
+    if (!$('#absencesTable').length) return;
+
+    const status = $('#absenceStatusFilter').val();
+    $.ajax({
+        url: '/api/absences' + (status ? `?status=${status}` : ''),
+        method: 'GET',
+        success: function(data) {
+            renderAbsences(data.absences || []);
+        },
+        error: function() {
+            showConnectionStatus('Failed to load absences', 'danger');
+        }
+    });
+}
+
+// Render absence rows with review actions
+function renderAbsences(absences) {
+    const statusBadges = { requested: 'bg-warning', approved: 'bg-success', rejected: 'bg-secondary' };
+    const tbody = $('#absencesTable tbody').empty();
+
+    if (absences.length === 0) {
+        tbody.append('<tr><td colspan="8" class="text-center text-muted">No absences</td></tr>');
+        return;
+    }
+
+    absences.forEach(function(absence) {
+        const name = $('<div>').text(absence.user ? absence.user.name : `User ${absence.user_id}`).html();
+        const note = $('<div>').text(absence.note || '').html();
+        const from = absence.start_date.split('T')[0] + (absence.start_half_day ? ' (½)' : '');
+        const to = absence.end_date.split('T')[0] + (absence.end_half_day ? ' (½)' : '');
+
+        let actions = '';
+        if (absence.status !== 'approved') {
+            actions += `<button class="btn btn-sm btn-outline-success me-1" title="Approve" onclick="reviewAbsence(${absence.id}, 'approve')"><i class="fas fa-check"></i></button>`;
+        }
+        if (absence.status !== 'rejected') {
+            actions += `<button class="btn btn-sm btn-outline-warning me-1" title="Reject" onclick="reviewAbsence(${absence.id}, 'reject')"><i class="fas fa-times"></i></button>`;
+        }
+        actions += `<button class="btn btn-sm btn-outline-danger" title="Delete" onclick="deleteAbsence(${absence.id})"><i class="fas fa-trash"></i></button>`;
+
+        tbody.append(`<tr>
+            <td>${name}</td>
+            <td>${absence.type}</td>
+            <td>${from}</td>
+            <td>${to}</td>
+            <td><span class="badge ${statusBadges[absence.status] || 'bg-secondary'}">${absence.status}</span></td>
+            <td>${absence.source}</td>
+            <td><small>${note}</small></td>
+            <td class="text-nowrap">${actions}</td>
+        </tr>`);
+    });
+}
+
+// Create an absence from the absence form
+function createAbsence(event) {
+    event.preventDefault();
+
+    const payload = {
+        user_id: parseInt($('#absenceUser').val(), 10),
+        type: $('#absenceType').val(),
+        start_date: $('#absenceStart').val(),
+        end_date: $('#absenceEnd').val() || $('#absenceStart').val(),
+        start_half_day: $('#absenceStartHalf').is(':checked'),
+        end_half_day: $('#absenceEndHalf').is(':checked'),
+        note: $('#absenceNote').val()
+    };
+
+    $.ajax({
+        url: '/api/absences',
+        method: 'POST',
+        contentType: 'application/json',
+        data: JSON.stringify(payload),
+        success: function() {
+            $('#absenceForm')[0].reset();
+            showConnectionStatus('Absence added', 'success');
+            loadAbsences();
+            refreshData();
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to add absence', 'danger');
+        }
+    });
+}
+
+// Approve or reject an absence
+function reviewAbsence(id, action) {
+    $.ajax({
+        url: `/api/absences/${id}/${action}`,
+        method: 'POST',
+        success: function() {
+            loadAbsences();
+            refreshData();
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to review absence', 'danger');
+        }
+    });
+}
+
+// Delete an absence
+function deleteAbsence(id) {
+    if (!confirm('Delete this absence?')) return;
+
+    $.ajax({
+        url: `/api/absences/${id}`,
+        method: 'DELETE',
+        success: function() {
+            loadAbsences();
+            refreshData();
+        },
+        error: function() {
+            showConnectionStatus('Failed to delete absence', 'danger');
+        }
+    });
+}
+
+// Load the compliance violations of the last 30 days
+function loadViolations() {
+    if (!$('#violationsTable').length) return;
+
+    const from = new Date(Date.now() - 30 * 24 * 3600 * 1000).toISOString().split('T')[0];
+    $.ajax({
+        url: `/api/compliance/violations?from=${from}`,
+        method: 'GET',
+        success: function(data) {
+            renderViolations(data.violations || []);
+        },
+        error: function() {
+            showConnectionStatus('Failed to load compliance violations', 'danger');
+        }
+    });
+}
+
+// Render compliance violation rows
+function renderViolations(violations) {
+    const ruleLabels = { max_daily_hours: 'Max daily hours', min_rest: 'Minimum rest', break: 'Mandatory break' };
+    const tbody = $('#violationsTable tbody').empty();
+
+    if (violations.length === 0) {
+        tbody.append('<tr><td colspan="5" class="text-center text-muted">No violations</td></tr>');
+        return;
+    }
+
+    violations.forEach(function(violation) {
+        const name = $('<div>').text(violation.user ? violation.user.name : `User ${violation.user_id}`).html();
+        const message = $('<div>').text(violation.message).html();
+        const ruleSet = $('<div>').text(violation.rule_set_name).html();
+
+        tbody.append(`<tr>
+            <td>${violation.date.split('T')[0]}</td>
+            <td>${name}</td>
+            <td><span class="badge bg-danger">${ruleLabels[violation.rule] || violation.rule}</span></td>
+            <td><small>${message}</small></td>
+            <td><small class="text-muted">${ruleSet}</small></td>
+        </tr>`);
+    });
+}
+
+// Re-evaluate compliance for the last 30 days
+function evaluateCompliance() {
+    $.ajax({
+        url: '/api/compliance/evaluate',
+        method: 'POST',
+        success: function(data) {
+            showConnectionStatus(`Compliance checked: ${data.violations} violation(s)`, 'success');
+            loadViolations();
+        },
+        error: function() {
+            showConnectionStatus('Failed to evaluate compliance', 'danger');
+        }
+    });
+}
+
+// Load a user's daily timeline
+function loadTimeline(event) {
+    if (event) event.preventDefault();
+    const userId = $('#timelineUser').val();
+    if (!userId) return;
+
+    const date = $('#timelineDate').val();
+    $.ajax({
+        url: `/api/users/${userId}/timeline` + (date ? `?date=${date}` : ''),
+        method: 'GET',
+        success: function(data) {
+            renderTimeline(data);
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to load timeline', 'danger');
+        }
+    });
+}
+
+// Download the PDF timesheet of the timeline's user for the month of the selected day
+function downloadMonthlyTimesheet() {
+    const userId = $('#timelineUser').val();
+    if (!userId) return;
+
+    const date = $('#timelineDate').val() || formatApiDate(new Date());
+    window.location.href = `/api/users/${userId}/timesheet?month=${date.substring(0, 7)}&format=pdf`;
+}
+
+// Issue a new calendar feed URL for the timeline's user and show it for copying
+function createCalendarFeed() {
+    const userId = $('#timelineUser').val();
+    if (!userId) return;
+    if (!confirm('Create a new calendar feed URL? The previous URL of this user stops working.')) return;
+
+    $.ajax({
+        url: `/api/users/${userId}/calendar-feed`,
+        method: 'POST',
+        success: function(data) {
+            prompt('Calendar feed URL (shown only once, subscribe to it in a calendar app):', data.url);
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to create calendar feed', 'danger');
+        }
+    });
+}
+
+// Revoke the calendar feed URL of the timeline's user
+function revokeCalendarFeed() {
+    const userId = $('#timelineUser').val();
+    if (!userId) return;
+    if (!confirm('Revoke the calendar feed URL of this user?')) return;
+
+    $.ajax({
+        url: `/api/users/${userId}/calendar-feed`,
+        method: 'DELETE',
+        success: function() {
+            showConnectionStatus('Calendar feed revoked', 'success');
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to revoke calendar feed', 'danger');
+        }
+    });
+}
+
+// Render timeline segments as Gantt-style bars, one lane for statuses and one for entries
+function renderTimeline(timeline) {
+    const container = $('#timeline').empty();
+    const dayStart = new Date(timeline.start).getTime();
+    const dayLength = new Date(timeline.end).getTime() - dayStart;
+
+    if (timeline.segments.length === 0) {
+        container.append('<p class="text-muted text-center mb-0">No activity on this day</p>');
+        return;
+    }
+
+    const lanes = { status: 'Slack status', entry: 'Time entries' };
+    Object.keys(lanes).forEach(function(kind) {
+        const track = $('<div class="timeline-track"></div>');
+        timeline.segments.filter(s => s.kind === kind).forEach(function(segment) {
+            const start = new Date(segment.start);
+            const end = new Date(segment.end);
+            const left = (start.getTime() - dayStart) / dayLength * 100;
+            const width = (end.getTime() - start.getTime()) / dayLength * 100;
+            const label = [segment.emoji, segment.text || segment.classification].filter(Boolean).join(' ');
+            const title = `${start.toLocaleTimeString()} - ${segment.open ? 'now' : end.toLocaleTimeString()}: ${label} ` +
+                `(${segment.classification}, ${segment.source}, ${segment.presence})`;
+            const cssClass = kind === 'entry' ? 'entry' : segment.classification;
+
+            $('<div class="timeline-segment"></div>')
+                .addClass(cssClass)
+                .toggleClass('open', segment.open)
+                .css({ left: `${left}%`, width: `${width}%` })
+                .attr('title', title)
+                .text(label)
+                .appendTo(track);
+        });
+
+        $('<div class="timeline-lane"></div>')
+            .append($('<div class="timeline-lane-label"></div>').text(lanes[kind]))
+            .append(track)
+            .appendTo(container);
+    });
+
+    const axis = $('<div class="timeline-axis"></div>');
+    for (let hour = 0; hour <= 24; hour += 3) {
+        axis.append(`<span style="left: ${hour / 24 * 100}%">${String(hour).padStart(2, '0')}:00</span>`);
+    }
+    container.append(axis);
+}
+
+// Format a date as YYYY-MM-DD in local time for API parameters
+function formatApiDate(date) {
+    return `${date.getFullYear()}-${String(date.getMonth() + 1).padStart(2, '0')}-${String(date.getDate()).padStart(2, '0')}`;
+}
+
+// Load the hours trend chart for the selected interval and breakdown
+function loadTrend() {
+    if (!document.getElementById('trendChart')) return;
+
+    const interval = $('#trendInterval').val();
+    const by = $('#trendBy').val();
+    const from = new Date();
+    from.setDate(from.getDate() - (interval === 'week' ? 7 * 12 : 29));
+
+    $.ajax({
+        url: `/api/charts/timeseries?interval=${interval}&by=${by}&from=${formatApiDate(from)}&to=${formatApiDate(new Date())}`,
+        method: 'GET',
+        success: function(data) {
+            renderTrend(data);
+        },
+        error: function() {
+            showConnectionStatus('Failed to load hours trend', 'danger');
+        }
+    });
+}
+
+// Render the time series as a line chart, one line per series
+function renderTrend(data) {
+    const colors = ['#007bff', '#28a745', '#fd7e14', '#6f42c1', '#dc3545', '#20c997', '#ffc107', '#6c757d'];
+    const datasets = data.series.map(function(line, i) {
+        const color = colors[i % colors.length];
+        return {
+            label: line.label,
+            data: line.values,
+            borderColor: color,
+            backgroundColor: color,
+            borderWidth: 2,
+            pointRadius: 2,
+            tension: 0.2,
+            fill: false
+        };
+    });
+
+    if (trendChart) {
+        trendChart.data.labels = data.labels;
+        trendChart.data.datasets = datasets;
+        trendChart.update();
+        return;
+    }
+
+    trendChart = new Chart(document.getElementById('trendChart'), {
+        type: 'line',
+        data: { labels: data.labels, datasets: datasets },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            plugins: {
+                legend: { position: 'top' },
+                tooltip: { mode: 'index', intersect: false }
+            },
+            scales: {
+                y: {
+                    beginAtZero: true,
+                    ticks: {
+                        callback: function(value) {
+                            return value + 'h';
+                        }
+                    }
+                }
+            }
+        }
+    });
+}
+
+// Load the weekday by hour activity heatmap for the last 30 days
+function loadHeatmap() {
+    if (!$('#heatmap').length) return;
+
+    const from = new Date();
+    from.setDate(from.getDate() - 29);
+    $.ajax({
+        url: `/api/charts/heatmap?from=${formatApiDate(from)}&to=${formatApiDate(new Date())}`,
+        method: 'GET',
+        success: function(data) {
+            renderHeatmap(data);
+        },
+        error: function() {
+            showConnectionStatus('Failed to load activity heatmap', 'danger');
+        }
+    });
+}
+
+// Render the heatmap as a table shaded by the hours tracked in each weekday and hour
+function renderHeatmap(data) {
+    const table = $('<table class="heatmap-table"></table>');
+    const header = $('<tr><th></th></tr>');
+    for (let hour = 0; hour < 24; hour++) {
+        header.append(`<th>${hour % 3 === 0 ? hour : ''}</th>`);
+    }
+    table.append(header);
+
+    data.weekdays.forEach(function(weekday, day) {
+        const row = $('<tr></tr>').append(`<th>${weekday}</th>`);
+        data.hours[day].forEach(function(hours, hour) {
+            const intensity = data.max > 0 ? hours / data.max : 0;
+            $('<td></td>')
+                .css('background-color', intensity > 0 ? `rgba(40, 167, 69, ${0.15 + intensity * 0.85})` : '#f1f3f5')
+                .attr('title', `${weekday} ${String(hour).padStart(2, '0')}:00 - ${hours.toFixed(2)}h`)
+                .appendTo(row);
+        });
+        table.append(row);
+    });
+
+    $('#heatmap').empty().append(table);
+}
+
+// Load the most recent archived reports
+function loadReportArchive() {
+    if (!$('#reportArchiveTable').length) return;
+
+    $.ajax({
+        url: '/api/reports/archive?limit=20',
+        method: 'GET',
+        success: function(data) {
+            renderReportArchive(data.reports || []);
+        },
+        error: function() {
+            showConnectionStatus('Failed to load report archive', 'danger');
+        }
+    });
+}
+
+// Render archived report rows with download links and the Slack upload result
+function renderReportArchive(reports) {
+    const tbody = $('#reportArchiveTable tbody').empty();
+
+    if (reports.length === 0) {
+        tbody.append('<tr><td colspan="5" class="text-center text-muted">No scheduled reports yet</td></tr>');
+        return;
+    }
+
+    reports.forEach(function(report) {
+        const schedule = $('<div>').text(report.schedule_name).html();
+        const filename = $('<div>').text(report.filename).html();
+        let slack = '<span class="text-muted">-</span>';
+        if (report.slack_error) {
+            slack = `<span class="badge bg-danger" title="${$('<div>').text(report.slack_error).html()}">Failed</span>`;
+        } else if (report.slack_channel) {
+            slack = '<span class="badge bg-success">Uploaded</span>';
+        }
+
+        tbody.append(`<tr>
+            <td>${formatDate(report.created_at)}</td>
+            <td>${schedule}</td>
+            <td>${report.from.split('T')[0]} &ndash; ${report.to.split('T')[0]}</td>
+            <td><a href="/api/reports/archive/${report.id}/download"><i class="fas fa-download me-1"></i>${filename}</a> <small class="text-muted">(${(report.size / 1024).toFixed(1)} KB)</small></td>
+            <td>${slack}</td>
+        </tr>`);
+    });
+}
+
+// Upload the selected CSV file as a dry run preview or as an import
+function importTimeEntries(dryRun) {
+    const file = $('#importFile')[0].files[0];
+    if (!file) {
+        showConnectionStatus('Select a CSV file to import', 'warning');
+        return;
+    }
+
+    const form = new FormData();
+    form.append('file', file);
+    form.append('format', $('#importFormat').val());
+    form.append('dry_run', dryRun ? 'true' : 'false');
+
+    $.ajax({
+        url: '/api/imports',
+        method: 'POST',
+        data: form,
+        processData: false,
+        contentType: false,
+        success: function(result) {
+            renderImportPreview(result);
+            if (!dryRun) {
+                showConnectionStatus(`Imported ${result.valid} entries`, 'success');
+                $('#importFile').val('');
+                loadImportBatches();
+                refreshData();
+            }
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to import time entries', 'danger');
+        }
+    });
+}
+
+// Render the summary of an import and the rows it skips
+function renderImportPreview(result) {
+    const preview = $('#importPreview').empty();
+    const verb = result.dry_run ? 'Would import' : 'Imported';
+    preview.append(`<p class="mb-2">${verb} <strong>${result.valid}</strong> of ${result.rows} rows (${result.hours.toFixed(2)}h), ${result.skipped} skipped.</p>`);
+
+    const skipped = (result.items || []).filter(function(row) { return row.error; });
+    if (skipped.length === 0) return;
+
+    const list = $('<ul class="small text-danger mb-0"></ul>');
+    skipped.forEach(function(row) {
+        $('<li></li>').text(`Line ${row.line}: ${row.error}`).appendTo(list);
+    });
+    preview.append(list);
+}
+
+// Load the import batches
+function loadImportBatches() {
+    if (!$('#importBatchesTable').length) return;
+
+    $.ajax({
+        url: '/api/imports',
+        method: 'GET',
+        success: function(data) {
+            renderImportBatches(data.imports || []);
+        },
+        error: function() {
+            showConnectionStatus('Failed to load imports', 'danger');
+        }
+    });
+}
+
+// Render import batches with a rollback button for batches that are still imported
+function renderImportBatches(batches) {
+    const tbody = $('#importBatchesTable tbody').empty();
+
+    if (batches.length === 0) {
+        tbody.append('<tr><td colspan="6" class="text-center text-muted">No imports yet</td></tr>');
+        return;
+    }
+
+    batches.forEach(function(batch) {
+        const filename = $('<div>').text(batch.filename).html();
+        const createdBy = $('<div>').text(batch.created_by || '-').html();
+        let status = `<button type="button" class="btn btn-sm btn-outline-danger" onclick="rollbackImportBatch(${batch.id})"><i class="fas fa-rotate-left me-1"></i>Roll back</button>`;
+        if (batch.status === 'rolled_back') {
+            status = `<span class="badge bg-secondary" title="${$('<div>').text(batch.rolled_back_by || '').html()}">Rolled back</span>`;
+        }
+
+        tbody.append(`<tr>
+            <td>${formatDate(batch.created_at)}</td>
+            <td>${filename} <small class="text-muted">(${batch.format})</small></td>
+            <td>${batch.imported} <small class="text-muted">(${batch.skipped} skipped)</small></td>
+            <td>${batch.hours.toFixed(2)}h</td>
+            <td>${createdBy}</td>
+            <td>${status}</td>
+        </tr>`);
+    });
+}
+
+// Delete the entries of an import batch
+function rollbackImportBatch(batchId) {
+    if (!confirm('Roll back this import? All entries it created are deleted.')) return;
+
+    $.ajax({
+        url: `/api/imports/${batchId}/rollback`,
+        method: 'POST',
+        success: function() {
+            showConnectionStatus('Import rolled back', 'success');
+            loadImportBatches();
+            refreshData();
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to roll back import', 'danger');
+        }
+    });
+}
+
+// Load the project catalogue with this month's hours and amounts
+function loadProjects() {
+    if (!$('#projectsTable').length) return;
+
+    $.when($.ajax({ url: '/api/projects', method: 'GET' }), $.ajax({ url: '/api/projects/report', method: 'GET' }))
+        .done(function(projectsResult, reportResult) {
+            renderProjects(projectsResult[0].projects || [], reportResult[0]);
+        })
+        .fail(function() {
+            showConnectionStatus('Failed to load projects', 'danger');
+        });
+}
+
+// Render the projects with their report rows; unbooked time is shown last
+function renderProjects(projects, report) {
+    const tbody = $('#projectsTable tbody').empty();
+    const rows = {};
+    let unbooked = null;
+    (report.rows || []).forEach(function(row) {
+        if (row.project_id) {
+            rows[row.project_id] = row;
+        } else {
+            unbooked = row;
+        }
+    });
+
+    if (projects.length === 0 && !unbooked) {
+        tbody.append('<tr><td colspan="7" class="text-center text-muted">No projects yet. Add one or set a status like "Working on #project"</td></tr>');
+        return;
+    }
+
+    projects.forEach(function(project) {
+        const row = rows[project.id] || { rounded_hours: 0, billable_hours: 0, amount: 0 };
+        const name = $('<div>').text(project.name).html();
+        const tag = $('<div>').text(project.tag).html();
+        const client = $('<div>').text(project.client || '-').html();
+        const billable = project.billable
+            ? '<span class="badge bg-success">Billable</span>'
+            : '<span class="badge bg-secondary">Non-billable</span>';
+
+        tbody.append(`<tr>
+            <td>${name} <small class="text-muted">#${tag}</small></td>
+            <td>${client}</td>
+            <td>${project.hourly_rate.toFixed(2)} ${billable}</td>
+            <td>${row.rounded_hours.toFixed(2)}h</td>
+            <td>${row.billable_hours.toFixed(2)}h</td>
+            <td>${row.amount.toFixed(2)}</td>
+            <td class="text-nowrap">
+                <button class="btn btn-sm btn-outline-primary me-1" title="Edit rate" onclick="editProjectRate(${project.id}, ${project.hourly_rate})"><i class="fas fa-pen"></i></button>
+                <button class="btn btn-sm btn-outline-secondary me-1" title="Toggle billable" onclick="updateProject(${project.id}, { billable: ${!project.billable} })"><i class="fas fa-file-invoice-dollar"></i></button>
+                <button class="btn btn-sm btn-outline-danger" title="Delete" onclick="deleteProject(${project.id})"><i class="fas fa-trash"></i></button>
+            </td>
+        </tr>`);
+    });
+
+    if (unbooked) {
+        tbody.append(`<tr class="text-muted">
+            <td>${$('<div>').text(unbooked.name).html()}</td>
+            <td>-</td>
+            <td>-</td>
+            <td>${unbooked.rounded_hours.toFixed(2)}h</td>
+            <td>-</td>
+            <td>-</td>
+            <td></td>
+        </tr>`);
+    }
+}
+
+// Create a project from the project form
+function createProject(event) {
+    event.preventDefault();
+
+    const payload = {
+        tag: $('#projectTag').val(),
+        name: $('#projectName').val(),
+        client: $('#projectClient').val(),
+        hourly_rate: parseFloat($('#projectRate').val()) || 0,
+        billable: $('#projectBillable').is(':checked')
+    };
+
+    $.ajax({
+        url: '/api/projects',
+        method: 'POST',
+        contentType: 'application/json',
+        data: JSON.stringify(payload),
+        success: function() {
+            $('#projectForm')[0].reset();
+            showConnectionStatus('Project added', 'success');
+            loadProjects();
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to add project', 'danger');
+        }
+    });
+}
+
+// Save changes to a project
+function updateProject(id, changes) {
+    $.ajax({
+        url: `/api/projects/${id}`,
+        method: 'PUT',
+        contentType: 'application/json',
+        data: JSON.stringify(changes),
+        success: function() {
+            loadProjects();
+        },
+        error: function(xhr) {
+            showConnectionStatus((xhr.responseJSON && xhr.responseJSON.error) || 'Failed to update project', 'danger');
+        }
+    });
+}
+
+// Ask for a new hourly rate of a project
+function editProjectRate(id, currentRate) {
+    const value = prompt('Hourly rate', currentRate);
+    if (value === null) return;
+
+    const rate = parseFloat(value);
+    if (isNaN(rate)) {
+        showConnectionStatus('Invalid hourly rate', 'danger');
+        return;
+    }
+    updateProject(id, { hourly_rate: rate });
+}
+
+// Delete a project; its entries are no longer booked on it
+function deleteProject(id) {
+    if (!confirm('Delete this project? Its time entries are kept without a project.')) return;
+
+    $.ajax({
+        url: `/api/projects/${id}`,
+        method: 'DELETE',
+        success: function() {
+            loadProjects();
+        },
+        error: function() {
+            showConnectionStatus('Failed to delete project', 'danger');
+        }
+    });
+}
+
+// Download this month's project report
+function downloadProjectReport(format) {
+    window.location.href = `/api/projects/report?format=${format}`;
+}
+
 // Utility function to format duration
 function formatDuration(seconds) {
     const hours = Math.floor(seconds / 3600);
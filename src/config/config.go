@@ -20,6 +20,32 @@ type Config struct {
 	LogMaxSize         int    // Maximum size in MB before rotation
 	LogMaxBackups      int    // Maximum number of backup files to keep
 	LogMaxAge          int    // Maximum number of days to retain logs
+	DefaultWeeklyHours float64 // Weekly target for users without a contract
+	DefaultWorkingDays string  // Working days for users without a contract, e.g. "mon,tue,wed,thu,fri"
+	AbsenceAutoApprove bool    // Approve absences detected from Slack statuses without review
+	HolidayCalendars   string  // .ics file or directory of .ics files imported at startup
+	FlextimeStart      string  // First day (YYYY-MM-DD) counted in flextime balances, empty for each user's first activity
+	FlextimeMaxHours   float64 // Balance cap in hours, excess is forfeited weekly (0 for no cap)
+	FlextimeMinHours   float64 // Largest allowed deficit in hours (0 for no limit)
+	FlextimeCarryOver  float64 // Hours of positive balance carried into a new year (0 carries everything)
+	MaxDailyHours      float64 // Default compliance limit on daily working hours (0 disables)
+	MinRestHours       float64 // Default minimum rest between working days (0 disables)
+	BreakAfterHours    float64 // Default working hours after which a break is mandatory (0 disables)
+	MinBreakMinutes    int     // Default length of the mandatory break
+	ComplianceWarnMins int     // Minutes before a limit at which users are warned in Slack (0 disables)
+	BreakDeductions    string  // Break tiers deducted at report time, e.g. "6:30,9:45" (hours:minutes)
+	RoundingMode       string  // Export rounding: none, nearest, up or down
+	RoundingMinutes    int     // Rounding increment in minutes
+	RoundingScope      string  // Round each entry ("entry") or each day's total ("day")
+	ReportArchiveDir   string  // Directory scheduled reports are stored in
+	ReportRetainDays   int     // Days archived reports are kept (0 keeps them forever)
+	PublicURL          string  // Base URL of links shared outside the dashboard, e.g. calendar feeds
+	CalendarFeedDays   int     // Days of past sessions in calendar feeds
+	SlackUserGroupSync bool    // Sync teams from Slack user groups
+	SlackTeamChannels  string  // Comma-separated Slack channel IDs whose members are synced as teams
+	TeamSyncMinutes    int     // Interval of the periodic Slack team sync
+	ProjectTagPattern  string  // Regular expression of project tags in status texts; the first group is the tag
+	ProjectAutoCreate  bool    // Add unknown tags to the project catalogue
 }
 
 var AppConfig *Config
@@ -40,6 +66,32 @@ func Init() {
 		LogMaxSize:         GetIntEnv("LOG_MAX_SIZE_MB", 10),
 		LogMaxBackups:      GetIntEnv("LOG_MAX_BACKUPS", 5),
 		LogMaxAge:          GetIntEnv("LOG_MAX_AGE_DAYS", 30),
+		DefaultWeeklyHours: getFloatEnv("DEFAULT_WEEKLY_HOURS", 20),
+		DefaultWorkingDays: getEnvOrDefault("DEFAULT_WORKING_DAYS", "mon,tue,wed,thu,fri"),
+		AbsenceAutoApprove: getBoolEnv("ABSENCE_AUTO_APPROVE", false),
+		HolidayCalendars:   os.Getenv("HOLIDAY_CALENDAR_PATH"),
+		FlextimeStart:      os.Getenv("FLEXTIME_START_DATE"),
+		FlextimeMaxHours:   getFloatEnv("FLEXTIME_MAX_HOURS", 0),
+		FlextimeMinHours:   getFloatEnv("FLEXTIME_MIN_HOURS", 0),
+		FlextimeCarryOver:  getFloatEnv("FLEXTIME_CARRYOVER_HOURS", 0),
+		MaxDailyHours:      getFloatEnv("COMPLIANCE_MAX_DAILY_HOURS", 10),
+		MinRestHours:       getFloatEnv("COMPLIANCE_MIN_REST_HOURS", 11),
+		BreakAfterHours:    getFloatEnv("COMPLIANCE_BREAK_AFTER_HOURS", 6),
+		MinBreakMinutes:    GetIntEnv("COMPLIANCE_MIN_BREAK_MINUTES", 30),
+		ComplianceWarnMins: GetIntEnv("COMPLIANCE_WARN_MINUTES", 0),
+		BreakDeductions:    getEnvOrDefault("BREAK_DEDUCTION_POLICY", "6:30"),
+		RoundingMode:       getEnvOrDefault("ROUNDING_MODE", "none"),
+		RoundingMinutes:    GetIntEnv("ROUNDING_MINUTES", 15),
+		RoundingScope:      getEnvOrDefault("ROUNDING_SCOPE", "entry"),
+		ReportArchiveDir:   getEnvOrDefault("REPORT_ARCHIVE_DIR", "./data/reports"),
+		ReportRetainDays:   GetIntEnv("REPORT_RETENTION_DAYS", 90),
+		PublicURL:          os.Getenv("PUBLIC_URL"),
+		CalendarFeedDays:   GetIntEnv("CALENDAR_FEED_DAYS", 90),
+		SlackUserGroupSync: getBoolEnv("SLACK_USERGROUP_SYNC", false),
+		SlackTeamChannels:  os.Getenv("SLACK_TEAM_CHANNELS"),
+		TeamSyncMinutes:    GetIntEnv("TEAM_SYNC_MINUTES", 60),
+		ProjectTagPattern:  getEnvOrDefault("PROJECT_TAG_PATTERN", `#([\w-]+)`),
+		ProjectAutoCreate:  getBoolEnv("PROJECT_AUTO_CREATE", true),
 	}
 }
 
@@ -59,6 +111,15 @@ func GetIntEnv(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getBoolEnv(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
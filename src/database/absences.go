@@ -0,0 +1,190 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidAbsence is returned when absence fields fail validation
+	ErrInvalidAbsence = errors.New("invalid absence")
+	// ErrAbsenceOverlap is returned when an absence overlaps another absence of the same user
+	ErrAbsenceOverlap = errors.New("absence overlaps an existing absence")
+)
+
+// validAbsenceTypes lists the accepted absence types
+var validAbsenceTypes = map[string]bool{
+	AbsenceTypeVacation: true,
+	AbsenceTypeSick:     true,
+	AbsenceTypeUnpaid:   true,
+	AbsenceTypeOther:    true,
+}
+
+// AbsenceFilter holds the optional filters for listing absences
+type AbsenceFilter struct {
+	UserID uint
+	Status string
+	Type   string
+	From   *time.Time // Absences ending on or after this day
+	To     *time.Time // Absences starting before this day
+}
+
+// validateAbsence normalizes absence dates and checks its fields and overlaps
+func validateAbsence(absence *Absence) error {
+	if !validAbsenceTypes[absence.Type] {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidAbsence, absence.Type)
+	}
+
+	absence.StartDate = dayStart(absence.StartDate)
+	absence.EndDate = dayStart(absence.EndDate)
+	if absence.EndDate.Before(absence.StartDate) {
+		return fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidAbsence)
+	}
+
+	var count int64
+	err := DB.Model(&Absence{}).
+		Where("user_id = ? AND id <> ? AND status <> ?", absence.UserID, absence.ID, AbsenceStatusRejected).
+		Where("start_date <= ? AND end_date >= ?", absence.EndDate, absence.StartDate).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAbsenceOverlap
+	}
+
+	return nil
+}
+
+// GetAbsence returns a single absence by ID
+func GetAbsence(absenceID uint) (*Absence, error) {
+	var absence Absence
+	err := DB.Preload("User").First(&absence, absenceID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &absence, nil
+}
+
+// GetAbsences returns absences matching the filter, ordered by start date
+func GetAbsences(filter AbsenceFilter) ([]Absence, error) {
+	query := DB.Preload("User")
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.From != nil {
+		query = query.Where("end_date >= ?", dayStart(*filter.From))
+	}
+	if filter.To != nil {
+		query = query.Where("start_date < ?", *filter.To)
+	}
+
+	var absences []Absence
+	err := query.Order("start_date ASC, id ASC").Find(&absences).Error
+	return absences, err
+}
+
+// CreateAbsence validates and stores a new absence
+func CreateAbsence(absence *Absence) error {
+	if err := validateAbsence(absence); err != nil {
+		return err
+	}
+	return DB.Omit("User").Create(absence).Error
+}
+
+// UpdateAbsence validates and saves changes to an absence
+func UpdateAbsence(absence *Absence) error {
+	if err := validateAbsence(absence); err != nil {
+		return err
+	}
+	return DB.Omit("User").Save(absence).Error
+}
+
+// ReviewAbsence approves or rejects an absence
+func ReviewAbsence(absence *Absence, status string, adminID uint) error {
+	now := time.Now()
+	absence.Status = status
+	absence.ReviewedBy = adminID
+	absence.ReviewedAt = &now
+	return DB.Omit("User").Save(absence).Error
+}
+
+// DeleteAbsence removes an absence
+func DeleteAbsence(absenceID uint) error {
+	return DB.Delete(&Absence{}, absenceID).Error
+}
+
+// RecordSlackAbsence records that a user's Slack status marks them absent from day until the
+// given last day. A Slack-detected absence of the same type that ends the day before or
+// overlaps is extended instead of creating a new one. Returns nil if nothing changed.
+func RecordSlackAbsence(userID uint, absenceType string, day, lastDay time.Time, autoApprove bool) (*Absence, error) {
+	day = dayStart(day)
+	lastDay = dayStart(lastDay)
+	if lastDay.Before(day) {
+		lastDay = day
+	}
+
+	var existing Absence
+	err := DB.Where("user_id = ? AND status <> ?", userID, AbsenceStatusRejected).
+		Where("start_date <= ? AND end_date >= ?", lastDay, day.AddDate(0, 0, -1)).
+		Order("start_date DESC").
+		First(&existing).Error
+	if err == nil {
+		// Only extend absences detected from Slack; admin-entered ones are left alone
+		if existing.Source != AbsenceSourceSlack || existing.Type != absenceType || !existing.EndDate.Before(lastDay) {
+			return nil, nil
+		}
+		existing.EndDate = lastDay
+		existing.EndHalfDay = false
+		return &existing, UpdateAbsence(&existing)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	absence := Absence{
+		UserID:    userID,
+		Type:      absenceType,
+		StartDate: day,
+		EndDate:   lastDay,
+		Status:    AbsenceStatusRequested,
+		Source:    AbsenceSourceSlack,
+		Note:      "Detected from Slack status",
+	}
+	if autoApprove {
+		now := time.Now()
+		absence.Status = AbsenceStatusApproved
+		absence.ReviewedAt = &now
+	}
+
+	return &absence, CreateAbsence(&absence)
+}
+
+// absenceFraction returns the share of a day (0, 0.5 or 1) covered by an absence
+func absenceFraction(absence Absence, day time.Time) float64 {
+	if day.Before(absence.StartDate) || day.After(absence.EndDate) {
+		return 0
+	}
+	fraction := 1.0
+	if day.Equal(absence.StartDate) && absence.StartHalfDay {
+		fraction -= 0.5
+	}
+	if day.Equal(absence.EndDate) && absence.EndHalfDay {
+		fraction -= 0.5
+	}
+	if fraction <= 0 {
+		// A single day marked half on both ends is a half day
+		fraction = 0.5
+	}
+	return fraction
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditFilter holds the optional filters for querying the audit log
+type AuditFilter struct {
+	ActorID  uint
+	Action   string
+	Entity   string
+	EntityID string
+	From     *time.Time
+	To       *time.Time
+}
+
+// auditSnapshot serializes an entity for the audit log, returning an empty string for nil
+func auditSnapshot(value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// CreateAuditLog records a mutation with before/after snapshots of the affected entity
+func CreateAuditLog(actorID uint, actorName, action, entity string, entityID interface{}, before, after interface{}) (*AuditLog, error) {
+	beforeJSON, err := auditSnapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	afterJSON, err := auditSnapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	log := AuditLog{
+		ActorID:   actorID,
+		ActorName: actorName,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  fmt.Sprint(entityID),
+		Before:    beforeJSON,
+		After:     afterJSON,
+		Timestamp: time.Now(),
+	}
+
+	err = DB.Create(&log).Error
+	return &log, err
+}
+
+// auditQuery builds the audit log query for the given filter
+func auditQuery(filter AuditFilter) *gorm.DB {
+	query := DB.Model(&AuditLog{})
+
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.From != nil {
+		query = query.Where("timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("timestamp < ?", *filter.To)
+	}
+
+	return query
+}
+
+// GetAuditLogs returns a page of audit log entries, newest first, and the total number of matches
+func GetAuditLogs(filter AuditFilter, page, perPage int) ([]AuditLog, int64, error) {
+	var total int64
+	if err := auditQuery(filter).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []AuditLog
+	err := auditQuery(filter).
+		Order("timestamp DESC, id DESC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// GetAllAuditLogs returns every audit log entry matching the filter, newest first
+func GetAllAuditLogs(filter AuditFilter) ([]AuditLog, error) {
+	var logs []AuditLog
+	err := auditQuery(filter).Order("timestamp DESC, id DESC").Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"sports-excitement-team-management/src/config"
+)
+
+// ErrInvalidBalanceAdjustment is returned when a balance adjustment fails validation
+var ErrInvalidBalanceAdjustment = errors.New("invalid balance adjustment")
+
+// BalanceWeek is one week of a user's flextime balance
+type BalanceWeek struct {
+	WeekStart     time.Time `json:"week_start"`
+	WorkedHours   float64   `json:"worked_hours"`
+	RequiredHours float64   `json:"required_hours"`
+	Adjustments   float64   `json:"adjustments"`  // Sum of manual adjustments
+	Payouts       float64   `json:"payouts"`      // Hours paid out
+	CarriedOver   float64   `json:"carried_over"` // Hours forfeited at the start of a new year (negative)
+	Capped        float64   `json:"capped"`       // Hours forfeited by the balance cap or floor
+	Balance       float64   `json:"balance"`      // Balance at the end of the week
+}
+
+// FlextimeBalance is a user's running overtime balance
+type FlextimeBalance struct {
+	UserID      uint                `json:"user_id"`
+	From        time.Time           `json:"from"`
+	Until       time.Time           `json:"until"`
+	Balance     float64             `json:"balance"`
+	Weeks       []BalanceWeek       `json:"weeks"`
+	Adjustments []BalanceAdjustment `json:"adjustments"`
+}
+
+// balanceStart returns the Monday from which a user's balance is computed: the week of
+// their first time entry or contract (or account creation), but not before FLEXTIME_START_DATE
+func balanceStart(user User) (time.Time, error) {
+	start := user.CreatedAt
+
+	var firstEntry TimeEntry
+	if err := DB.Where("user_id = ?", user.ID).Order("start_time ASC").Limit(1).Find(&firstEntry).Error; err != nil {
+		return time.Time{}, err
+	}
+	if firstEntry.ID != 0 && firstEntry.StartTime.Before(start) {
+		start = firstEntry.StartTime
+	}
+
+	var firstContract Contract
+	if err := DB.Where("user_id = ?", user.ID).Order("effective_from ASC").Limit(1).Find(&firstContract).Error; err != nil {
+		return time.Time{}, err
+	}
+	if firstContract.ID != 0 && firstContract.EffectiveFrom.Before(start) {
+		start = firstContract.EffectiveFrom
+	}
+
+	if config.AppConfig != nil && config.AppConfig.FlextimeStart != "" {
+		configured, err := time.ParseInLocation("2006-01-02", config.AppConfig.FlextimeStart, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid FLEXTIME_START_DATE: %w", err)
+		}
+		if configured.After(start) {
+			start = configured
+		}
+	}
+
+	return WeekStartOf(start), nil
+}
+
+// applyBalanceLimits clamps a balance to the configured cap and floor and returns
+// the clamped balance together with the forfeited hours
+func applyBalanceLimits(balance float64) (float64, float64) {
+	if config.AppConfig == nil {
+		return balance, 0
+	}
+	if limit := config.AppConfig.FlextimeMaxHours; limit > 0 && balance > limit {
+		return limit, limit - balance
+	}
+	if floor := -config.AppConfig.FlextimeMinHours; floor < 0 && balance < floor {
+		return floor, floor - balance
+	}
+	return balance, 0
+}
+
+// applyCarryOver limits the positive balance taken into a new year and returns the
+// carried balance together with the forfeited hours
+func applyCarryOver(balance float64) (float64, float64) {
+	if config.AppConfig == nil {
+		return balance, 0
+	}
+	if limit := config.AppConfig.FlextimeCarryOver; limit > 0 && balance > limit {
+		return limit, limit - balance
+	}
+	return balance, 0
+}
+
+// GetUserBalance computes a user's flextime balance week by week up to and including
+// the day of until. Worked hours are compared to the required hours, which already
+// account for contracts, approved absences and public holidays.
+func GetUserBalance(userID uint, until time.Time) (*FlextimeBalance, error) {
+	var user User
+	if err := DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	start, err := balanceStart(user)
+	if err != nil {
+		return nil, err
+	}
+	end := dayStart(until).AddDate(0, 0, 1)
+
+	result := &FlextimeBalance{UserID: userID, From: start, Until: dayStart(until)}
+	if !start.Before(end) {
+		return result, nil
+	}
+
+	schedule, err := loadWorkSchedule([]uint{userID}, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []TimeEntry
+	err = DB.Where("user_id = ? AND start_time >= ? AND start_time < ?", userID, start, end).Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	worked := make(map[time.Time]float64)
+	for _, entry := range entries {
+		worked[WeekStartOf(entry.StartTime)] += float64(entry.Duration) / 3600.0
+	}
+
+	err = DB.Where("user_id = ? AND date >= ? AND date < ?", userID, start, end).
+		Order("date ASC, id ASC").
+		Find(&result.Adjustments).Error
+	if err != nil {
+		return nil, err
+	}
+	adjustments := make(map[time.Time]float64)
+	payouts := make(map[time.Time]float64)
+	for _, adjustment := range result.Adjustments {
+		week := WeekStartOf(adjustment.Date)
+		if adjustment.Kind == BalanceAdjustmentPayout {
+			payouts[week] += adjustment.Hours
+		} else {
+			adjustments[week] += adjustment.Hours
+		}
+	}
+
+	balance := 0.0
+	for weekStart := start; weekStart.Before(end); weekStart = weekStart.AddDate(0, 0, 7) {
+		week := BalanceWeek{WeekStart: weekStart}
+
+		// Carry-over is applied in the first week that starts in a new year
+		if !weekStart.Equal(start) && weekStart.Year() != weekStart.AddDate(0, 0, -7).Year() {
+			balance, week.CarriedOver = applyCarryOver(balance)
+		}
+
+		weekEnd := weekStart.AddDate(0, 0, 7)
+		if weekEnd.After(end) {
+			weekEnd = end
+		}
+		week.WorkedHours = worked[weekStart]
+		week.RequiredHours = schedule.requiredHours(userID, weekStart, weekEnd)
+		week.Adjustments = adjustments[weekStart]
+		week.Payouts = payouts[weekStart]
+
+		balance += week.WorkedHours - week.RequiredHours + week.Adjustments - week.Payouts
+		balance, week.Capped = applyBalanceLimits(balance)
+		week.Balance = math.Round(balance*100) / 100
+
+		result.Weeks = append(result.Weeks, week)
+	}
+	result.Balance = math.Round(balance*100) / 100
+
+	return result, nil
+}
+
+// GetBalanceAdjustment returns a single balance adjustment by ID
+func GetBalanceAdjustment(adjustmentID uint) (*BalanceAdjustment, error) {
+	var adjustment BalanceAdjustment
+	err := DB.First(&adjustment, adjustmentID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+// CreateBalanceAdjustment validates and stores a balance adjustment or payout
+func CreateBalanceAdjustment(adjustment *BalanceAdjustment) error {
+	switch adjustment.Kind {
+	case BalanceAdjustmentManual:
+		if adjustment.Hours == 0 {
+			return fmt.Errorf("%w: hours must not be zero", ErrInvalidBalanceAdjustment)
+		}
+	case BalanceAdjustmentPayout:
+		if adjustment.Hours <= 0 {
+			return fmt.Errorf("%w: payout hours must be positive", ErrInvalidBalanceAdjustment)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidBalanceAdjustment, adjustment.Kind)
+	}
+
+	adjustment.Date = dayStart(adjustment.Date)
+	return DB.Create(adjustment).Error
+}
+
+// DeleteBalanceAdjustment removes a balance adjustment
+func DeleteBalanceAdjustment(adjustmentID uint) error {
+	return DB.Delete(&BalanceAdjustment{}, adjustmentID).Error
+}
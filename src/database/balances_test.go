@@ -0,0 +1,188 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetUserBalance(t *testing.T) {
+	// Four weeks with 10 required hours each; the week of 2026-01-05 is the first
+	// one that starts in the new year
+	start := time.Date(2025, 12, 15, 0, 0, 0, 0, time.Local)
+	until := time.Date(2026, 1, 11, 0, 0, 0, 0, time.Local)
+
+	type adjustment struct {
+		week  int
+		kind  string
+		hours float64
+	}
+	tests := []struct {
+		name        string
+		env         map[string]string
+		worked      [4]float64
+		adjustments []adjustment
+		balances    [4]float64
+		carriedOver [4]float64
+		capped      [4]float64
+	}{
+		{
+			name:     "no limits",
+			worked:   [4]float64{30, 10, 12, 10},
+			balances: [4]float64{20, 20, 22, 22},
+		},
+		{
+			name:        "carry-over at the year boundary",
+			env:         map[string]string{"FLEXTIME_CARRYOVER_HOURS": "5"},
+			worked:      [4]float64{30, 10, 12, 14},
+			balances:    [4]float64{20, 20, 22, 9},
+			carriedOver: [4]float64{0, 0, 0, -17},
+		},
+		{
+			name:     "carry-over keeps a deficit",
+			env:      map[string]string{"FLEXTIME_CARRYOVER_HOURS": "5"},
+			worked:   [4]float64{0, 10, 10, 10},
+			balances: [4]float64{-10, -10, -10, -10},
+		},
+		{
+			name:     "balance cap",
+			env:      map[string]string{"FLEXTIME_MAX_HOURS": "15"},
+			worked:   [4]float64{30, 14, 6, 10},
+			balances: [4]float64{15, 15, 11, 11},
+			capped:   [4]float64{-5, -4, 0, 0},
+		},
+		{
+			name:     "balance floor",
+			env:      map[string]string{"FLEXTIME_MIN_HOURS": "8"},
+			worked:   [4]float64{0, 0, 14, 10},
+			balances: [4]float64{-8, -8, -4, -4},
+			capped:   [4]float64{2, 10, 0, 0},
+		},
+		{
+			name:        "adjustments and payouts",
+			worked:      [4]float64{30, 10, 10, 10},
+			adjustments: []adjustment{{1, BalanceAdjustmentManual, 4}, {2, BalanceAdjustmentManual, -1.5}, {3, BalanceAdjustmentPayout, 6}},
+			balances:    [4]float64{20, 24, 22.5, 16.5},
+		},
+		{
+			name:        "adjustment above the cap",
+			env:         map[string]string{"FLEXTIME_MAX_HOURS": "15"},
+			worked:      [4]float64{20, 10, 10, 10},
+			adjustments: []adjustment{{1, BalanceAdjustmentManual, 8}},
+			balances:    [4]float64{10, 15, 15, 15},
+			capped:      [4]float64{0, -3, 0, 0},
+		},
+		{
+			name:        "payout before the carry-over",
+			env:         map[string]string{"FLEXTIME_CARRYOVER_HOURS": "5"},
+			worked:      [4]float64{30, 10, 10, 10},
+			adjustments: []adjustment{{2, BalanceAdjustmentPayout, 12}},
+			balances:    [4]float64{20, 20, 8, 5},
+			carriedOver: [4]float64{0, 0, 0, -3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"FLEXTIME_CARRYOVER_HOURS", "FLEXTIME_MAX_HOURS", "FLEXTIME_MIN_HOURS"} {
+				t.Setenv(key, tt.env[key])
+			}
+			t.Setenv("FLEXTIME_START_DATE", start.Format("2006-01-02"))
+			openTestDB(t)
+
+			user := createTestUser(t, "U1")
+			contract := Contract{UserID: user.ID, WeeklyHours: 10, WorkingDays: "mon,tue,wed,thu,fri", EffectiveFrom: start}
+			if err := DB.Create(&contract).Error; err != nil {
+				t.Fatalf("creating contract: %v", err)
+			}
+			for week, hours := range tt.worked {
+				if hours > 0 {
+					entryStart := start.AddDate(0, 0, 7*week).Add(8 * time.Hour)
+					createClosedEntry(t, user.ID, entryStart, entryStart.Add(time.Duration(hours*float64(time.Hour))))
+				}
+			}
+			for _, adj := range tt.adjustments {
+				adjustment := BalanceAdjustment{UserID: user.ID, Date: start.AddDate(0, 0, 7*adj.week+2), Kind: adj.kind, Hours: adj.hours}
+				if err := CreateBalanceAdjustment(&adjustment); err != nil {
+					t.Fatalf("creating adjustment: %v", err)
+				}
+			}
+
+			balance, err := GetUserBalance(user.ID, until)
+			if err != nil {
+				t.Fatalf("GetUserBalance: %v", err)
+			}
+			if len(balance.Weeks) != 4 {
+				t.Fatalf("got %d weeks, want 4", len(balance.Weeks))
+			}
+			for i, week := range balance.Weeks {
+				label := fmt.Sprintf("week of %s", week.WeekStart.Format("2006-01-02"))
+				if !week.WeekStart.Equal(start.AddDate(0, 0, 7*i)) {
+					t.Errorf("%s, want %s", label, start.AddDate(0, 0, 7*i).Format("2006-01-02"))
+				}
+				if math.Abs(week.RequiredHours-10) > 0.01 {
+					t.Errorf("%s: required %.2f, want 10", label, week.RequiredHours)
+				}
+				if math.Abs(week.Balance-tt.balances[i]) > 0.01 {
+					t.Errorf("%s: balance %.2f, want %.2f", label, week.Balance, tt.balances[i])
+				}
+				if math.Abs(week.CarriedOver-tt.carriedOver[i]) > 0.01 {
+					t.Errorf("%s: carried over %.2f, want %.2f", label, week.CarriedOver, tt.carriedOver[i])
+				}
+				if math.Abs(week.Capped-tt.capped[i]) > 0.01 {
+					t.Errorf("%s: capped %.2f, want %.2f", label, week.Capped, tt.capped[i])
+				}
+			}
+			for _, adj := range tt.adjustments {
+				week := balance.Weeks[adj.week]
+				got := week.Adjustments
+				if adj.kind == BalanceAdjustmentPayout {
+					got = week.Payouts
+				}
+				if math.Abs(got-adj.hours) > 0.01 {
+					t.Errorf("week %d: %s hours %.2f, want %.2f", adj.week, adj.kind, got, adj.hours)
+				}
+			}
+			if math.Abs(balance.Balance-tt.balances[3]) > 0.01 {
+				t.Errorf("balance = %.2f, want %.2f", balance.Balance, tt.balances[3])
+			}
+		})
+	}
+}
+
+func TestCreateBalanceAdjustmentValidation(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+
+	tests := []struct {
+		name    string
+		kind    string
+		hours   float64
+		wantErr bool
+	}{
+		{name: "positive adjustment", kind: BalanceAdjustmentManual, hours: 2},
+		{name: "negative adjustment", kind: BalanceAdjustmentManual, hours: -2},
+		{name: "zero adjustment", kind: BalanceAdjustmentManual, hours: 0, wantErr: true},
+		{name: "payout", kind: BalanceAdjustmentPayout, hours: 5},
+		{name: "negative payout", kind: BalanceAdjustmentPayout, hours: -5, wantErr: true},
+		{name: "unknown kind", kind: "bonus", hours: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adjustment := BalanceAdjustment{UserID: user.ID, Date: time.Now(), Kind: tt.kind, Hours: tt.hours}
+			err := CreateBalanceAdjustment(&adjustment)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateBalanceAdjustment error = %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidBalanceAdjustment) {
+				t.Errorf("error %v is not ErrInvalidBalanceAdjustment", err)
+			}
+			if err == nil && !adjustment.Date.Equal(dayStart(adjustment.Date)) {
+				t.Errorf("date %v is not the start of a day", adjustment.Date)
+			}
+		})
+	}
+}
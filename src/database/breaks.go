@@ -0,0 +1,175 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/utils"
+)
+
+// breakTier requires a minimum break once a day's work exceeds a threshold
+type breakTier struct {
+	after   time.Duration
+	minimum time.Duration
+}
+
+// parseBreakPolicy parses tiers such as "6:30,9:45": over 6 hours a 30 minute break is
+// required, over 9 hours a 45 minute one
+func parseBreakPolicy(policy string) ([]breakTier, error) {
+	var tiers []breakTier
+	for _, part := range strings.Split(policy, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		hours, minutes, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("break tier %q must look like hours:minutes", part)
+		}
+		after, err := strconv.ParseFloat(hours, 64)
+		if err != nil || after < 0 {
+			return nil, fmt.Errorf("invalid hours in break tier %q", part)
+		}
+		minimum, err := strconv.Atoi(minutes)
+		if err != nil || minimum < 0 {
+			return nil, fmt.Errorf("invalid minutes in break tier %q", part)
+		}
+		tiers = append(tiers, breakTier{
+			after:   time.Duration(after * float64(time.Hour)),
+			minimum: time.Duration(minimum) * time.Minute,
+		})
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].after < tiers[j].after })
+	return tiers, nil
+}
+
+// configuredBreakPolicy returns the break tiers from BREAK_DEDUCTION_POLICY
+func configuredBreakPolicy() []breakTier {
+	if config.AppConfig == nil {
+		return nil
+	}
+	tiers, err := parseBreakPolicy(config.AppConfig.BreakDeductions)
+	if err != nil {
+		utils.LogError("Invalid BREAK_DEDUCTION_POLICY, no breaks are deducted: %v", err)
+		return nil
+	}
+	return tiers
+}
+
+// breakDeduction returns the unrecorded break time to deduct from a day. Only the
+// missing part of the required break is deducted, and never so much that the net
+// time falls below the threshold that triggered the break.
+func (d workDay) breakDeduction(tiers []breakTier) time.Duration {
+	var tier *breakTier
+	for i := range tiers {
+		if d.worked > tiers[i].after {
+			tier = &tiers[i]
+		}
+	}
+	if tier == nil {
+		return 0
+	}
+
+	missing := tier.minimum - d.breakTime()
+	if missing <= 0 {
+		return 0
+	}
+	if excess := d.worked - tier.after; missing > excess {
+		missing = excess
+	}
+	return missing
+}
+
+// loadBreakDeductions returns the break deduction per user and day for entries starting in [from, to)
+func loadBreakDeductions(userIDs []uint, from, to, now time.Time) (map[uint]map[time.Time]time.Duration, error) {
+	deductions := make(map[uint]map[time.Time]time.Duration)
+	tiers := configuredBreakPolicy()
+	if len(tiers) == 0 {
+		return deductions, nil
+	}
+
+	byUser, err := loadEntriesByUser(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for userID, userEntries := range byUser {
+		for _, day := range buildWorkDays(userEntries, now) {
+			if deduction := day.breakDeduction(tiers); deduction > 0 {
+				if deductions[userID] == nil {
+					deductions[userID] = make(map[time.Time]time.Duration)
+				}
+				deductions[userID][day.day] = deduction
+			}
+		}
+	}
+	return deductions, nil
+}
+
+// loadEntriesByUser returns the time entries starting in [from, to) grouped by user,
+// for the given users or all users if nil
+func loadEntriesByUser(userIDs []uint, from, to time.Time) (map[uint][]TimeEntry, error) {
+	query := DB.Where("start_time >= ? AND start_time < ?", from, to)
+	if userIDs != nil {
+		query = query.Where("user_id IN ?", userIDs)
+	}
+	var entries []TimeEntry
+	if err := query.Order("start_time ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint][]TimeEntry)
+	for _, entry := range entries {
+		byUser[entry.UserID] = append(byUser[entry.UserID], entry)
+	}
+	return byUser, nil
+}
+
+// sumDailyHours returns the hours of the days in [from, to)
+func sumDailyHours(days map[time.Time]time.Duration, from, to time.Time) float64 {
+	total := time.Duration(0)
+	for day, duration := range days {
+		if !day.Before(from) && day.Before(to) {
+			total += duration
+		}
+	}
+	return math.Round(total.Hours()*100) / 100
+}
+
+// summaryHourWindows returns the starts of the weekly and monthly windows of the
+// summary hour columns, which both end with today
+func summaryHourWindows(now time.Time) (weekFrom, monthFrom time.Time) {
+	weekFrom, _, monthFrom, _ = summaryWindows(now)
+	return weekFrom, monthFrom
+}
+
+// summaryHourRange returns the range covering both summary hour windows
+func summaryHourRange(now time.Time) (from, to time.Time) {
+	weekFrom, monthFrom := summaryHourWindows(now)
+	from = weekFrom
+	if monthFrom.Before(from) {
+		from = monthFrom
+	}
+	return from, dayStart(now).AddDate(0, 0, 1)
+}
+
+// loadSummaryBreakDeductions loads the deductions covering both summary windows
+func loadSummaryBreakDeductions(userIDs []uint, now time.Time) (map[uint]map[time.Time]time.Duration, error) {
+	from, to := summaryHourRange(now)
+	return loadBreakDeductions(userIDs, from, to, now)
+}
+
+// fillSummaryBreaks sets the deducted and net hours of a summary
+func fillSummaryBreaks(summary *UserSummary, deductions map[uint]map[time.Time]time.Duration, now time.Time) {
+	weekFrom, monthFrom := summaryHourWindows(now)
+	_, end := summaryHourRange(now)
+
+	summary.WeeklyBreakDeduction = sumDailyHours(deductions[summary.UserID], weekFrom, end)
+	summary.WeeklyNetHours = math.Round((summary.WeeklyHours-summary.WeeklyBreakDeduction)*100) / 100
+	summary.MonthlyBreakDeduction = sumDailyHours(deductions[summary.UserID], monthFrom, end)
+	summary.MonthlyNetHours = math.Round((summary.MonthlyHours-summary.MonthlyBreakDeduction)*100) / 100
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// hashFeedToken returns the stored form of a calendar feed token
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetCalendarFeed returns the calendar feed of a user
+func GetCalendarFeed(userID uint) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	if err := DB.Where("user_id = ?", userID).First(&feed).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// CreateCalendarFeed issues a new feed token for a user, revoking any previous token,
+// and returns the feed with the token. The token cannot be retrieved later.
+func CreateCalendarFeed(userID uint) (*CalendarFeed, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	feed := &CalendarFeed{UserID: userID, TokenHash: hashFeedToken(token)}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(feed).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return feed, token, nil
+}
+
+// RevokeCalendarFeed deletes the calendar feed of a user, invalidating its token
+func RevokeCalendarFeed(userID uint) error {
+	return DB.Where("user_id = ?", userID).Delete(&CalendarFeed{}).Error
+}
+
+// UseCalendarFeed returns the feed of a token and records its use
+func UseCalendarFeed(token string, now time.Time) (*CalendarFeed, error) {
+	var feed CalendarFeed
+	if err := DB.Where("token_hash = ?", hashFeedToken(token)).First(&feed).Error; err != nil {
+		return nil, err
+	}
+	feed.LastUsedAt = &now
+	if err := DB.Model(&feed).Update("last_used_at", now).Error; err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Time series intervals and breakdowns
+const (
+	SeriesIntervalDay  = "day"
+	SeriesIntervalWeek = "week"
+	SeriesByTotal      = "total"
+	SeriesByUser       = "user"
+	SeriesByTeam       = "team"
+)
+
+// ActivityHeatmap is the tracked time per weekday and hour of day. Entries are split
+// at hour boundaries, so an entry from 8:30 to 10:00 adds 0.5h to 8:00 and 1h to 9:00.
+type ActivityHeatmap struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"` // Last day included
+	Weekdays []string       `json:"weekdays"`
+	Hours    [7][24]float64 `json:"hours"` // Hours by weekday (Monday first) and hour of day
+	Max      float64        `json:"max"`
+}
+
+// TimeSeriesLine is one user's, team's or the overall tracked time per period
+type TimeSeriesLine struct {
+	Key    string    `json:"key"`
+	Label  string    `json:"label"`
+	Values []float64 `json:"values"`
+	Total  float64   `json:"total"`
+}
+
+// TimeSeries is tracked time per day or week, broken down by user or team
+type TimeSeries struct {
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"` // Last day included
+	Interval string           `json:"interval"`
+	By       string           `json:"by"`
+	Periods  []time.Time      `json:"periods"` // Start of each period
+	Labels   []string         `json:"labels"`
+	Series   []TimeSeriesLine `json:"series"`
+}
+
+// chartEntries validates the range of a chart query and loads the selected users and
+// their entries starting in it
+func chartEntries(query *ReportQuery) ([]User, map[uint][]TimeEntry, error) {
+	query.GroupBy = ReportGroupUser
+	if err := validateReportQuery(query); err != nil {
+		return nil, nil, err
+	}
+
+	users, err := loadReportUsers(*query)
+	if err != nil || len(users) == 0 {
+		return users, nil, err
+	}
+	userIDs := make([]uint, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	byUser, err := loadEntriesByUser(userIDs, query.From, query.To)
+	return users, byUser, err
+}
+
+// GetActivityHeatmap returns when the selected users tracked time in the query's range
+func GetActivityHeatmap(query ReportQuery) (*ActivityHeatmap, error) {
+	users, byUser, err := chartEntries(&query)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := &ActivityHeatmap{
+		From:     query.From,
+		To:       query.To.AddDate(0, 0, -1),
+		Weekdays: []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"},
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		for _, entry := range byUser[user.ID] {
+			end := now
+			if entry.EndTime != nil {
+				end = *entry.EndTime
+			}
+			if end.After(query.To) {
+				end = query.To
+			}
+
+			for cursor := entry.StartTime.Local(); cursor.Before(end); {
+				next := time.Date(cursor.Year(), cursor.Month(), cursor.Day(), cursor.Hour()+1, 0, 0, 0, time.Local)
+				if next.After(end) {
+					next = end
+				}
+				weekday := (int(cursor.Weekday()) + 6) % 7
+				heatmap.Hours[weekday][cursor.Hour()] += next.Sub(cursor).Hours()
+				cursor = next
+			}
+		}
+	}
+
+	for weekday := range heatmap.Hours {
+		for hour := range heatmap.Hours[weekday] {
+			hours := math.Round(heatmap.Hours[weekday][hour]*100) / 100
+			heatmap.Hours[weekday][hour] = hours
+			heatmap.Max = math.Max(heatmap.Max, hours)
+		}
+	}
+	return heatmap, nil
+}
+
+// GetTimeSeries returns the tracked time of the selected users per day or week of the
+// query's range, in total or per user or team. Entries count on the day they start.
+func GetTimeSeries(query ReportQuery, interval, by string) (*TimeSeries, error) {
+	switch interval {
+	case "":
+		interval = SeriesIntervalDay
+	case SeriesIntervalDay, SeriesIntervalWeek:
+	default:
+		return nil, fmt.Errorf("%w: unknown interval %q", ErrInvalidReportQuery, interval)
+	}
+	switch by {
+	case "":
+		by = SeriesByTotal
+	case SeriesByTotal, SeriesByUser, SeriesByTeam:
+	default:
+		return nil, fmt.Errorf("%w: unknown breakdown %q", ErrInvalidReportQuery, by)
+	}
+
+	users, byUser, err := chartEntries(&query)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &TimeSeries{From: query.From, To: query.To.AddDate(0, 0, -1), Interval: interval, By: by, Series: []TimeSeriesLine{}}
+	periodStart := query.From
+	if interval == SeriesIntervalWeek {
+		periodStart = WeekStartOf(query.From)
+	}
+	index := make(map[time.Time]int)
+	for period := periodStart; period.Before(query.To); {
+		index[period] = len(series.Periods)
+		series.Periods = append(series.Periods, period)
+		if interval == SeriesIntervalWeek {
+			series.Labels = append(series.Labels, period.Format("2006-01-02"))
+			period = period.AddDate(0, 0, 7)
+		} else {
+			series.Labels = append(series.Labels, period.Format("Mon 01-02"))
+			period = period.AddDate(0, 0, 1)
+		}
+	}
+
+	lines := make(map[string]int)
+	line := func(key, label string) *TimeSeriesLine {
+		i, ok := lines[key]
+		if !ok {
+			i = len(series.Series)
+			lines[key] = i
+			series.Series = append(series.Series, TimeSeriesLine{Key: key, Label: label, Values: make([]float64, len(series.Periods))})
+		}
+		return &series.Series[i]
+	}
+	if by == SeriesByTotal {
+		line("total", "Total")
+	}
+
+	// Like group_by=team of reports, a user counts in each of their teams
+	var userTeams map[uint][]string
+	if by == SeriesByTeam {
+		if userTeams, err = loadUserTeams(users, query.Teams); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	for _, user := range users {
+		keys, labels := []string{"total"}, []string{"Total"}
+		switch by {
+		case SeriesByUser:
+			keys[0], labels[0] = strconv.FormatUint(uint64(user.ID), 10), user.Name
+			if user.RealName != "" {
+				labels[0] = user.RealName
+			}
+		case SeriesByTeam:
+			keys, labels = []string{""}, []string{"No team"}
+			if teams := userTeams[user.ID]; len(teams) > 0 {
+				keys, labels = teams, teams
+			}
+		}
+
+		for _, entry := range byUser[user.ID] {
+			end := now
+			if entry.EndTime != nil {
+				end = *entry.EndTime
+			}
+			if !end.After(entry.StartTime) {
+				continue
+			}
+
+			period := dayStart(entry.StartTime)
+			if interval == SeriesIntervalWeek {
+				period = WeekStartOf(period)
+			}
+			for i, key := range keys {
+				current := line(key, labels[i])
+				current.Values[index[period]] += end.Sub(entry.StartTime).Hours()
+			}
+		}
+	}
+
+	for i := range series.Series {
+		current := &series.Series[i]
+		for j, hours := range current.Values {
+			current.Values[j] = math.Round(hours*100) / 100
+			current.Total += hours
+		}
+		current.Total = math.Round(current.Total*100) / 100
+	}
+	return series, nil
+}
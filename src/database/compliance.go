@@ -0,0 +1,351 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/utils"
+)
+
+// ErrInvalidRuleSet is returned when a compliance rule set fails validation
+var ErrInvalidRuleSet = errors.New("invalid compliance rule set")
+
+// DefaultRuleSet returns the rule set built from the configured defaults
+func DefaultRuleSet() ComplianceRuleSet {
+	rules := ComplianceRuleSet{Name: "default", MaxDailyHours: 10, MinRestHours: 11, BreakAfterHours: 6, MinBreakMinutes: 30}
+	if config.AppConfig != nil {
+		rules.MaxDailyHours = config.AppConfig.MaxDailyHours
+		rules.MinRestHours = config.AppConfig.MinRestHours
+		rules.BreakAfterHours = config.AppConfig.BreakAfterHours
+		rules.MinBreakMinutes = config.AppConfig.MinBreakMinutes
+		rules.WarnBeforeMins = config.AppConfig.ComplianceWarnMins
+	}
+	return rules
+}
+
+// validateRuleSet checks the fields of a rule set
+func validateRuleSet(rules *ComplianceRuleSet) error {
+	rules.Name = strings.TrimSpace(rules.Name)
+	rules.Country = strings.ToUpper(strings.TrimSpace(rules.Country))
+	rules.Team = strings.TrimSpace(rules.Team)
+	if rules.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRuleSet)
+	}
+	if rules.MaxDailyHours < 0 || rules.MaxDailyHours > 24 || rules.MinRestHours < 0 || rules.MinRestHours > 24 ||
+		rules.BreakAfterHours < 0 || rules.BreakAfterHours > 24 {
+		return fmt.Errorf("%w: hour limits must be between 0 and 24", ErrInvalidRuleSet)
+	}
+	if rules.MinBreakMinutes < 0 || rules.WarnBeforeMins < 0 {
+		return fmt.Errorf("%w: minutes must not be negative", ErrInvalidRuleSet)
+	}
+	return nil
+}
+
+// GetComplianceRuleSets returns all stored rule sets ordered by name
+func GetComplianceRuleSets() ([]ComplianceRuleSet, error) {
+	var ruleSets []ComplianceRuleSet
+	err := DB.Order("name ASC").Find(&ruleSets).Error
+	return ruleSets, err
+}
+
+// GetComplianceRuleSet returns a single rule set by ID
+func GetComplianceRuleSet(ruleSetID uint) (*ComplianceRuleSet, error) {
+	var rules ComplianceRuleSet
+	err := DB.First(&rules, ruleSetID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// CreateComplianceRuleSet validates and stores a new rule set
+func CreateComplianceRuleSet(rules *ComplianceRuleSet) error {
+	if err := validateRuleSet(rules); err != nil {
+		return err
+	}
+	return DB.Create(rules).Error
+}
+
+// UpdateComplianceRuleSet validates and saves changes to a rule set
+func UpdateComplianceRuleSet(rules *ComplianceRuleSet) error {
+	if err := validateRuleSet(rules); err != nil {
+		return err
+	}
+	return DB.Save(rules).Error
+}
+
+// DeleteComplianceRuleSet removes a rule set
+func DeleteComplianceRuleSet(ruleSetID uint) error {
+	return DB.Delete(&ComplianceRuleSet{}, ruleSetID).Error
+}
+
+// ruleSetFor picks the rule set of a user: a team match wins over a country match,
+// which wins over a stored rule set without assignment, then the configured defaults
+func ruleSetFor(user User, ruleSets []ComplianceRuleSet) ComplianceRuleSet {
+	var countryMatch, unassigned *ComplianceRuleSet
+	for i := range ruleSets {
+		rules := &ruleSets[i]
+		switch {
+		case rules.Team != "" && strings.EqualFold(rules.Team, user.Team):
+			return *rules
+		case rules.Country != "" && strings.EqualFold(rules.Country, user.Country) && countryMatch == nil:
+			countryMatch = rules
+		case rules.Country == "" && rules.Team == "" && unassigned == nil:
+			unassigned = rules
+		}
+	}
+	if countryMatch != nil {
+		return *countryMatch
+	}
+	if unassigned != nil {
+		return *unassigned
+	}
+	return DefaultRuleSet()
+}
+
+// workDay aggregates a user's time entries that start on the same day
+type workDay struct {
+	day    time.Time
+	first  time.Time     // Start of the first entry
+	last   time.Time     // End of the last entry
+	worked time.Duration // Sum of entry durations
+}
+
+// breakTime returns the time between the first start and last end not spent working
+func (d workDay) breakTime() time.Duration {
+	if gap := d.last.Sub(d.first) - d.worked; gap > 0 {
+		return gap
+	}
+	return 0
+}
+
+// buildWorkDays groups entries by day; active entries count as running until now
+func buildWorkDays(entries []TimeEntry, now time.Time) []workDay {
+	byDay := make(map[time.Time]*workDay)
+	for _, entry := range entries {
+		end := now
+		if entry.EndTime != nil {
+			end = *entry.EndTime
+		}
+		if !end.After(entry.StartTime) {
+			continue
+		}
+
+		day := dayStart(entry.StartTime)
+		current, ok := byDay[day]
+		if !ok {
+			current = &workDay{day: day, first: entry.StartTime, last: end}
+			byDay[day] = current
+		}
+		if entry.StartTime.Before(current.first) {
+			current.first = entry.StartTime
+		}
+		if end.After(current.last) {
+			current.last = end
+		}
+		current.worked += end.Sub(entry.StartTime)
+	}
+
+	days := make([]workDay, 0, len(byDay))
+	for _, day := range byDay {
+		days = append(days, *day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].day.Before(days[j].day) })
+	return days
+}
+
+// evaluateWorkDays checks consecutive work days against a rule set
+func evaluateWorkDays(userID uint, rules ComplianceRuleSet, days []workDay) []ComplianceViolation {
+	var violations []ComplianceViolation
+	add := func(day time.Time, rule string, value, limit float64, message string) {
+		violations = append(violations, ComplianceViolation{
+			UserID:      userID,
+			Rule:        rule,
+			Date:        day,
+			RuleSetName: rules.Name,
+			Value:       value,
+			Limit:       limit,
+			Message:     message,
+		})
+	}
+
+	for i, day := range days {
+		worked := day.worked.Hours()
+
+		if rules.MaxDailyHours > 0 && worked > rules.MaxDailyHours {
+			add(day.day, ComplianceRuleMaxDaily, worked, rules.MaxDailyHours,
+				fmt.Sprintf("Worked %.2fh, limit is %.2fh", worked, rules.MaxDailyHours))
+		}
+
+		if rules.BreakAfterHours > 0 && worked > rules.BreakAfterHours {
+			breakMinutes := day.breakTime().Minutes()
+			if breakMinutes < float64(rules.MinBreakMinutes) {
+				add(day.day, ComplianceRuleBreak, breakMinutes, float64(rules.MinBreakMinutes),
+					fmt.Sprintf("Worked %.2fh with %.0f min break, %d min required after %.2fh",
+						worked, breakMinutes, rules.MinBreakMinutes, rules.BreakAfterHours))
+			}
+		}
+
+		if rules.MinRestHours > 0 && i > 0 && days[i-1].day.AddDate(0, 0, 1).Equal(day.day) {
+			rest := day.first.Sub(days[i-1].last).Hours()
+			if rest < rules.MinRestHours {
+				add(day.day, ComplianceRuleMinRest, rest, rules.MinRestHours,
+					fmt.Sprintf("Rested %.2fh since the previous day, %.2fh required", rest, rules.MinRestHours))
+			}
+		}
+	}
+
+	return violations
+}
+
+// EvaluateCompliance re-evaluates the given users (all active users if nil) for the
+// days in [from, to), replacing the stored violations of that range. It returns the
+// number of violations found.
+func EvaluateCompliance(userIDs []uint, from, to time.Time) (int, error) {
+	from, to = dayStart(from), dayStart(to)
+	if !to.After(from) {
+		return 0, ErrInvalidTimeRange
+	}
+
+	userQuery := DB.Where("is_active = ?", true)
+	if userIDs != nil {
+		userQuery = DB.Where("id IN ?", userIDs)
+	}
+	var users []User
+	if err := userQuery.Find(&users).Error; err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, nil
+	}
+
+	ruleSets, err := GetComplianceRuleSets()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+
+	// The previous day is needed to check the rest before the first day
+	var entries []TimeEntry
+	err = DB.Where("user_id IN ? AND start_time >= ? AND start_time < ?", ids, from.AddDate(0, 0, -1), to).
+		Order("start_time ASC").
+		Find(&entries).Error
+	if err != nil {
+		return 0, err
+	}
+	entriesByUser := make(map[uint][]TimeEntry)
+	for _, entry := range entries {
+		entriesByUser[entry.UserID] = append(entriesByUser[entry.UserID], entry)
+	}
+
+	now := time.Now()
+	var violations []ComplianceViolation
+	for _, user := range users {
+		days := buildWorkDays(entriesByUser[user.ID], now)
+		for _, violation := range evaluateWorkDays(user.ID, ruleSetFor(user, ruleSets), days) {
+			if !violation.Date.Before(from) {
+				violations = append(violations, violation)
+			}
+		}
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id IN ? AND date >= ? AND date < ?", ids, from, to).Delete(&ComplianceViolation{}).Error
+		if err != nil || len(violations) == 0 {
+			return err
+		}
+		return tx.CreateInBatches(violations, 100).Error
+	})
+	return len(violations), err
+}
+
+// RefreshUserCompliance re-evaluates the days touched by a change to a user's entries,
+// including the following day whose rest period depends on them. Failures are logged.
+func RefreshUserCompliance(userID uint, start, end time.Time) {
+	if _, err := EvaluateCompliance([]uint{userID}, start, dayStart(end).AddDate(0, 0, 2)); err != nil {
+		utils.LogError("Error evaluating compliance for user %d: %v", userID, err)
+	}
+}
+
+// ComplianceFilter holds the optional filters for listing violations
+type ComplianceFilter struct {
+	UserID uint
+	Rule   string
+	From   *time.Time
+	To     *time.Time
+}
+
+// GetComplianceViolations returns violations matching the filter, newest first
+func GetComplianceViolations(filter ComplianceFilter) ([]ComplianceViolation, error) {
+	query := DB.Preload("User")
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Rule != "" {
+		query = query.Where("rule = ?", filter.Rule)
+	}
+	if filter.From != nil {
+		query = query.Where("date >= ?", dayStart(*filter.From))
+	}
+	if filter.To != nil {
+		query = query.Where("date < ?", *filter.To)
+	}
+
+	var violations []ComplianceViolation
+	err := query.Order("date DESC, user_id ASC").Find(&violations).Error
+	return violations, err
+}
+
+// ComplianceStatus is a user's standing against their rule set for the current day
+type ComplianceStatus struct {
+	Rules        ComplianceRuleSet
+	WorkedToday  time.Duration
+	BreakToday   time.Duration
+	RestedBefore time.Duration // Rest before today's first entry, zero if yesterday was not worked
+}
+
+// GetComplianceStatus returns how much a user has worked and rested today, used to warn
+// users before they reach a limit
+func GetComplianceStatus(userID uint, now time.Time) (*ComplianceStatus, error) {
+	var user User
+	if err := DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	ruleSets, err := GetComplianceRuleSets()
+	if err != nil {
+		return nil, err
+	}
+
+	today := dayStart(now)
+	var entries []TimeEntry
+	err = DB.Where("user_id = ? AND start_time >= ? AND start_time < ?", userID, today.AddDate(0, 0, -1), today.AddDate(0, 0, 1)).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ComplianceStatus{Rules: ruleSetFor(user, ruleSets)}
+	days := buildWorkDays(entries, now)
+	for i, day := range days {
+		if !day.day.Equal(today) {
+			continue
+		}
+		status.WorkedToday = day.worked
+		status.BreakToday = day.breakTime()
+		if i > 0 {
+			status.RestedBefore = day.first.Sub(days[i-1].last)
+		}
+	}
+	return status, nil
+}
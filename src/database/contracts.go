@@ -0,0 +1,276 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"sports-excitement-team-management/src/config"
+)
+
+// ErrInvalidContract is returned when contract fields fail validation
+var ErrInvalidContract = errors.New("invalid contract")
+
+// weekdayNames maps working day names to weekdays
+var weekdayNames = map[string]time.Weekday{
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+	"sun": time.Sunday,
+}
+
+// parseWorkingDays parses a comma-separated list of weekday names
+func parseWorkingDays(workingDays string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, name := range strings.Split(workingDays, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		weekday, ok := weekdayNames[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown working day %q", ErrInvalidContract, name)
+		}
+		days[weekday] = true
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("%w: at least one working day is required", ErrInvalidContract)
+	}
+	return days, nil
+}
+
+// dayStart returns local midnight of the day containing t
+func dayStart(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// validateContract normalizes contract dates and checks its fields
+func validateContract(contract *Contract) error {
+	if contract.WeeklyHours < 0 || contract.WeeklyHours > 168 {
+		return fmt.Errorf("%w: weekly hours must be between 0 and 168", ErrInvalidContract)
+	}
+	if _, err := parseWorkingDays(contract.WorkingDays); err != nil {
+		return err
+	}
+
+	contract.EffectiveFrom = dayStart(contract.EffectiveFrom)
+	if contract.EffectiveTo != nil {
+		effectiveTo := dayStart(*contract.EffectiveTo)
+		if effectiveTo.Before(contract.EffectiveFrom) {
+			return fmt.Errorf("%w: effective_to must not be before effective_from", ErrInvalidContract)
+		}
+		contract.EffectiveTo = &effectiveTo
+	}
+
+	return nil
+}
+
+// GetUserContracts returns a user's contracts, most recent first
+func GetUserContracts(userID uint) ([]Contract, error) {
+	var contracts []Contract
+	err := DB.Where("user_id = ?", userID).Order("effective_from DESC, id DESC").Find(&contracts).Error
+	return contracts, err
+}
+
+// GetContract returns a single contract by ID
+func GetContract(contractID uint) (*Contract, error) {
+	var contract Contract
+	err := DB.First(&contract, contractID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// CreateContract validates and stores a new contract
+func CreateContract(contract *Contract) error {
+	if err := validateContract(contract); err != nil {
+		return err
+	}
+	return DB.Create(contract).Error
+}
+
+// UpdateContract validates and saves changes to a contract
+func UpdateContract(contract *Contract) error {
+	if err := validateContract(contract); err != nil {
+		return err
+	}
+	return DB.Save(contract).Error
+}
+
+// DeleteContract removes a contract
+func DeleteContract(contractID uint) error {
+	return DB.Delete(&Contract{}, contractID).Error
+}
+
+// workSchedule answers how many hours users are required to work on given days.
+// Users without a contract for a day fall back to the configured defaults.
+type workSchedule struct {
+	contracts      map[uint][]Contract        // Per user, most recent first
+	absences       map[uint][]Absence         // Approved absences per user within the loaded range
+	holidays       map[uint]map[string]string // Public holiday names per user by day
+	defaultHours   float64
+	defaultWorking map[time.Weekday]bool
+}
+
+// loadWorkSchedule loads the contracts of the given users (all users if nil) and
+// their approved absences and public holidays in [from, to)
+func loadWorkSchedule(userIDs []uint, from, to time.Time) (*workSchedule, error) {
+	schedule := &workSchedule{
+		contracts:    make(map[uint][]Contract),
+		absences:     make(map[uint][]Absence),
+		defaultHours: 20,
+	}
+
+	defaultDays := "mon,tue,wed,thu,fri"
+	if config.AppConfig != nil {
+		schedule.defaultHours = config.AppConfig.DefaultWeeklyHours
+		defaultDays = config.AppConfig.DefaultWorkingDays
+	}
+	workingDays, err := parseWorkingDays(defaultDays)
+	if err != nil {
+		return nil, err
+	}
+	schedule.defaultWorking = workingDays
+
+	query := DB.Order("effective_from DESC, id DESC")
+	if userIDs != nil {
+		query = query.Where("user_id IN ?", userIDs)
+	}
+
+	var contracts []Contract
+	if err := query.Find(&contracts).Error; err != nil {
+		return nil, err
+	}
+	for _, contract := range contracts {
+		schedule.contracts[contract.UserID] = append(schedule.contracts[contract.UserID], contract)
+	}
+
+	absenceQuery := DB.Where("status = ? AND start_date < ? AND end_date >= ?", AbsenceStatusApproved, to, dayStart(from))
+	if userIDs != nil {
+		absenceQuery = absenceQuery.Where("user_id IN ?", userIDs)
+	}
+
+	var absences []Absence
+	if err := absenceQuery.Find(&absences).Error; err != nil {
+		return nil, err
+	}
+	for _, absence := range absences {
+		schedule.absences[absence.UserID] = append(schedule.absences[absence.UserID], absence)
+	}
+
+	schedule.holidays, err = loadUserHolidays(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// contractFor returns the contract in effect for a user on a day, or nil
+func (s *workSchedule) contractFor(userID uint, day time.Time) *Contract {
+	for i := range s.contracts[userID] {
+		contract := &s.contracts[userID][i]
+		if contract.EffectiveFrom.After(day) {
+			continue
+		}
+		if contract.EffectiveTo != nil && contract.EffectiveTo.Before(day) {
+			continue
+		}
+		return contract
+	}
+	return nil
+}
+
+// contractedHoursForDay returns the contracted hours of a user on a day
+func (s *workSchedule) contractedHoursForDay(userID uint, day time.Time) float64 {
+	weeklyHours := s.defaultHours
+	workingDays := s.defaultWorking
+
+	if contract := s.contractFor(userID, day); contract != nil {
+		days, err := parseWorkingDays(contract.WorkingDays)
+		if err != nil {
+			return 0
+		}
+		weeklyHours = contract.WeeklyHours
+		workingDays = days
+	}
+
+	if !workingDays[day.Weekday()] {
+		return 0
+	}
+	return weeklyHours / float64(len(workingDays))
+}
+
+// absentFractionForDay returns the share of a day a user is on approved leave
+func (s *workSchedule) absentFractionForDay(userID uint, day time.Time) float64 {
+	fraction := 0.0
+	for _, absence := range s.absences[userID] {
+		fraction = math.Max(fraction, absenceFraction(absence, day))
+	}
+	return fraction
+}
+
+// requiredHoursForDay returns the hours a user is required to work on a day,
+// reduced by approved absences; nothing is required on public holidays
+func (s *workSchedule) requiredHoursForDay(userID uint, day time.Time) float64 {
+	if _, ok := s.holidays[userID][holidayDateKey(day)]; ok {
+		return 0
+	}
+	return s.contractedHoursForDay(userID, day) * (1 - s.absentFractionForDay(userID, day))
+}
+
+// requiredHours sums the required hours of a user for the days in [from, to)
+func (s *workSchedule) requiredHours(userID uint, from, to time.Time) float64 {
+	total := 0.0
+	for day := dayStart(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		total += s.requiredHoursForDay(userID, day)
+	}
+	return total
+}
+
+// summaryWindows returns the weekly window (the last 7 days including today) and the
+// monthly window (the whole current month) used for summary targets
+func summaryWindows(now time.Time) (weekFrom, weekTo, monthFrom, monthTo time.Time) {
+	today := dayStart(now)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local)
+	return today.AddDate(0, 0, -6), today.AddDate(0, 0, 1), monthStart, monthStart.AddDate(0, 1, 0)
+}
+
+// loadSummarySchedule loads the work schedule covering both summary windows
+func loadSummarySchedule(userIDs []uint, now time.Time) (*workSchedule, error) {
+	weekFrom, weekTo, monthFrom, monthTo := summaryWindows(now)
+	from, to := monthFrom, monthTo
+	if weekFrom.Before(from) {
+		from = weekFrom
+	}
+	if weekTo.After(to) {
+		to = weekTo
+	}
+	return loadWorkSchedule(userIDs, from, to)
+}
+
+// fillSummaryTargets sets the weekly and monthly targets of a summary
+func (s *workSchedule) fillSummaryTargets(summary *UserSummary, now time.Time) {
+	weekFrom, weekTo, monthFrom, monthTo := summaryWindows(now)
+	summary.WeeklyTarget = s.requiredHours(summary.UserID, weekFrom, weekTo)
+	summary.MonthlyTarget = s.requiredHours(summary.UserID, monthFrom, monthTo)
+}
+
+// GetRequiredHours returns the hours a user is required to work in [from, to)
+func GetRequiredHours(userID uint, from, to time.Time) (float64, error) {
+	schedule, err := loadWorkSchedule([]uint{userID}, from, to)
+	if err != nil {
+		return 0, err
+	}
+	return schedule.requiredHours(userID, from, to), nil
+}
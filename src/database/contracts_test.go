@@ -0,0 +1,47 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetUserSummaryWeeklyWindow(t *testing.T) {
+	today := dayStart(time.Now())
+	tests := []struct {
+		name       string
+		daysAgo    int
+		wantWeekly float64
+	}{
+		{name: "today", daysAgo: 0, wantWeekly: 2},
+		{name: "first day of the window", daysAgo: 6, wantWeekly: 2},
+		{name: "day before the window", daysAgo: 7, wantWeekly: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			user := createTestUser(t, "U1")
+			start := today.AddDate(0, 0, -tt.daysAgo).Add(time.Hour)
+			end := start.Add(2 * time.Hour)
+			entry := TimeEntry{UserID: user.ID, StartTime: start, EndTime: &end, Duration: int64((2 * time.Hour).Seconds()),
+				Status: "Working", Source: TimeEntrySourceManual}
+			if err := DB.Create(&entry).Error; err != nil {
+				t.Fatalf("creating entry: %v", err)
+			}
+
+			summary, err := GetUserSummary(user.ID)
+			if err != nil {
+				t.Fatalf("GetUserSummary: %v", err)
+			}
+			if math.Abs(summary.WeeklyHours-tt.wantWeekly) > 0.01 {
+				t.Errorf("weekly hours = %.2f, want %.2f", summary.WeeklyHours, tt.wantWeekly)
+			}
+
+			weekFrom, weekTo, _, _ := summaryWindows(time.Now())
+			if days := int(weekTo.Sub(weekFrom).Hours()/24 + 0.5); days != 7 {
+				t.Errorf("weekly target window covers %d days, want 7", days)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"math"
+	"time"
+)
+
+// DailyHours is one user's tracked and required time on one day
+type DailyHours struct {
+	UserID        uint      `json:"user_id"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	PersonnelNo   string    `json:"personnel_number"`
+	Date          time.Time `json:"date"`
+	Entries       int       `json:"entries"`
+	GrossHours    float64   `json:"gross_hours"`
+	BreakHours    float64   `json:"break_deduction_hours"`
+	NetHours      float64   `json:"net_hours"`
+	RoundedHours  float64   `json:"rounded_hours"`
+	RequiredHours float64   `json:"required_hours"`
+}
+
+// GetDailyHours returns the hours of every active user per day in [from, to). Days
+// without tracked or required time are left out.
+func GetDailyHours(from, to time.Time) ([]DailyHours, error) {
+	return GetDailyHoursForQuery(ReportQuery{From: from, To: to})
+}
+
+// GetDailyHoursForQuery returns the daily hours of the active users selected by the
+// users and teams filters of a query in [query.From, query.To)
+func GetDailyHoursForQuery(query ReportQuery) ([]DailyHours, error) {
+	from, to := dayStart(query.From), dayStart(query.To)
+	if !to.After(from) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	var users []User
+	var userIDs []uint
+	if len(query.UserIDs) > 0 || len(query.Teams) > 0 {
+		selected, err := loadReportUsers(query)
+		if err != nil {
+			return nil, err
+		}
+		userIDs = []uint{}
+		for _, user := range selected {
+			if user.IsActive {
+				users = append(users, user)
+				userIDs = append(userIDs, user.ID)
+			}
+		}
+		if len(users) == 0 {
+			return nil, nil
+		}
+	} else if err := DB.Where("is_active = ?", true).Order("name ASC").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	byUser, err := loadEntriesByUser(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := loadWorkSchedule(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	deductions, err := loadBreakDeductions(userIDs, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	rounded, err := loadRoundedHours(ConfiguredRounding(), userIDs, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []DailyHours
+	for _, user := range users {
+		gross := make(map[time.Time]time.Duration)
+		counts := make(map[time.Time]int)
+		for _, day := range buildWorkDays(byUser[user.ID], now) {
+			gross[day.day] = day.worked
+		}
+		for _, entry := range byUser[user.ID] {
+			counts[dayStart(entry.StartTime)]++
+		}
+
+		name := user.Name
+		if user.RealName != "" {
+			name = user.RealName
+		}
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			required := schedule.requiredHoursForDay(user.ID, day)
+			if counts[day] == 0 && required == 0 {
+				continue
+			}
+
+			daily := DailyHours{
+				UserID:        user.ID,
+				Name:          name,
+				Email:         user.Email,
+				PersonnelNo:   user.PersonnelNo,
+				Date:          day,
+				Entries:       counts[day],
+				GrossHours:    math.Round(gross[day].Hours()*100) / 100,
+				BreakHours:    math.Round(deductions[user.ID][day].Hours()*100) / 100,
+				RoundedHours:  math.Round(rounded[user.ID][day].Hours()*100) / 100,
+				RequiredHours: math.Round(required*100) / 100,
+			}
+			daily.NetHours = math.Round((daily.GrossHours-daily.BreakHours)*100) / 100
+			days = append(days, daily)
+		}
+	}
+	return days, nil
+}
+
+// GetTimeEntriesInRange returns the entries of all active users starting in [from, to)
+// with their users and projects, ordered by start time. Like GetDailyHours it leaves
+// out inactive users, so that both add up to the same hours.
+func GetTimeEntriesInRange(from, to time.Time) ([]TimeEntry, error) {
+	var entries []TimeEntry
+	err := DB.Preload("User").Preload("Project").
+		Where("start_time >= ? AND start_time < ?", from, to).
+		Where("user_id IN (?)", DB.Model(&User{}).Select("id").Where("is_active = ?", true)).
+		Order("start_time ASC, id ASC").
+		Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeEntriesInRangeMatchDailyHoursUsers(t *testing.T) {
+	openTestDB(t)
+	active := createTestUser(t, "U1")
+	inactive := createTestUser(t, "U2")
+	if err := DB.Model(inactive).Update("is_active", false).Error; err != nil {
+		t.Fatalf("deactivating user: %v", err)
+	}
+
+	from := dayStart(time.Now()).AddDate(0, 0, -3)
+	for _, user := range []*User{active, inactive} {
+		start := from.Add(9 * time.Hour)
+		end := start.Add(2 * time.Hour)
+		entry := TimeEntry{UserID: user.ID, StartTime: start, EndTime: &end, Duration: int64((2 * time.Hour).Seconds()),
+			Status: "Working", Source: TimeEntrySourceManual}
+		if err := DB.Create(&entry).Error; err != nil {
+			t.Fatalf("creating entry: %v", err)
+		}
+	}
+
+	to := from.AddDate(0, 0, 1)
+	days, err := GetDailyHours(from, to)
+	if err != nil {
+		t.Fatalf("GetDailyHours: %v", err)
+	}
+	entries, err := GetTimeEntriesInRange(from, to)
+	if err != nil {
+		t.Fatalf("GetTimeEntriesInRange: %v", err)
+	}
+
+	dailyUsers := make(map[uint]bool)
+	for _, day := range days {
+		if day.Entries > 0 {
+			dailyUsers[day.UserID] = true
+		}
+	}
+	entryUsers := make(map[uint]bool)
+	for _, entry := range entries {
+		entryUsers[entry.UserID] = true
+	}
+	for _, user := range []*User{active, inactive} {
+		if dailyUsers[user.ID] != entryUsers[user.ID] {
+			t.Errorf("user %s: in daily hours %v, in entries %v", user.SlackUserID, dailyUsers[user.ID], entryUsers[user.ID])
+		}
+	}
+	if !entryUsers[active.ID] || entryUsers[inactive.ID] {
+		t.Errorf("entries cover users %v, want only the active user %d", entryUsers, active.ID)
+	}
+}
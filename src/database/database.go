@@ -1,6 +1,7 @@
 package database
 
 import (
+	"math"
 	"os"
 	"path/filepath"
 	"time"
@@ -53,6 +54,25 @@ func Initialize() {
 		&UserStatus{},
 		&Admin{},
 		&Session{},
+		&AuditLog{},
+		&Timesheet{},
+		&TimesheetComment{},
+		&PeriodLock{},
+		&Contract{},
+		&Absence{},
+		&HolidayCalendar{},
+		&Holiday{},
+		&BalanceAdjustment{},
+		&ComplianceRuleSet{},
+		&ComplianceViolation{},
+		&ReportSchedule{},
+		&ArchivedReport{},
+		&CalendarFeed{},
+		&ImportBatch{},
+		&Team{},
+		&TeamMember{},
+		&TrackingRule{},
+		&Project{},
 	)
 
 	if err != nil {
@@ -62,6 +82,9 @@ func Initialize() {
 	// Create default admin user
 	createDefaultAdmin()
 
+	// Create teams for the primary teams set on users
+	ensurePrimaryTeams()
+
 	utils.LogInfo("Database initialized successfully at: %s", config.AppConfig.DatabasePath)
 }
 
@@ -127,11 +150,11 @@ func GetUserSummaries() ([]UserSummary, error) {
 			) THEN 1 ELSE 0 END as is_currently_working,
 			COALESCE(us_current.status_text, '') as current_status,
 			COALESCE(SUM(CASE 
-				WHEN te.start_time >= date('now', '-7 days') 
+				WHEN te.start_time >= ? 
 				THEN te.duration ELSE 0 
 			END), 0) / 3600.0 as weekly_hours,
 			COALESCE(SUM(CASE 
-				WHEN te.start_time >= date('now', 'start of month') 
+				WHEN te.start_time >= ? 
 				THEN te.duration ELSE 0 
 			END), 0) / 3600.0 as monthly_hours
 		FROM users u
@@ -146,7 +169,21 @@ func GetUserSummaries() ([]UserSummary, error) {
 		ORDER BY u.name
 	`
 
-	err := DB.Raw(query).Scan(&rawSummaries).Error
+	weekFrom, _, monthFrom, _ := summaryWindows(time.Now())
+	err := DB.Raw(query, weekFrom, monthFrom).Scan(&rawSummaries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := loadSummarySchedule(nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	deductions, err := loadSummaryBreakDeductions(nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	rounded, err := loadSummaryRoundedHours(nil, time.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +213,9 @@ func GetUserSummaries() ([]UserSummary, error) {
 			WeeklyHours:        raw.WeeklyHours,
 			MonthlyHours:       raw.MonthlyHours,
 		}
+		schedule.fillSummaryTargets(&summary, time.Now())
+		fillSummaryBreaks(&summary, deductions, time.Now())
+		fillSummaryRounding(&summary, rounded, time.Now())
 		summaries = append(summaries, summary)
 	}
 
@@ -184,19 +224,19 @@ func GetUserSummaries() ([]UserSummary, error) {
 
 // WeeklyReportRaw is used for scanning raw SQL results
 type WeeklyReportRaw struct {
-	UserID         uint    `json:"user_id"`
-	Name           string  `json:"name"`
-	Email          string  `json:"email"`
-	WeekStart      string  `json:"week_start"` // String for SQLite datetime
-	WeekEnd        string  `json:"week_end"`   // String for SQLite datetime
-	TotalHours     float64 `json:"total_hours"`
-	RequiredHours  float64 `json:"required_hours"`
-	CompletionRate float64 `json:"completion_rate"`
+	UserID     uint    `json:"user_id"`
+	Name       string  `json:"name"`
+	Email      string  `json:"email"`
+	WeekStart  string  `json:"week_start"` // String for SQLite datetime
+	WeekEnd    string  `json:"week_end"`   // String for SQLite datetime
+	TotalHours float64 `json:"total_hours"`
 }
 
-// GetWeeklyReports returns weekly time tracking reports
+// GetWeeklyReports returns weekly time tracking reports for the week, Monday to Sunday
+// in local time, that contains weekStart
 func GetWeeklyReports(weekStart time.Time) ([]WeeklyReport, error) {
 	var rawReports []WeeklyReportRaw
+	weekStart = WeekStartOf(weekStart)
 	weekEnd := weekStart.AddDate(0, 0, 6)
 
 	query := `
@@ -206,19 +246,32 @@ func GetWeeklyReports(weekStart time.Time) ([]WeeklyReport, error) {
 			u.email,
 			? as week_start,
 			? as week_end,
-			COALESCE(SUM(te.duration), 0) / 3600.0 as total_hours,
-			20.0 as required_hours,
-			(COALESCE(SUM(te.duration), 0) / 3600.0) / 20.0 * 100 as completion_rate
+			COALESCE(SUM(te.duration), 0) / 3600.0 as total_hours
 		FROM users u
 		LEFT JOIN time_entries te ON u.id = te.user_id 
 			AND te.start_time >= ? 
-			AND te.start_time <= ?
+			AND te.start_time < ?
 		WHERE u.is_active = 1
 		GROUP BY u.id, u.name, u.email
 		ORDER BY u.name
 	`
 
-	err := DB.Raw(query, weekStart, weekEnd, weekStart, weekEnd).Scan(&rawReports).Error
+	// Entries are matched up to the start of the following Monday so Sunday is included
+	nextWeekStart := weekStart.AddDate(0, 0, 7)
+	err := DB.Raw(query, weekStart, weekEnd, weekStart, nextWeekStart).Scan(&rawReports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := loadWorkSchedule(nil, weekStart, nextWeekStart)
+	if err != nil {
+		return nil, err
+	}
+	deductions, err := loadBreakDeductions(nil, weekStart, nextWeekStart, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	rounded, err := loadRoundedHours(ConfiguredRounding(), nil, weekStart, nextWeekStart, time.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -243,6 +296,12 @@ func GetWeeklyReports(weekStart time.Time) ([]WeeklyReport, error) {
 			}
 		}
 
+		requiredHours := schedule.requiredHours(raw.UserID, weekStart, nextWeekStart)
+		completionRate := 0.0
+		if requiredHours > 0 {
+			completionRate = raw.TotalHours / requiredHours * 100
+		}
+
 		report := WeeklyReport{
 			UserID:         raw.UserID,
 			Name:           raw.Name,
@@ -250,9 +309,13 @@ func GetWeeklyReports(weekStart time.Time) ([]WeeklyReport, error) {
 			WeekStart:      weekStartParsed,
 			WeekEnd:        weekEndParsed,
 			TotalHours:     raw.TotalHours,
-			RequiredHours:  raw.RequiredHours,
-			CompletionRate: raw.CompletionRate,
+			RequiredHours:  requiredHours,
+			CompletionRate: completionRate,
+			BreakHours:     sumDailyHours(deductions[raw.UserID], weekStart, nextWeekStart),
 		}
+		report.NetHours = math.Round((report.TotalHours-report.BreakHours)*100) / 100
+		report.RoundedHours = sumDailyHours(rounded[raw.UserID], weekStart, nextWeekStart)
+		report.RoundedNet = math.Round((report.RoundedHours-report.BreakHours)*100) / 100
 		reports = append(reports, report)
 	}
 
@@ -293,10 +356,16 @@ func CreateOrUpdateUser(slackUserID, name, email, realName, profileImage string)
 
 // StartTimeEntry starts a new time tracking entry
 func StartTimeEntry(userID uint, status, statusText, statusEmoji string) (*TimeEntry, error) {
-	// End any existing active entries for this user
-	DB.Model(&TimeEntry{}).
-		Where("user_id = ? AND end_time IS NULL", userID).
-		Update("end_time", time.Now())
+	// Refuse to track time inside a locked period
+	if err := CheckTimeEntryLocked(userID, time.Now(), time.Now().Add(time.Second)); err != nil {
+		return nil, err
+	}
+
+	// End any existing active entry for this user, e.g. when they switch projects. A
+	// user has at most one running entry, so no new one is started if it cannot be ended.
+	if err := EndTimeEntry(userID); err != nil {
+		return nil, err
+	}
 
 	// Create new entry
 	entry := TimeEntry{
@@ -305,13 +374,22 @@ func StartTimeEntry(userID uint, status, statusText, statusEmoji string) (*TimeE
 		Status:      status,
 		StatusText:  statusText,
 		StatusEmoji: statusEmoji,
+		Source:      TimeEntrySourceSlack,
+	}
+
+	// Book the entry on the project tagged in the status text
+	if err := AssignEntryProject(&entry); err != nil {
+		utils.LogError("Error assigning a project to the time entry of user %d: %v", userID, err)
 	}
 
 	err := DB.Create(&entry).Error
 	return &entry, err
 }
 
-// EndTimeEntry ends an active time tracking entry
+// EndTimeEntry ends an active time tracking entry. Locked time is not tracked, so an
+// entry that ran into a locked period or an approved week stops where the first of them
+// starts. An entry that started inside one cannot be changed and is refused with
+// ErrPeriodLocked.
 func EndTimeEntry(userID uint) error {
 	now := time.Now()
 
@@ -324,12 +402,32 @@ func EndTimeEntry(userID uint) error {
 		return result.Error
 	}
 
-	// Calculate duration and update entry
-	duration := now.Sub(entry.StartTime).Seconds()
-	entry.EndTime = &now
-	entry.Duration = int64(duration)
+	end := now
+	lockStart, locked, err := firstLockedStart(userID, entry.StartTime, now)
+	if err != nil {
+		return err
+	}
+	if locked {
+		if !lockStart.After(entry.StartTime) {
+			return CheckTimeEntryLocked(userID, entry.StartTime, now)
+		}
+		end = lockStart
+	}
+
+	return endEntryAt(&entry, end)
+}
+
+// endEntryAt ends a running entry at end with its duration and re-evaluates the
+// user's compliance over it
+func endEntryAt(entry *TimeEntry, end time.Time) error {
+	entry.EndTime = &end
+	entry.Duration = int64(end.Sub(entry.StartTime).Seconds())
+	if err := DB.Save(entry).Error; err != nil {
+		return err
+	}
 
-	return DB.Save(&entry).Error
+	RefreshUserCompliance(entry.UserID, entry.StartTime, end)
+	return nil
 }
 
 // CreateUserStatus creates a new user status record
@@ -447,11 +545,11 @@ func GetUserSummary(userID uint) (UserSummary, error) {
 			) THEN 1 ELSE 0 END as is_currently_working,
 			COALESCE(te_current.status, '') as current_status,
 			COALESCE(SUM(CASE 
-				WHEN te.start_time >= date('now', '-7 days') 
+				WHEN te.start_time >= ? 
 				THEN te.duration ELSE 0 
 			END), 0) / 3600.0 as weekly_hours,
 			COALESCE(SUM(CASE 
-				WHEN te.start_time >= date('now', 'start of month') 
+				WHEN te.start_time >= ? 
 				THEN te.duration ELSE 0 
 			END), 0) / 3600.0 as monthly_hours
 		FROM users u
@@ -465,7 +563,8 @@ func GetUserSummary(userID uint) (UserSummary, error) {
 		GROUP BY u.id, u.name, u.email, te_current.status
 	`
 
-	err := DB.Raw(query, userID).Scan(&rawSummary).Error
+	weekFrom, _, monthFrom, _ := summaryWindows(time.Now())
+	err := DB.Raw(query, weekFrom, monthFrom, userID).Scan(&rawSummary).Error
 	if err != nil {
 		return UserSummary{}, err
 	}
@@ -491,6 +590,24 @@ func GetUserSummary(userID uint) (UserSummary, error) {
 		MonthlyHours:       rawSummary.MonthlyHours,
 	}
 
+	schedule, err := loadSummarySchedule([]uint{userID}, time.Now())
+	if err != nil {
+		return UserSummary{}, err
+	}
+	schedule.fillSummaryTargets(&summary, time.Now())
+
+	deductions, err := loadSummaryBreakDeductions([]uint{userID}, time.Now())
+	if err != nil {
+		return UserSummary{}, err
+	}
+	fillSummaryBreaks(&summary, deductions, time.Now())
+
+	rounded, err := loadSummaryRoundedHours([]uint{userID}, time.Now())
+	if err != nil {
+		return UserSummary{}, err
+	}
+	fillSummaryRounding(&summary, rounded, time.Now())
+
 	return summary, nil
 }
 
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTestDB initializes a fresh database in a temporary directory
+func openTestDB(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("DATABASE_PATH", filepath.Join(dir, "test.db"))
+	t.Setenv("LOG_FILE_PATH", filepath.Join(dir, "test.log"))
+	t.Setenv("ENABLE_VERBOSE_LOGS", "false")
+	Initialize()
+	t.Cleanup(func() {
+		if sqlDB, err := DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+// createTestUser adds an active user
+func createTestUser(t *testing.T, slackUserID string) *User {
+	t.Helper()
+	user := User{SlackUserID: slackUserID, Name: slackUserID, Email: slackUserID + "@example.com", IsActive: true}
+	if err := DB.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	return &user
+}
+
+// createRunningEntry adds an active entry of a user that started at start
+func createRunningEntry(t *testing.T, userID uint, start time.Time) *TimeEntry {
+	t.Helper()
+	entry := TimeEntry{UserID: userID, StartTime: start, Status: "Working", Source: TimeEntrySourceSlack}
+	if err := DB.Create(&entry).Error; err != nil {
+		t.Fatalf("creating entry: %v", err)
+	}
+	return &entry
+}
+
+// useLocation runs a test with time.Local set to a named time zone
+func useLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	local := time.Local
+	time.Local = location
+	t.Cleanup(func() { time.Local = local })
+	return location
+}
+
+// createClosedEntry adds an ended entry of a user
+func createClosedEntry(t *testing.T, userID uint, start, end time.Time) *TimeEntry {
+	t.Helper()
+	entry := TimeEntry{UserID: userID, StartTime: start, EndTime: &end, Duration: int64(end.Sub(start).Seconds()),
+		Status: "Working", Source: TimeEntrySourceManual}
+	if err := DB.Create(&entry).Error; err != nil {
+		t.Fatalf("creating entry: %v", err)
+	}
+	return &entry
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/utils"
+)
+
+// ErrInvalidHolidayCalendar is returned when a holiday calendar fails validation
+var ErrInvalidHolidayCalendar = errors.New("invalid holiday calendar")
+
+// holidayDateKey formats a day for holiday lookups
+func holidayDateKey(day time.Time) string {
+	return day.Local().Format("2006-01-02")
+}
+
+// appliesTo reports whether the calendar covers a user
+func (c HolidayCalendar) appliesTo(user User) bool {
+	if c.Country == "" && c.Team == "" {
+		return true
+	}
+	if c.Country != "" && strings.EqualFold(c.Country, user.Country) {
+		return true
+	}
+	return c.Team != "" && strings.EqualFold(c.Team, user.Team)
+}
+
+// GetHolidayCalendars returns all holiday calendars ordered by name
+func GetHolidayCalendars() ([]HolidayCalendar, error) {
+	var calendars []HolidayCalendar
+	err := DB.Order("name ASC").Find(&calendars).Error
+	return calendars, err
+}
+
+// GetHolidayCalendar returns a calendar with its holidays
+func GetHolidayCalendar(calendarID uint) (*HolidayCalendar, error) {
+	var calendar HolidayCalendar
+	err := DB.Preload("Holidays", func(db *gorm.DB) *gorm.DB {
+		return db.Order("date ASC")
+	}).First(&calendar, calendarID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// UpdateHolidayCalendar saves the name and assignment of a calendar
+func UpdateHolidayCalendar(calendar *HolidayCalendar) error {
+	calendar.Name = strings.TrimSpace(calendar.Name)
+	if calendar.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidHolidayCalendar)
+	}
+	return DB.Omit("Holidays").Save(calendar).Error
+}
+
+// DeleteHolidayCalendar removes a calendar and its holidays
+func DeleteHolidayCalendar(calendarID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", calendarID).Delete(&Holiday{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&HolidayCalendar{}, calendarID).Error
+	})
+}
+
+// ImportHolidayCalendar parses an ICS stream and replaces the holidays of the calendar,
+// creating the calendar if it is new. Multi-day events become one holiday per day.
+func ImportHolidayCalendar(calendar *HolidayCalendar, r io.Reader) error {
+	calendar.Name = strings.TrimSpace(calendar.Name)
+	if calendar.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidHolidayCalendar)
+	}
+
+	events, err := utils.ParseICS(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHolidayCalendar, err)
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		calendar.ImportedAt = &now
+		if err := tx.Omit("Holidays").Save(calendar).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("calendar_id = ?", calendar.ID).Delete(&Holiday{}).Error; err != nil {
+			return err
+		}
+
+		var holidays []Holiday
+		for _, event := range events {
+			last := event.End
+			if !last.After(event.Start) {
+				// Events without a duration still mark their day
+				last = event.Start.Add(time.Nanosecond)
+			}
+			for day := dayStart(event.Start); day.Before(last); day = day.AddDate(0, 0, 1) {
+				holidays = append(holidays, Holiday{
+					CalendarID: calendar.ID,
+					Date:       day,
+					Name:       event.Summary,
+					UID:        event.UID,
+				})
+			}
+		}
+		if len(holidays) == 0 {
+			return nil
+		}
+		calendar.Holidays = holidays
+		return tx.CreateInBatches(holidays, 100).Error
+	})
+}
+
+// ImportHolidayCalendarFiles imports an .ics file, or every .ics file in a directory.
+// Calendars are named after the file; a two-letter name is assigned to that country
+// when the calendar is first created.
+func ImportHolidayCalendarFiles(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.ics"))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		var calendar HolidayCalendar
+		if err := DB.Where("name = ?", name).Limit(1).Find(&calendar).Error; err != nil {
+			return err
+		}
+		if calendar.ID == 0 {
+			calendar.Name = name
+			if len(name) == 2 {
+				calendar.Country = strings.ToUpper(name)
+			}
+		}
+		calendar.SourcePath = file
+
+		f, err := os.Open(file)
+		if err != nil {
+			utils.LogError("Error opening holiday calendar %s: %v", file, err)
+			continue
+		}
+		err = ImportHolidayCalendar(&calendar, f)
+		f.Close()
+		if err != nil {
+			utils.LogError("Error importing holiday calendar %s: %v", file, err)
+			continue
+		}
+		utils.LogInfo("Imported holiday calendar %s with %d holidays", calendar.Name, len(calendar.Holidays))
+	}
+
+	return nil
+}
+
+// loadUserHolidays returns, per user, the holiday names by day in [from, to)
+func loadUserHolidays(userIDs []uint, from, to time.Time) (map[uint]map[string]string, error) {
+	var holidays []Holiday
+	err := DB.Where("date >= ? AND date < ?", dayStart(from), to).Find(&holidays).Error
+	if err != nil || len(holidays) == 0 {
+		return map[uint]map[string]string{}, err
+	}
+
+	var calendars []HolidayCalendar
+	if err := DB.Find(&calendars).Error; err != nil {
+		return nil, err
+	}
+
+	userQuery := DB.Model(&User{})
+	if userIDs != nil {
+		userQuery = userQuery.Where("id IN ?", userIDs)
+	}
+	var users []User
+	if err := userQuery.Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	byCalendar := make(map[uint][]Holiday)
+	for _, holiday := range holidays {
+		byCalendar[holiday.CalendarID] = append(byCalendar[holiday.CalendarID], holiday)
+	}
+
+	result := make(map[uint]map[string]string)
+	for _, user := range users {
+		for _, calendar := range calendars {
+			if !calendar.appliesTo(user) {
+				continue
+			}
+			for _, holiday := range byCalendar[calendar.ID] {
+				if result[user.ID] == nil {
+					result[user.ID] = make(map[string]string)
+				}
+				result[user.ID][holidayDateKey(holiday.Date)] = holiday.Name
+			}
+		}
+	}
+	return result, nil
+}
+
+// UserHoliday is a holiday that applies to a user
+type UserHoliday struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+}
+
+// GetUserHolidays returns the holidays that apply to a user in [from, to)
+func GetUserHolidays(userID uint, from, to time.Time) ([]UserHoliday, error) {
+	holidays, err := loadUserHolidays([]uint{userID}, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []UserHoliday
+	for day := dayStart(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if name, ok := holidays[userID][holidayDateKey(day)]; ok {
+			result = append(result, UserHoliday{Date: day, Name: name})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,515 @@
+package database
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidImport is returned when an import file or its mapping cannot be read
+	ErrInvalidImport = errors.New("invalid import")
+	// ErrImportRolledBack is returned when rolling back a batch that was already rolled back
+	ErrImportRolledBack = errors.New("import batch is already rolled back")
+)
+
+// ImportMapping names the CSV columns of an import. Start and end are read from
+// Start and End (date and time in one column), or from StartDate and StartTime and
+// EndDate and EndTime; without end columns Duration is added to the start. Users are
+// matched by Email or SlackID.
+type ImportMapping struct {
+	Email      string `json:"email"`
+	SlackID    string `json:"slack_id"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	StartDate  string `json:"start_date"`
+	StartTime  string `json:"start_time"`
+	EndDate    string `json:"end_date"`
+	EndTime    string `json:"end_time"`
+	Duration   string `json:"duration"` // hh:mm[:ss] or decimal hours
+	Category   string `json:"category"` // Defaults to "Working"
+	Project    string `json:"project"`  // Stored as the status text and booked on the project of that name or tag
+	Note       string `json:"note"`
+	DateLayout string `json:"date_layout"` // Go layout of dates, e.g. "02/01/2006"; common layouts if empty
+}
+
+// importPresets are the column mappings of the supported formats
+var importPresets = map[string]ImportMapping{
+	"toggl": {
+		Email: "Email", StartDate: "Start date", StartTime: "Start time", EndDate: "End date", EndTime: "End time",
+		Duration: "Duration", Project: "Project", Note: "Description",
+	},
+	"clockify": {
+		Email: "Email", StartDate: "Start Date", StartTime: "Start Time", EndDate: "End Date", EndTime: "End Time",
+		Duration: "Duration (h)", Project: "Project", Note: "Description", DateLayout: "01/02/2006",
+	},
+	"csv": {
+		Email: "email", SlackID: "slack_id", Start: "start", End: "end", Duration: "duration",
+		Category: "category", Project: "project", Note: "note",
+	},
+}
+
+// Layouts tried for dates without a configured layout, and for times
+var (
+	importDateLayouts = []string{"2006-01-02", "02.01.2006", "01/02/2006", "2006/01/02"}
+	importTimeLayouts = []string{"15:04:05", "15:04", "03:04:05 PM", "3:04:05 PM", "03:04 PM", "3:04 PM"}
+)
+
+// ImportOptions configure an import
+type ImportOptions struct {
+	Format    string        // toggl, clockify or csv
+	Filename  string        // Name of the uploaded file
+	Mapping   ImportMapping // Overrides the columns of the format
+	Delimiter rune          // Field separator, 0 to detect ",", ";" or tab
+	DryRun    bool          // Validate and preview without writing
+	CreatedBy string        // Admin username
+}
+
+// ImportRow is the outcome of one CSV row
+type ImportRow struct {
+	Line     int        `json:"line"`
+	UserID   uint       `json:"user_id,omitempty"`
+	User     string     `json:"user,omitempty"`
+	Start    *time.Time `json:"start,omitempty"`
+	End      *time.Time `json:"end,omitempty"`
+	Hours    float64    `json:"hours"`
+	Category string     `json:"category,omitempty"`
+	Project  string     `json:"project,omitempty"`
+	Note     string     `json:"note,omitempty"`
+	Error    string     `json:"error,omitempty"` // Why the row is skipped
+}
+
+// ImportResult is the preview or outcome of an import
+type ImportResult struct {
+	DryRun  bool         `json:"dry_run"`
+	Batch   *ImportBatch `json:"batch"` // Nil for dry runs
+	Rows    int          `json:"rows"`
+	Valid   int          `json:"valid"`
+	Skipped int          `json:"skipped"`
+	Hours   float64      `json:"hours"` // Hours of the valid rows
+	Items   []ImportRow  `json:"items"`
+}
+
+// ImportFormats returns the names of the supported import formats
+func ImportFormats() []string {
+	return []string{"toggl", "clockify", "csv"}
+}
+
+// mergeImportMapping returns the preset mapping of a format with the non-empty columns
+// of overrides
+func mergeImportMapping(preset, overrides ImportMapping) ImportMapping {
+	merge := func(value *string, override string) {
+		if override = strings.TrimSpace(override); override != "" {
+			*value = override
+		}
+	}
+	merge(&preset.Email, overrides.Email)
+	merge(&preset.SlackID, overrides.SlackID)
+	merge(&preset.Start, overrides.Start)
+	merge(&preset.End, overrides.End)
+	merge(&preset.StartDate, overrides.StartDate)
+	merge(&preset.StartTime, overrides.StartTime)
+	merge(&preset.EndDate, overrides.EndDate)
+	merge(&preset.EndTime, overrides.EndTime)
+	merge(&preset.Duration, overrides.Duration)
+	merge(&preset.Category, overrides.Category)
+	merge(&preset.Project, overrides.Project)
+	merge(&preset.Note, overrides.Note)
+	merge(&preset.DateLayout, overrides.DateLayout)
+	return preset
+}
+
+// detectDelimiter returns the most frequent of ",", ";" and tab in a header line
+func detectDelimiter(header string) rune {
+	best, count := ',', strings.Count(header, ",")
+	for _, candidate := range []rune{';', '\t'} {
+		if n := strings.Count(header, string(candidate)); n > count {
+			best, count = candidate, n
+		}
+	}
+	return best
+}
+
+// importRecord gives access to the mapped columns of a CSV record
+type importRecord struct {
+	columns map[string]int
+	values  []string
+}
+
+// get returns the value of a column, or "" if the column is not mapped or missing
+func (r importRecord) get(column string) string {
+	if column == "" {
+		return ""
+	}
+	index, ok := r.columns[strings.ToLower(column)]
+	if !ok || index >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[index])
+}
+
+// parseImportTime parses a date and an optional time of day in local time
+func parseImportTime(date, clock, dateLayout string) (time.Time, error) {
+	dateLayouts := importDateLayouts
+	if dateLayout != "" {
+		dateLayouts = []string{dateLayout}
+	}
+
+	value := strings.TrimSpace(date + " " + clock)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Local(), nil
+	}
+	for _, layout := range dateLayouts {
+		if clock == "" {
+			if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		for _, clockLayout := range importTimeLayouts {
+			for _, separator := range []string{" ", "T"} {
+				if t, err := time.ParseInLocation(layout+separator+clockLayout, value, time.Local); err == nil {
+					return t, nil
+				}
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date or time %q", value)
+}
+
+// parseImportDuration parses hh:mm[:ss] or decimal hours (with "." or ",")
+func parseImportDuration(value string) (time.Duration, error) {
+	if parts := strings.Split(value, ":"); len(parts) >= 2 && len(parts) <= 3 {
+		var total time.Duration
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			total += time.Duration(n) * units[i]
+		}
+		return total, nil
+	}
+
+	hours, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || hours < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return time.Duration(hours * float64(time.Hour)), nil
+}
+
+// parseImportRow reads the range, category, project and note of a record
+func parseImportRow(record importRecord, mapping ImportMapping, row *ImportRow) error {
+	var start time.Time
+	var err error
+	if mapping.Start != "" && record.get(mapping.Start) != "" {
+		start, err = parseImportTime(record.get(mapping.Start), "", mapping.DateLayout)
+	} else {
+		start, err = parseImportTime(record.get(mapping.StartDate), record.get(mapping.StartTime), mapping.DateLayout)
+	}
+	if err != nil {
+		return fmt.Errorf("start: %v", err)
+	}
+
+	var end time.Time
+	switch {
+	case mapping.End != "" && record.get(mapping.End) != "":
+		end, err = parseImportTime(record.get(mapping.End), "", mapping.DateLayout)
+	case record.get(mapping.EndTime) != "":
+		endDate := record.get(mapping.EndDate)
+		if endDate == "" {
+			endDate = record.get(mapping.StartDate)
+		}
+		end, err = parseImportTime(endDate, record.get(mapping.EndTime), mapping.DateLayout)
+		if err == nil && record.get(mapping.EndDate) == "" && !end.After(start) {
+			end = end.AddDate(0, 0, 1) // Ends after midnight
+		}
+	case record.get(mapping.Duration) != "":
+		var duration time.Duration
+		duration, err = parseImportDuration(record.get(mapping.Duration))
+		end = start.Add(duration)
+	default:
+		return errors.New("no end time or duration")
+	}
+	if err != nil {
+		return fmt.Errorf("end: %v", err)
+	}
+
+	if !end.After(start) {
+		return ErrInvalidTimeRange
+	}
+	if end.Sub(start) > 24*time.Hour {
+		return errors.New("entry is longer than 24 hours")
+	}
+
+	row.Start, row.End = &start, &end
+	row.Hours = math.Round(end.Sub(start).Hours()*100) / 100
+	row.Category = record.get(mapping.Category)
+	if row.Category == "" {
+		row.Category = "Working"
+	}
+	row.Project = record.get(mapping.Project)
+	row.Note = record.get(mapping.Note)
+	return nil
+}
+
+// importUsers indexes users by lower-cased email and by Slack ID
+func importUsers() (map[string]User, map[string]User, error) {
+	var users []User
+	if err := DB.Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+	byEmail := make(map[string]User)
+	bySlackID := make(map[string]User)
+	for _, user := range users {
+		if user.Email != "" {
+			byEmail[strings.ToLower(user.Email)] = user
+		}
+		bySlackID[user.SlackUserID] = user
+	}
+	return byEmail, bySlackID, nil
+}
+
+// ImportTimeEntries reads time entries from a CSV file, validates them and, unless
+// the options ask for a dry run, creates the valid rows as one import batch. Rows whose
+// user is unknown, whose range cannot be read, that overlap existing entries or other
+// rows of the file, or that fall into locked periods are skipped.
+func ImportTimeEntries(r io.Reader, options ImportOptions) (*ImportResult, error) {
+	preset, ok := importPresets[options.Format]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidImport, options.Format)
+	}
+	mapping := mergeImportMapping(preset, options.Mapping)
+	if mapping.Email == "" && mapping.SlackID == "" {
+		return nil, fmt.Errorf("%w: an email or Slack ID column is required", ErrInvalidImport)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	delimiter := options.Delimiter
+	if delimiter == 0 {
+		firstLine, _, _ := strings.Cut(text, "\n")
+		delimiter = detectDelimiter(firstLine)
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%w: the file has no data rows", ErrInvalidImport)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns[strings.ToLower(mapping.Email)]; !ok {
+		if _, ok := columns[strings.ToLower(mapping.SlackID)]; !ok {
+			return nil, fmt.Errorf("%w: the file has neither a %q nor a %q column", ErrInvalidImport, mapping.Email, mapping.SlackID)
+		}
+	}
+
+	byEmail, bySlackID, err := importUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{DryRun: options.DryRun, Rows: len(records) - 1}
+	accepted := make(map[uint][]ImportRow)
+	for i, values := range records[1:] {
+		record := importRecord{columns: columns, values: values}
+		row := ImportRow{Line: i + 2}
+
+		user, found := byEmail[strings.ToLower(record.get(mapping.Email))]
+		if !found {
+			user, found = bySlackID[record.get(mapping.SlackID)]
+		}
+		switch {
+		case !found:
+			row.Error = fmt.Sprintf("no user with email %q or Slack ID %q", record.get(mapping.Email), record.get(mapping.SlackID))
+		default:
+			row.UserID, row.User = user.ID, user.Name
+			if err := parseImportRow(record, mapping, &row); err != nil {
+				row.Error = err.Error()
+			}
+		}
+
+		if row.Error == "" {
+			for _, other := range accepted[row.UserID] {
+				if row.Start.Before(*other.End) && other.Start.Before(*row.End) {
+					row.Error = fmt.Sprintf("overlaps line %d of the file", other.Line)
+					break
+				}
+			}
+		}
+		if row.Error == "" {
+			overlapping, err := FindOverlappingTimeEntries(row.UserID, *row.Start, *row.End, 0)
+			if err != nil {
+				return nil, err
+			}
+			if len(overlapping) > 0 {
+				row.Error = fmt.Sprintf("overlaps existing entry %d", overlapping[0].ID)
+			}
+		}
+		if row.Error == "" {
+			if err := CheckTimeEntryLocked(row.UserID, *row.Start, *row.End); err != nil {
+				row.Error = err.Error()
+			}
+		}
+
+		if row.Error != "" {
+			result.Skipped++
+		} else {
+			result.Valid++
+			result.Hours += row.Hours
+			accepted[row.UserID] = append(accepted[row.UserID], row)
+		}
+		result.Items = append(result.Items, row)
+	}
+	result.Hours = math.Round(result.Hours*100) / 100
+
+	if options.DryRun || result.Valid == 0 {
+		return result, nil
+	}
+
+	batch := &ImportBatch{
+		Filename:  options.Filename,
+		Format:    options.Format,
+		Status:    ImportBatchImported,
+		Rows:      result.Rows,
+		Imported:  result.Valid,
+		Skipped:   result.Skipped,
+		Hours:     result.Hours,
+		CreatedBy: options.CreatedBy,
+	}
+	var entries []TimeEntry
+	for _, row := range result.Items {
+		if row.Error != "" {
+			continue
+		}
+		end := *row.End
+		entries = append(entries, TimeEntry{
+			UserID:     row.UserID,
+			StartTime:  *row.Start,
+			EndTime:    &end,
+			Duration:   int64(end.Sub(*row.Start).Seconds()),
+			Status:     row.Category,
+			StatusText: row.Project,
+			Source:     TimeEntrySourceImport,
+			Note:       row.Note,
+		})
+	}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		projects := make(map[string]*uint)
+		for i := range entries {
+			entries[i].ImportID = &batch.ID
+			projectID, err := importProjectID(tx, entries[i].StatusText, projects)
+			if err != nil {
+				return err
+			}
+			entries[i].ProjectID = projectID
+		}
+		return tx.CreateInBatches(entries, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Batch = batch
+
+	refreshEntriesCompliance(entries)
+	return result, nil
+}
+
+// GetImportBatches returns all import batches, newest first
+func GetImportBatches() ([]ImportBatch, error) {
+	var batches []ImportBatch
+	err := DB.Order("created_at DESC, id DESC").Find(&batches).Error
+	return batches, err
+}
+
+// GetImportBatch returns a single import batch by ID
+func GetImportBatch(batchID uint) (*ImportBatch, error) {
+	var batch ImportBatch
+	if err := DB.First(&batch, batchID).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// RollbackImportBatch deletes the entries of an import batch, unless one of them lies
+// in a locked period, and marks the batch as rolled back
+func RollbackImportBatch(batch *ImportBatch, adminName string) error {
+	if batch.Status == ImportBatchRolledBack {
+		return ErrImportRolledBack
+	}
+
+	var entries []TimeEntry
+	if err := DB.Where("import_id = ?", batch.ID).Find(&entries).Error; err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := checkEntryRangeLocked(&entry); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("import_id = ?", batch.ID).Delete(&TimeEntry{}).Error; err != nil {
+			return err
+		}
+		batch.Status = ImportBatchRolledBack
+		batch.RolledBackAt = &now
+		batch.RolledBackBy = adminName
+		return tx.Save(batch).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	refreshEntriesCompliance(entries)
+	return nil
+}
+
+// refreshEntriesCompliance re-evaluates compliance over the range of each user's entries
+func refreshEntriesCompliance(entries []TimeEntry) {
+	type bounds struct{ start, end time.Time }
+	ranges := make(map[uint]*bounds)
+	for _, entry := range entries {
+		end := entry.StartTime
+		if entry.EndTime != nil {
+			end = *entry.EndTime
+		}
+		current, ok := ranges[entry.UserID]
+		if !ok {
+			ranges[entry.UserID] = &bounds{entry.StartTime, end}
+			continue
+		}
+		if entry.StartTime.Before(current.start) {
+			current.start = entry.StartTime
+		}
+		if end.After(current.end) {
+			current.end = end
+		}
+	}
+	for userID, current := range ranges {
+		RefreshUserCompliance(userID, current.start, current.end)
+	}
+}
@@ -0,0 +1,264 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseImportTime(t *testing.T) {
+	local := func(year int, month time.Month, day, hour, minute, second int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, 0, time.Local)
+	}
+
+	tests := []struct {
+		name       string
+		date       string
+		clock      string
+		dateLayout string
+		want       time.Time
+		wantErr    bool
+	}{
+		{name: "toggl date and time", date: "2026-03-02", clock: "09:15:00", want: local(2026, 3, 2, 9, 15, 0)},
+		{name: "toggl time after midnight", date: "2026-03-03", clock: "00:05:30", want: local(2026, 3, 3, 0, 5, 30)},
+		{name: "clockify 12-hour time", date: "03/02/2026", clock: "09:15 AM", dateLayout: "01/02/2006", want: local(2026, 3, 2, 9, 15, 0)},
+		{name: "clockify afternoon with seconds", date: "03/02/2026", clock: "1:05:30 PM", dateLayout: "01/02/2006", want: local(2026, 3, 2, 13, 5, 30)},
+		{name: "clockify noon", date: "03/02/2026", clock: "12:00 PM", dateLayout: "01/02/2006", want: local(2026, 3, 2, 12, 0, 0)},
+		{name: "clockify midnight", date: "03/02/2026", clock: "12:00 AM", dateLayout: "01/02/2006", want: local(2026, 3, 2, 0, 0, 0)},
+		{name: "clockify 24-hour time", date: "03/02/2026", clock: "17:45", dateLayout: "01/02/2006", want: local(2026, 3, 2, 17, 45, 0)},
+		{name: "clockify layout rejects ISO dates", date: "2026-03-02", clock: "09:15", dateLayout: "01/02/2006", wantErr: true},
+		{name: "RFC 3339 with offset", date: "2026-03-02T09:15:00+01:00", want: time.Date(2026, 3, 2, 8, 15, 0, 0, time.UTC)},
+		{name: "date and time in one column", date: "2026-03-02 09:15", want: local(2026, 3, 2, 9, 15, 0)},
+		{name: "date and time joined by T", date: "2026-03-02T09:15:00", want: local(2026, 3, 2, 9, 15, 0)},
+		{name: "date only", date: "2026-03-02", want: local(2026, 3, 2, 0, 0, 0)},
+		{name: "german date", date: "02.03.2026", clock: "09:15", want: local(2026, 3, 2, 9, 15, 0)},
+		{name: "slashed ISO date", date: "2026/03/02", clock: "09:15", want: local(2026, 3, 2, 9, 15, 0)},
+		{name: "invalid month", date: "2026-13-02", clock: "09:15", wantErr: true},
+		{name: "invalid time", date: "2026-03-02", clock: "25:00", wantErr: true},
+		{name: "text", date: "tomorrow", wantErr: true},
+		{name: "empty", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportTime(tt.date, tt.clock, tt.dateLayout)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseImportTime = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseImportTime: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseImportTime = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "toggl hh:mm:ss", value: "01:30:00", want: 90 * time.Minute},
+		{name: "toggl seconds", value: "00:00:45", want: 45 * time.Second},
+		{name: "toggl over a day", value: "25:10:00", want: 25*time.Hour + 10*time.Minute},
+		{name: "clockify h:mm:ss", value: "1:30:15", want: 90*time.Minute + 15*time.Second},
+		{name: "hh:mm", value: "2:45", want: 2*time.Hour + 45*time.Minute},
+		{name: "clockify decimal hours", value: "1.50", want: 90 * time.Minute},
+		{name: "decimal comma", value: "1,25", want: 75 * time.Minute},
+		{name: "whole hours", value: "8", want: 8 * time.Hour},
+		{name: "zero", value: "0:00:00", want: 0},
+		{name: "negative hours", value: "-1", wantErr: true},
+		{name: "negative minutes", value: "1:-30", wantErr: true},
+		{name: "too many parts", value: "1:02:03:04", wantErr: true},
+		{name: "text", value: "an hour", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportDuration(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseImportDuration = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseImportDuration: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseImportDuration = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// importTestFile has two valid rows and one row for each reason to skip a row
+const importTestFile = `email,slack_id,start,end,duration,category,project,note
+alice@example.com,,2026-03-03 09:00,2026-03-03 12:00,,,,Training
+,bob,2026-03-03 10:00,,1:30,Meeting,,
+alice@example.com,,2026-03-02 10:00,2026-03-02 12:00,,,,
+alice@example.com,,2026-03-03 11:00,2026-03-03 13:00,,,,
+alice@example.com,,2026-02-10 09:00,2026-02-10 10:00,,,,
+carol@example.com,,2026-03-04 09:00,2026-03-04 10:00,,,,
+`
+
+// setupImportTest creates the users of importTestFile, an existing entry of alice on
+// 2026-03-02 from 9:00 to 11:00 and a lock of February 2026
+func setupImportTest(t *testing.T) (*User, *User, *TimeEntry) {
+	t.Helper()
+	openTestDB(t)
+	alice := createTestUser(t, "alice")
+	bob := createTestUser(t, "bob")
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	existing := createClosedEntry(t, alice.ID, day.Add(9*time.Hour), day.Add(11*time.Hour))
+	createActiveLock(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local), time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local))
+	return alice, bob, existing
+}
+
+// countRows returns the number of rows of a model matching a condition
+func countRows(t *testing.T, model interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := DB.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		t.Fatalf("counting rows: %v", err)
+	}
+	return count
+}
+
+func TestImportTimeEntries(t *testing.T) {
+	wantErrors := map[int]string{
+		2: "",
+		3: "",
+		4: "overlaps existing entry",
+		5: "overlaps line 2 of the file",
+		6: ErrPeriodLocked.Error(),
+		7: "no user with email",
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		name := "import"
+		if dryRun {
+			name = "dry run"
+		}
+		t.Run(name, func(t *testing.T) {
+			alice, bob, existing := setupImportTest(t)
+
+			result, err := ImportTimeEntries(strings.NewReader(importTestFile),
+				ImportOptions{Format: "csv", Filename: "entries.csv", DryRun: dryRun, CreatedBy: "admin"})
+			if err != nil {
+				t.Fatalf("ImportTimeEntries: %v", err)
+			}
+			if result.DryRun != dryRun || result.Rows != 6 || result.Valid != 2 || result.Skipped != 4 || result.Hours != 4.5 {
+				t.Errorf("result dry run %v, rows %d, valid %d, skipped %d, hours %.2f; want %v, 6, 2, 4, 4.50",
+					result.DryRun, result.Rows, result.Valid, result.Skipped, result.Hours, dryRun)
+			}
+			for _, item := range result.Items {
+				want := wantErrors[item.Line]
+				if (want == "") != (item.Error == "") || !strings.Contains(item.Error, want) {
+					t.Errorf("line %d: error %q, want %q", item.Line, item.Error, want)
+				}
+			}
+
+			if dryRun {
+				if result.Batch != nil {
+					t.Errorf("dry run created batch %d", result.Batch.ID)
+				}
+				if count := countRows(t, &ImportBatch{}, "1 = 1"); count != 0 {
+					t.Errorf("dry run stored %d batches", count)
+				}
+				if count := countRows(t, &TimeEntry{}, "id <> ?", existing.ID); count != 0 {
+					t.Errorf("dry run stored %d entries", count)
+				}
+				return
+			}
+
+			if result.Batch == nil || result.Batch.Imported != 2 || result.Batch.Skipped != 4 {
+				t.Fatalf("batch = %+v, want 2 imported and 4 skipped", result.Batch)
+			}
+			var entries []TimeEntry
+			if err := DB.Where("import_id = ?", result.Batch.ID).Order("user_id ASC").Find(&entries).Error; err != nil {
+				t.Fatalf("loading imported entries: %v", err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("got %d imported entries, want 2", len(entries))
+			}
+			if entries[0].UserID != alice.ID || entries[0].Note != "Training" || entries[0].Duration != 3*3600 {
+				t.Errorf("alice's entry = %+v", entries[0])
+			}
+			if entries[1].UserID != bob.ID || entries[1].Status != "Meeting" || entries[1].Duration != 90*60 {
+				t.Errorf("bob's entry = %+v", entries[1])
+			}
+			for _, entry := range entries {
+				if entry.Source != TimeEntrySourceImport {
+					t.Errorf("entry %d has source %q, want import", entry.ID, entry.Source)
+				}
+			}
+		})
+	}
+}
+
+func TestImportTimeEntriesWithoutValidRows(t *testing.T) {
+	setupImportTest(t)
+	file := "email,start,end\ncarol@example.com,2026-03-04 09:00,2026-03-04 10:00\n"
+	result, err := ImportTimeEntries(strings.NewReader(file), ImportOptions{Format: "csv"})
+	if err != nil {
+		t.Fatalf("ImportTimeEntries: %v", err)
+	}
+	if result.Batch != nil || result.Valid != 0 || result.Skipped != 1 {
+		t.Errorf("result = %+v, want no batch and one skipped row", result)
+	}
+	if count := countRows(t, &ImportBatch{}, "1 = 1"); count != 0 {
+		t.Errorf("stored %d batches, want none", count)
+	}
+}
+
+func TestRollbackImportBatch(t *testing.T) {
+	_, _, existing := setupImportTest(t)
+	result, err := ImportTimeEntries(strings.NewReader(importTestFile), ImportOptions{Format: "csv", CreatedBy: "admin"})
+	if err != nil {
+		t.Fatalf("ImportTimeEntries: %v", err)
+	}
+	batch := result.Batch
+
+	// An imported entry in a locked period keeps the whole batch
+	createActiveLock(t, time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local), time.Date(2026, 3, 4, 0, 0, 0, 0, time.Local))
+	if err := RollbackImportBatch(batch, "admin"); !errors.Is(err, ErrPeriodLocked) {
+		t.Fatalf("rollback in a locked period: error = %v, want ErrPeriodLocked", err)
+	}
+	if count := countRows(t, &TimeEntry{}, "import_id = ?", batch.ID); count != 2 {
+		t.Fatalf("locked rollback left %d of 2 entries", count)
+	}
+	if err := DB.Where("1 = 1").Delete(&PeriodLock{}).Error; err != nil {
+		t.Fatalf("removing locks: %v", err)
+	}
+
+	if err := RollbackImportBatch(batch, "admin"); err != nil {
+		t.Fatalf("RollbackImportBatch: %v", err)
+	}
+	if count := countRows(t, &TimeEntry{}, "import_id = ?", batch.ID); count != 0 {
+		t.Errorf("rollback left %d entries", count)
+	}
+	if _, err := GetTimeEntry(existing.ID); err != nil {
+		t.Errorf("rollback removed the existing entry: %v", err)
+	}
+
+	stored, err := GetImportBatch(batch.ID)
+	if err != nil {
+		t.Fatalf("GetImportBatch: %v", err)
+	}
+	if stored.Status != ImportBatchRolledBack || stored.RolledBackAt == nil || stored.RolledBackBy != "admin" {
+		t.Errorf("batch status %q, rolled back at %v by %q", stored.Status, stored.RolledBackAt, stored.RolledBackBy)
+	}
+	if err := RollbackImportBatch(stored, "admin"); !errors.Is(err, ErrImportRolledBack) {
+		t.Errorf("second rollback: error = %v, want ErrImportRolledBack", err)
+	}
+}
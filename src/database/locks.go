@@ -0,0 +1,173 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrPeriodLocked is returned when a change would alter time inside a locked period
+	ErrPeriodLocked = errors.New("time entries in this period are locked")
+	// ErrLockNotActive is returned when unlocking a lock that was already lifted
+	ErrLockNotActive = errors.New("period lock is not active")
+)
+
+// CreatePeriodLock locks the range [start, end) against time entry changes. Entries
+// running into the range are ended first, as they cannot be changed afterwards.
+func CreatePeriodLock(start, end time.Time, reason string, adminID uint, adminName string) (*PeriodLock, error) {
+	if !end.After(start) {
+		return nil, ErrInvalidTimeRange
+	}
+	if err := endEntriesRunningInto(0, start.Local(), end.Local()); err != nil {
+		return nil, err
+	}
+
+	lock := PeriodLock{
+		StartTime:    start.Local(),
+		EndTime:      end.Local(),
+		Reason:       reason,
+		LockedBy:     adminID,
+		LockedByName: adminName,
+	}
+
+	err := DB.Create(&lock).Error
+	return &lock, err
+}
+
+// GetPeriodLock returns a single period lock by ID
+func GetPeriodLock(lockID uint) (*PeriodLock, error) {
+	var lock PeriodLock
+	err := DB.First(&lock, lockID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// GetPeriodLocks returns period locks, newest period first, optionally only the active ones
+func GetPeriodLocks(activeOnly bool) ([]PeriodLock, error) {
+	query := DB.Model(&PeriodLock{})
+	if activeOnly {
+		query = query.Where("unlocked_at IS NULL")
+	}
+
+	var locks []PeriodLock
+	err := query.Order("start_time DESC").Find(&locks).Error
+	return locks, err
+}
+
+// UnlockPeriod lifts an active period lock, keeping it for history
+func UnlockPeriod(lock *PeriodLock, adminID uint, adminName string) error {
+	if lock.UnlockedAt != nil {
+		return ErrLockNotActive
+	}
+
+	now := time.Now()
+	lock.UnlockedAt = &now
+	lock.UnlockedBy = adminID
+	lock.UnlockedByName = adminName
+
+	return DB.Save(lock).Error
+}
+
+// CheckTimeEntryLocked returns ErrPeriodLocked if the range of a user's entry touches
+// an active period lock or an approved timesheet week
+func CheckTimeEntryLocked(userID uint, start, end time.Time) error {
+	var lock PeriodLock
+	result := DB.Where("unlocked_at IS NULL AND start_time < ? AND end_time > ?", end, start).
+		Limit(1).
+		Find(&lock)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return fmt.Errorf("%w: %s to %s is closed (%s)", ErrPeriodLocked,
+			lock.StartTime.Format("2006-01-02"),
+			lock.EndTime.AddDate(0, 0, -1).Format("2006-01-02"),
+			lock.Reason)
+	}
+
+	var timesheet Timesheet
+	result = DB.Where("user_id = ? AND status = ?", userID, TimesheetStatusApproved).
+		Where("week_start < ? AND week_start > ?", end, start.AddDate(0, 0, -7)).
+		Limit(1).
+		Find(&timesheet)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return fmt.Errorf("%w: the timesheet for the week of %s is approved", ErrPeriodLocked,
+			timesheet.WeekStart.Format("2006-01-02"))
+	}
+
+	return nil
+}
+
+// firstLockedStart returns the earliest start of the active period locks and approved
+// timesheet weeks of a user that overlap [from, to)
+func firstLockedStart(userID uint, from, to time.Time) (time.Time, bool, error) {
+	var start time.Time
+	locked := false
+
+	var lock PeriodLock
+	result := DB.Where("unlocked_at IS NULL AND start_time < ? AND end_time > ?", to, from).
+		Order("start_time ASC").
+		Limit(1).
+		Find(&lock)
+	if result.Error != nil {
+		return start, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		start, locked = lock.StartTime, true
+	}
+
+	var timesheet Timesheet
+	result = DB.Where("user_id = ? AND status = ?", userID, TimesheetStatusApproved).
+		Where("week_start < ? AND week_start > ?", to, from.AddDate(0, 0, -7)).
+		Order("week_start ASC").
+		Limit(1).
+		Find(&timesheet)
+	if result.Error != nil {
+		return start, false, result.Error
+	}
+	if result.RowsAffected > 0 && (!locked || timesheet.WeekStart.Before(start)) {
+		start, locked = timesheet.WeekStart, true
+	}
+
+	return start, locked, nil
+}
+
+// endEntriesRunningInto ends the running entries that overlap a range about to be
+// locked, of one user or of all users if userID is 0. Entries that started before the
+// range stop where it starts; entries that started inside it end now, or where it ends
+// if that has passed. Entries have not reached a range in the future yet.
+func endEntriesRunningInto(userID uint, start, end time.Time) error {
+	now := time.Now()
+	if !now.After(start) {
+		return nil
+	}
+
+	query := DB.Where("end_time IS NULL AND start_time < ?", end)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var entries []TimeEntry
+	if err := query.Find(&entries).Error; err != nil {
+		return err
+	}
+
+	for i := range entries {
+		stop := now
+		if end.Before(stop) {
+			stop = end
+		}
+		if entries[i].StartTime.Before(start) {
+			stop = start
+		}
+		if err := endEntryAt(&entries[i], stop); err != nil {
+			return err
+		}
+	}
+	return nil
+}
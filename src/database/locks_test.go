@@ -0,0 +1,287 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// assertEntryEnded checks that an entry ended at want, within a second, with its duration
+func assertEntryEnded(t *testing.T, entryID uint, want time.Time) {
+	t.Helper()
+	ended, err := GetTimeEntry(entryID)
+	if err != nil {
+		t.Fatalf("GetTimeEntry: %v", err)
+	}
+	if ended.EndTime == nil {
+		t.Fatal("entry is still running")
+	}
+	if diff := ended.EndTime.Sub(want); diff < -time.Second || diff > time.Second {
+		t.Errorf("end = %v, want %v", ended.EndTime, want)
+	}
+	if wantDuration := int64(ended.EndTime.Sub(ended.StartTime).Seconds()); ended.Duration != wantDuration {
+		t.Errorf("duration = %d, want %d", ended.Duration, wantDuration)
+	}
+}
+
+// createActiveLock stores a period lock without ending the entries running into it, as
+// if it had been created before they started
+func createActiveLock(t *testing.T, start, end time.Time) {
+	t.Helper()
+	lock := PeriodLock{StartTime: start, EndTime: end, Reason: "payroll", LockedBy: 1, LockedByName: "admin"}
+	if err := DB.Create(&lock).Error; err != nil {
+		t.Fatalf("creating lock: %v", err)
+	}
+}
+
+func TestCreatePeriodLockWhileEntryRunning(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name        string
+		start       time.Time // Start of the running entry
+		lockStart   time.Time
+		lockEnd     time.Time
+		wantEnd     time.Time // Zero if the entry keeps running until it is ended now
+		stillActive bool
+	}{
+		{
+			name:      "lock starts after the entry and covers now",
+			start:     now.Add(-2 * time.Hour),
+			lockStart: now.Add(-time.Hour),
+			lockEnd:   now.Add(24 * time.Hour),
+			wantEnd:   now.Add(-time.Hour),
+		},
+		{
+			name:      "entry started inside the lock",
+			start:     now.Add(-2 * time.Hour),
+			lockStart: now.Add(-3 * time.Hour),
+			lockEnd:   now.Add(24 * time.Hour),
+			wantEnd:   now,
+		},
+		{
+			name:      "lock lies in the past",
+			start:     now.Add(-3 * time.Hour),
+			lockStart: now.Add(-2 * time.Hour),
+			lockEnd:   now.Add(-time.Hour),
+			wantEnd:   now.Add(-2 * time.Hour),
+		},
+		{
+			name:      "entry started inside a lock that lies in the past",
+			start:     now.Add(-2 * time.Hour),
+			lockStart: now.Add(-3 * time.Hour),
+			lockEnd:   now.Add(-time.Hour),
+			wantEnd:   now.Add(-time.Hour),
+		},
+		{
+			name:        "lock lies in the future",
+			start:       now.Add(-time.Hour),
+			lockStart:   now.Add(time.Hour),
+			lockEnd:     now.Add(2 * time.Hour),
+			stillActive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			user := createTestUser(t, "U1")
+			entry := createRunningEntry(t, user.ID, tt.start)
+
+			if _, err := CreatePeriodLock(tt.lockStart, tt.lockEnd, "payroll", 1, "admin"); err != nil {
+				t.Fatalf("CreatePeriodLock: %v", err)
+			}
+
+			if tt.stillActive {
+				running, err := GetTimeEntry(entry.ID)
+				if err != nil {
+					t.Fatalf("GetTimeEntry: %v", err)
+				}
+				if running.EndTime != nil {
+					t.Fatalf("entry ended at %v, want it running", running.EndTime)
+				}
+				if err := EndTimeEntry(user.ID); err != nil {
+					t.Fatalf("EndTimeEntry: %v", err)
+				}
+				assertEntryEnded(t, entry.ID, time.Now())
+				return
+			}
+
+			assertEntryEnded(t, entry.ID, tt.wantEnd)
+			if err := EndTimeEntry(user.ID); err != nil {
+				t.Errorf("EndTimeEntry after the lock: %v", err)
+			}
+		})
+	}
+}
+
+func TestEndTimeEntryStopsAtFirstLock(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		locks   [][2]time.Time
+		wantEnd time.Time
+	}{
+		{
+			name:    "lock that has closed again",
+			locks:   [][2]time.Time{{now.Add(-3 * time.Hour), now.Add(-2 * time.Hour)}},
+			wantEnd: now.Add(-3 * time.Hour),
+		},
+		{
+			name:    "lock covering now",
+			locks:   [][2]time.Time{{now.Add(-time.Hour), now.Add(time.Hour)}},
+			wantEnd: now.Add(-time.Hour),
+		},
+		{
+			name: "earliest of several locks",
+			locks: [][2]time.Time{
+				{now.Add(-time.Hour), now.Add(time.Hour)},
+				{now.Add(-4 * time.Hour), now.Add(-3 * time.Hour)},
+			},
+			wantEnd: now.Add(-4 * time.Hour),
+		},
+		{
+			name:    "unrelated lock before the entry",
+			locks:   [][2]time.Time{{now.Add(-8 * time.Hour), now.Add(-6 * time.Hour)}},
+			wantEnd: now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			user := createTestUser(t, "U1")
+			entry := createRunningEntry(t, user.ID, now.Add(-5*time.Hour))
+			for _, lock := range tt.locks {
+				createActiveLock(t, lock[0], lock[1])
+			}
+
+			if err := EndTimeEntry(user.ID); err != nil {
+				t.Fatalf("EndTimeEntry: %v", err)
+			}
+			assertEntryEnded(t, entry.ID, tt.wantEnd)
+		})
+	}
+}
+
+func TestEndTimeEntryInsideLockIsRefused(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+	entry := createRunningEntry(t, user.ID, time.Now().Add(-2*time.Hour))
+	createActiveLock(t, time.Now().Add(-3*time.Hour), time.Now().Add(time.Hour))
+
+	if err := EndTimeEntry(user.ID); !errors.Is(err, ErrPeriodLocked) {
+		t.Fatalf("EndTimeEntry error = %v, want ErrPeriodLocked", err)
+	}
+	running, err := GetTimeEntry(entry.ID)
+	if err != nil {
+		t.Fatalf("GetTimeEntry: %v", err)
+	}
+	if running.EndTime != nil || running.Duration != 0 {
+		t.Errorf("locked entry was changed: end %v, duration %d", running.EndTime, running.Duration)
+	}
+}
+
+func TestEndTimeEntryAfterTimesheetApprovedWhileRunning(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+	weekStart := WeekStartOf(time.Now())
+	entry := createRunningEntry(t, user.ID, weekStart.Add(-time.Hour))
+
+	timesheet := Timesheet{UserID: user.ID, WeekStart: weekStart, WeekEnd: weekStart.AddDate(0, 0, 7), Status: TimesheetStatusApproved}
+	if err := DB.Create(&timesheet).Error; err != nil {
+		t.Fatalf("creating timesheet: %v", err)
+	}
+
+	if err := EndTimeEntry(user.ID); err != nil {
+		t.Fatalf("EndTimeEntry: %v", err)
+	}
+	assertEntryEnded(t, entry.ID, weekStart)
+}
+
+func TestApprovingTimesheetEndsRunningEntry(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+	other := createTestUser(t, "U2")
+	weekStart := WeekStartOf(time.Now())
+	entry := createRunningEntry(t, user.ID, weekStart.Add(-time.Hour))
+	otherEntry := createRunningEntry(t, other.ID, weekStart.Add(-time.Hour))
+
+	timesheet := Timesheet{UserID: user.ID, WeekStart: weekStart, WeekEnd: weekStart.AddDate(0, 0, 7), Status: TimesheetStatusSubmitted}
+	if err := DB.Create(&timesheet).Error; err != nil {
+		t.Fatalf("creating timesheet: %v", err)
+	}
+	if err := TransitionTimesheet(&timesheet, TimesheetStatusApproved, 1); err != nil {
+		t.Fatalf("TransitionTimesheet: %v", err)
+	}
+
+	assertEntryEnded(t, entry.ID, weekStart)
+	running, err := GetTimeEntry(otherEntry.ID)
+	if err != nil {
+		t.Fatalf("GetTimeEntry: %v", err)
+	}
+	if running.EndTime != nil {
+		t.Error("the entry of another user was ended")
+	}
+}
+
+func TestStartTimeEntryInsideLockedPeriod(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+	createRunningEntry(t, user.ID, time.Now().Add(-2*time.Hour))
+
+	if _, err := CreatePeriodLock(time.Now().Add(-time.Hour), time.Now().Add(time.Hour), "payroll", 1, "admin"); err != nil {
+		t.Fatalf("CreatePeriodLock: %v", err)
+	}
+
+	if _, err := StartTimeEntry(user.ID, "Working", "", ""); err == nil {
+		t.Error("StartTimeEntry inside a locked period succeeded")
+	}
+	if err := EndTimeEntry(user.ID); err != nil {
+		t.Errorf("EndTimeEntry: %v", err)
+	}
+}
+
+func TestStartTimeEntryEndsEntryRunningThroughLock(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+	previous := createRunningEntry(t, user.ID, time.Now().Add(-3*time.Hour))
+
+	if _, err := CreatePeriodLock(time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour), "payroll", 1, "admin"); err != nil {
+		t.Fatalf("CreatePeriodLock: %v", err)
+	}
+
+	entry, err := StartTimeEntry(user.ID, "Working", "Working on #matchday-app", "")
+	if err != nil {
+		t.Fatalf("StartTimeEntry: %v", err)
+	}
+	if entry.EndTime != nil {
+		t.Error("new entry is not running")
+	}
+
+	ended, err := GetTimeEntry(previous.ID)
+	if err != nil {
+		t.Fatalf("GetTimeEntry: %v", err)
+	}
+	if ended.EndTime == nil || ended.Duration == 0 {
+		t.Errorf("previous entry was not ended with its duration: end %v, duration %d", ended.EndTime, ended.Duration)
+	}
+}
+
+func TestStartTimeEntryKeepsOneRunningEntryWhenTheActiveOneIsLocked(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+	previous := createRunningEntry(t, user.ID, time.Now().Add(-2*time.Hour))
+	createActiveLock(t, time.Now().Add(-3*time.Hour), time.Now().Add(-time.Hour))
+
+	if _, err := StartTimeEntry(user.ID, "Working", "", ""); !errors.Is(err, ErrPeriodLocked) {
+		t.Fatalf("StartTimeEntry error = %v, want ErrPeriodLocked", err)
+	}
+
+	var running []TimeEntry
+	if err := DB.Where("user_id = ? AND end_time IS NULL", user.ID).Find(&running).Error; err != nil {
+		t.Fatalf("loading running entries: %v", err)
+	}
+	if len(running) != 1 || running[0].ID != previous.ID {
+		t.Errorf("running entries = %+v, want only entry %d", running, previous.ID)
+	}
+}
@@ -13,6 +13,10 @@ type User struct {
 	RealName     string    `json:"real_name"`
 	ProfileImage string    `json:"profile_image"`
 	IsActive     bool      `json:"is_active" gorm:"default:true"`
+	InactiveBy   string    `json:"inactive_by"`      // Who deactivated the user: "admin" or "tracking_scope"
+	Country      string    `json:"country"`          // ISO country code, selects holiday calendars
+	Team         string    `json:"team"`             // Primary team name, selects holiday calendars
+	PersonnelNo  string    `json:"personnel_number"` // Employee number in the payroll system
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 
@@ -20,21 +24,47 @@ type User struct {
 	TimeEntries []TimeEntry `json:"time_entries" gorm:"foreignKey:UserID"`
 }
 
+// Time entry sources
+const (
+	TimeEntrySourceSlack  = "slack"  // Derived from Slack status changes
+	TimeEntrySourceManual = "manual" // Created or edited by an admin
+	TimeEntrySourceImport = "import" // Imported from a CSV file, see ImportBatch
+)
+
 // TimeEntry represents a time tracking entry for a user
 type TimeEntry struct {
 	ID          uint       `json:"id" gorm:"primaryKey"`
 	UserID      uint       `json:"user_id" gorm:"not null"`
 	StartTime   time.Time  `json:"start_time" gorm:"not null"`
 	EndTime     *time.Time `json:"end_time"`
-	Duration    int64      `json:"duration"` // Duration in seconds
-	Status      string     `json:"status" gorm:"not null"`
+	Duration    int64      `json:"duration"`               // Duration in seconds
+	Status      string     `json:"status" gorm:"not null"` // Category of the entry, e.g. "Working"
 	StatusText  string     `json:"status_text"`
 	StatusEmoji string     `json:"status_emoji"`
+	Source      string     `json:"source" gorm:"not null;default:slack"`
+	Note        string     `json:"note"`
+	ImportID    *uint      `json:"import_id,omitempty" gorm:"index"` // Import batch of imported entries
+	ProjectID   *uint      `json:"project_id" gorm:"index"`
+	Tags        string     `json:"tags"` // Comma-separated tags of the status text
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 
 	// Relationships
-	User User `json:"user" gorm:"foreignKey:UserID"`
+	User    *User    `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Project *Project `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+}
+
+// Project is an entry of the project catalogue. Time entries are booked on the project
+// whose tag appears in their status text.
+type Project struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Tag        string    `json:"tag" gorm:"uniqueIndex;not null"` // Lowercase tag, e.g. "matchday-app"
+	Name       string    `json:"name" gorm:"not null"`
+	Client     string    `json:"client"`
+	Billable   bool      `json:"billable"`
+	HourlyRate float64   `json:"hourly_rate"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // UserSummary represents aggregated user data for dashboard
@@ -48,6 +78,26 @@ type UserSummary struct {
 	CurrentStatus      string    `json:"current_status"`
 	WeeklyHours        float64   `json:"weekly_hours"`
 	MonthlyHours       float64   `json:"monthly_hours"`
+	WeeklyTarget       float64   `json:"weekly_target"`  // Required hours over the weekly window
+	MonthlyTarget      float64   `json:"monthly_target"` // Required hours for the current month
+
+	// Break deductions applied at report time; WeeklyHours and MonthlyHours stay gross
+	WeeklyBreakDeduction  float64 `json:"weekly_break_deduction"`
+	WeeklyNetHours        float64 `json:"weekly_net_hours"`
+	MonthlyBreakDeduction float64 `json:"monthly_break_deduction"`
+	MonthlyNetHours       float64 `json:"monthly_net_hours"`
+
+	// Hours rounded by the rounding policy, reported next to the exact values for exports
+	WeeklyRoundedHours  float64 `json:"weekly_rounded_hours"`
+	MonthlyRoundedHours float64 `json:"monthly_rounded_hours"`
+}
+
+// WeeklyCompletion returns the weekly hours as a percentage of the weekly target
+func (s UserSummary) WeeklyCompletion() float64 {
+	if s.WeeklyTarget <= 0 {
+		return 100
+	}
+	return s.WeeklyHours / s.WeeklyTarget * 100
 }
 
 // WeeklyReport represents weekly time tracking report
@@ -57,9 +107,13 @@ type WeeklyReport struct {
 	Email          string    `json:"email"`
 	WeekStart      time.Time `json:"week_start"`
 	WeekEnd        time.Time `json:"week_end"`
-	TotalHours     float64   `json:"total_hours"`
+	TotalHours     float64   `json:"total_hours"` // Gross tracked hours
 	RequiredHours  float64   `json:"required_hours"`
 	CompletionRate float64   `json:"completion_rate"`
+	BreakHours     float64   `json:"break_deduction_hours"` // Unrecorded breaks deducted by the break policy
+	NetHours       float64   `json:"net_hours"`             // Payable hours after break deductions
+	RoundedHours   float64   `json:"rounded_hours"`         // Gross hours after the rounding policy
+	RoundedNet     float64   `json:"rounded_net_hours"`     // Rounded hours after break deductions
 }
 
 // Admin represents admin user session
@@ -95,3 +149,395 @@ type Session struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// Timesheet states
+const (
+	TimesheetStatusDraft     = "draft"
+	TimesheetStatusSubmitted = "submitted"
+	TimesheetStatusApproved  = "approved"
+	TimesheetStatusRejected  = "rejected"
+)
+
+// Timesheet is a user's weekly sign-off of tracked time
+type Timesheet struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_timesheet_user_week"`
+	WeekStart     time.Time  `json:"week_start" gorm:"not null;uniqueIndex:idx_timesheet_user_week"`
+	WeekEnd       time.Time  `json:"week_end" gorm:"not null"`
+	Status        string     `json:"status" gorm:"not null;default:draft;index"`
+	TotalHours    float64    `json:"total_hours"`
+	RequiredHours float64    `json:"required_hours"`
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	ReviewedBy    uint       `json:"reviewed_by"` // Admin ID that approved or rejected the timesheet
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relationships
+	User     *User              `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Comments []TimesheetComment `json:"comments,omitempty" gorm:"foreignKey:TimesheetID"`
+}
+
+// TimesheetComment is a note left on a timesheet, usually alongside a state change
+type TimesheetComment struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	TimesheetID uint      `json:"timesheet_id" gorm:"not null;index"`
+	AuthorID    uint      `json:"author_id"`
+	AuthorName  string    `json:"author_name"`
+	Status      string    `json:"status"` // Timesheet state the comment was made in
+	Body        string    `json:"body" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Contract defines a user's working time agreement for a period of time
+type Contract struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	ContractType  string     `json:"contract_type"` // e.g. "full_time", "part_time", "working_student"
+	WeeklyHours   float64    `json:"weekly_hours" gorm:"not null"`
+	WorkingDays   string     `json:"working_days" gorm:"not null"` // Comma-separated weekdays, e.g. "mon,tue,wed,thu,fri"
+	EffectiveFrom time.Time  `json:"effective_from" gorm:"not null"`
+	EffectiveTo   *time.Time `json:"effective_to"` // Inclusive last day, nil while the contract is ongoing
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Absence types
+const (
+	AbsenceTypeVacation = "vacation"
+	AbsenceTypeSick     = "sick"
+	AbsenceTypeUnpaid   = "unpaid"
+	AbsenceTypeOther    = "other"
+)
+
+// Absence states
+const (
+	AbsenceStatusRequested = "requested"
+	AbsenceStatusApproved  = "approved"
+	AbsenceStatusRejected  = "rejected"
+)
+
+// Absence sources
+const (
+	AbsenceSourceManual = "manual" // Entered by an admin
+	AbsenceSourceSlack  = "slack"  // Detected from a Slack status
+)
+
+// Absence is a period a user is on leave; approved absences reduce required hours
+type Absence struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	Type         string     `json:"type" gorm:"not null"`
+	StartDate    time.Time  `json:"start_date" gorm:"not null;index"` // First day (local midnight)
+	EndDate      time.Time  `json:"end_date" gorm:"not null;index"`   // Last day, inclusive
+	StartHalfDay bool       `json:"start_half_day"`                   // Only the second half of the first day is taken
+	EndHalfDay   bool       `json:"end_half_day"`                     // Only the first half of the last day is taken
+	Status       string     `json:"status" gorm:"not null;default:requested;index"`
+	Source       string     `json:"source" gorm:"not null;default:manual"`
+	Note         string     `json:"note"`
+	ReviewedBy   uint       `json:"reviewed_by"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// HolidayCalendar is a set of public holidays imported from an ICS file. A calendar
+// applies to users of its country or team; one with neither applies to everyone.
+type HolidayCalendar struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Name       string     `json:"name" gorm:"uniqueIndex;not null"`
+	Country    string     `json:"country"`
+	Team       string     `json:"team"`
+	SourcePath string     `json:"source_path"` // File the calendar was imported from, empty for uploads
+	ImportedAt *time.Time `json:"imported_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	Holidays []Holiday `json:"holidays,omitempty" gorm:"foreignKey:CalendarID"`
+}
+
+// Holiday is a single public holiday of a calendar
+type Holiday struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CalendarID uint      `json:"calendar_id" gorm:"not null;index"`
+	Date       time.Time `json:"date" gorm:"not null;index"` // Local midnight
+	Name       string    `json:"name"`
+	UID        string    `json:"uid"` // VEVENT UID from the ICS file
+}
+
+// Balance adjustment kinds
+const (
+	BalanceAdjustmentManual = "adjustment" // Signed correction of the balance
+	BalanceAdjustmentPayout = "payout"     // Overtime paid out, reduces the balance
+)
+
+// BalanceAdjustment is a manual change to a user's flextime balance
+type BalanceAdjustment struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        uint      `json:"user_id" gorm:"not null;index"`
+	Date          time.Time `json:"date" gorm:"not null"` // Day the adjustment takes effect
+	Kind          string    `json:"kind" gorm:"not null;default:adjustment"`
+	Hours         float64   `json:"hours"` // Signed for adjustments, positive for payouts
+	Reason        string    `json:"reason"`
+	CreatedBy     uint      `json:"created_by"`
+	CreatedByName string    `json:"created_by_name"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ComplianceRuleSet holds working-time limits. Like holiday calendars, a rule set applies
+// to users of its country or team; users without one use the configured defaults.
+type ComplianceRuleSet struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Name            string    `json:"name" gorm:"uniqueIndex;not null"`
+	Country         string    `json:"country"`
+	Team            string    `json:"team"`
+	MaxDailyHours   float64   `json:"max_daily_hours"`   // 0 disables the rule
+	MinRestHours    float64   `json:"min_rest_hours"`    // Rest between working days, 0 disables the rule
+	BreakAfterHours float64   `json:"break_after_hours"` // Work above which a break is mandatory, 0 disables the rule
+	MinBreakMinutes int       `json:"min_break_minutes"`
+	WarnBeforeMins  int       `json:"warn_before_minutes"` // Slack warning lead time, 0 disables warnings
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Compliance rules
+const (
+	ComplianceRuleMaxDaily = "max_daily_hours"
+	ComplianceRuleMinRest  = "min_rest"
+	ComplianceRuleBreak    = "break"
+)
+
+// ComplianceViolation is a breach of a compliance rule on a day
+type ComplianceViolation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_violation_user_rule_date"`
+	Rule        string    `json:"rule" gorm:"not null;uniqueIndex:idx_violation_user_rule_date"`
+	Date        time.Time `json:"date" gorm:"not null;uniqueIndex:idx_violation_user_rule_date"`
+	RuleSetName string    `json:"rule_set_name"`
+	Value       float64   `json:"value"` // Measured hours (minutes for breaks)
+	Limit       float64   `json:"limit"`
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relationships
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// PeriodLock closes a date range (e.g. a payroll month) against changes to time entries
+type PeriodLock struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	StartTime      time.Time  `json:"start_time" gorm:"not null;index"` // Inclusive
+	EndTime        time.Time  `json:"end_time" gorm:"not null;index"`   // Exclusive
+	Reason         string     `json:"reason"`
+	LockedBy       uint       `json:"locked_by"`
+	LockedByName   string     `json:"locked_by_name"`
+	UnlockedAt     *time.Time `json:"unlocked_at"` // Set when the lock is lifted; the row is kept for history
+	UnlockedBy     uint       `json:"unlocked_by"`
+	UnlockedByName string     `json:"unlocked_by_name"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CalendarFeed grants access to a user's iCalendar feed of tracked sessions without a
+// dashboard session. Only a hash of the token is stored; a new token replaces the old one.
+type CalendarFeed struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex;not null"` // Hex SHA-256 of the token
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Import batch states
+const (
+	ImportBatchImported   = "imported"
+	ImportBatchRolledBack = "rolled_back"
+)
+
+// ImportBatch is one CSV file imported as time entries. Its entries are tagged with
+// the batch so that the whole import can be rolled back.
+type ImportBatch struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Filename     string     `json:"filename"`
+	Format       string     `json:"format"` // toggl, clockify or csv
+	Status       string     `json:"status" gorm:"not null;default:imported"`
+	Rows         int        `json:"rows"`     // Data rows in the file
+	Imported     int        `json:"imported"` // Entries created
+	Skipped      int        `json:"skipped"`  // Rows rejected by validation
+	Hours        float64    `json:"hours"`    // Hours of the created entries
+	CreatedBy    string     `json:"created_by"`
+	RolledBackAt *time.Time `json:"rolled_back_at"`
+	RolledBackBy string     `json:"rolled_back_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Team is a group of users for dashboards, filters and targets. A user can belong to
+// several teams; User.Team names the primary one, which selects holiday calendars and
+// compliance rules and groups reports by team.
+type Team struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Name          string    `json:"name" gorm:"uniqueIndex;not null"`
+	Description   string    `json:"description"`
+	WeeklyTarget  float64   `json:"weekly_target"`                   // Team target hours, 0 for the sum of the members' contracts
+	MonthlyTarget float64   `json:"monthly_target"`                  // Team target hours, 0 for the sum of the members' contracts
+	SlackGroupID  string    `json:"slack_usergroup_id" gorm:"index"` // Slack user group the members are synced from
+	SlackChannel  string    `json:"slack_channel_id" gorm:"index"`   // Slack channel the members are synced from
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Relationships
+	Members []TeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID"`
+}
+
+// Team membership sources
+const (
+	TeamMemberManual  = "manual"  // Added by an admin
+	TeamMemberPrimary = "primary" // Follows the user's primary team
+	TeamMemberSlack   = "slack"   // Synced from the team's Slack user group or channel
+)
+
+// TeamMember is the membership of a user in a team
+type TeamMember struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TeamID    uint      `json:"team_id" gorm:"not null;uniqueIndex:idx_team_member"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_team_member;index"`
+	Source    string    `json:"source" gorm:"not null;default:manual"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Tracking rule kinds
+const (
+	TrackingRuleUserGroup   = "usergroup"    // Members of a Slack user group (value: user group ID)
+	TrackingRuleChannel     = "channel"      // Members of a Slack channel (value: channel ID)
+	TrackingRuleEmailDomain = "email_domain" // Users with an email address at a domain
+	TrackingRuleGuest       = "guest"        // Single- and multi-channel guests (no value)
+	TrackingRuleUser        = "user"         // A single user (value: Slack user ID or email)
+)
+
+// Deactivations of users (User.InactiveBy)
+const (
+	UserInactiveByAdmin         = "admin"          // Deactivated on the dashboard; stays inactive
+	UserInactiveByTrackingScope = "tracking_scope" // Left the tracking scope; reactivated when back in it
+)
+
+// Tracking rule actions
+const (
+	TrackingRuleInclude = "include"
+	TrackingRuleExclude = "exclude"
+)
+
+// TrackingRule decides which Slack users are tracked. With include rules, only users
+// matching one of them are tracked; users matching an exclude rule never are.
+type TrackingRule struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Kind      string    `json:"kind" gorm:"not null"`
+	Value     string    `json:"value"`
+	Action    string    `json:"action" gorm:"not null;default:include"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Report schedule types
+const (
+	ReportTypeUsers      = "users"      // User summaries, or the workbook of the range for xlsx
+	ReportTypeWeekly     = "weekly"     // Weekly reports of the week the range starts in
+	ReportTypeReport     = "report"     // Grouped date-range report, see ReportQuery
+	ReportTypeTimesheets = "timesheets" // Monthly PDF timesheets of the month the range starts in
+)
+
+// Report schedule ranges, relative to the time a report is generated
+const (
+	ReportRangeToday         = "today"
+	ReportRangeYesterday     = "yesterday"
+	ReportRangeCurrentWeek   = "current_week"
+	ReportRangePreviousWeek  = "previous_week"
+	ReportRangeCurrentMonth  = "current_month"
+	ReportRangePreviousMonth = "previous_month"
+	ReportRangeLast7Days     = "last_7_days"
+	ReportRangeLast30Days    = "last_30_days"
+)
+
+// ReportSchedule generates a report on a cron schedule and stores it in the report archive
+type ReportSchedule struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	Name         string     `json:"name" gorm:"uniqueIndex;not null"`
+	Cron         string     `json:"cron" gorm:"not null"` // Five-field cron expression in server time, e.g. "0 7 * * mon"
+	Type         string     `json:"type" gorm:"not null;default:weekly"`
+	Range        string     `json:"range" gorm:"not null;default:previous_week"`
+	Format       string     `json:"format" gorm:"not null;default:csv"` // csv, xlsx, json (report type) or pdf (timesheets type)
+	GroupBy      string     `json:"group_by"`                           // Report types only
+	Users        string     `json:"users"`                              // Comma-separated user IDs, report and timesheets types
+	Teams        string     `json:"teams"`                              // Comma-separated team names, report and timesheets types
+	Categories   string     `json:"categories"`                         // Comma-separated categories, report type only
+	SlackChannel string     `json:"slack_channel"`                      // Channel ID the file is uploaded to, empty to only archive
+	Enabled      bool       `json:"enabled" gorm:"not null;default:true"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastError    string     `json:"last_error"`
+	NextRunAt    *time.Time `json:"next_run_at" gorm:"index"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ArchivedReport is a generated report file kept in the report archive
+type ArchivedReport struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ScheduleID   uint      `json:"schedule_id" gorm:"index"` // 0 for reports generated without a schedule
+	ScheduleName string    `json:"schedule_name"`
+	Filename     string    `json:"filename" gorm:"not null"` // File name inside the archive directory
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	From         time.Time `json:"from"` // First day of the reported range
+	To           time.Time `json:"to"`   // Last day of the reported range
+	SlackChannel string    `json:"slack_channel"`
+	SlackFileID  string    `json:"slack_file_id"`
+	SlackError   string    `json:"slack_error"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index"`
+}
+
+// Audit log actions
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	AuditActionLock   = "lock"
+	AuditActionUnlock = "unlock"
+)
+
+// Audit log entities
+const (
+	AuditEntityTimeEntry       = "time_entry"
+	AuditEntityUser            = "user"
+	AuditEntityTimesheet       = "timesheet"
+	AuditEntityPeriodLock      = "period_lock"
+	AuditEntityContract        = "contract"
+	AuditEntityAbsence         = "absence"
+	AuditEntityHolidayCalendar = "holiday_calendar"
+	AuditEntityBalance         = "balance_adjustment"
+	AuditEntityComplianceRules = "compliance_rule_set"
+	AuditEntityReportSchedule  = "report_schedule"
+	AuditEntityArchivedReport  = "archived_report"
+	AuditEntityCalendarFeed    = "calendar_feed"
+	AuditEntityImportBatch     = "import_batch"
+	AuditEntityTeam            = "team"
+	AuditEntityTrackingRule    = "tracking_rule"
+	AuditEntityProject         = "project"
+)
+
+// AuditLog records a single mutation performed by an admin
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ActorID   uint      `json:"actor_id" gorm:"index"` // Admin ID from the session, 0 for system changes
+	ActorName string    `json:"actor_name"`
+	Action    string    `json:"action" gorm:"not null;index"`
+	Entity    string    `json:"entity" gorm:"not null;index:idx_audit_entity"`
+	EntityID  string    `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Before    string    `json:"before"` // JSON snapshot before the change, empty for creations
+	After     string    `json:"after"`  // JSON snapshot after the change, empty for deletions
+	Timestamp time.Time `json:"timestamp" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
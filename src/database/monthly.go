@@ -0,0 +1,204 @@
+package database
+
+import (
+	"math"
+	"time"
+)
+
+// MonthlyTimesheetDay is one calendar day of a monthly timesheet
+type MonthlyTimesheetDay struct {
+	Date          time.Time   `json:"date"`
+	Entries       []TimeEntry `json:"entries"`
+	GrossHours    float64     `json:"gross_hours"`
+	BreakHours    float64     `json:"break_deduction_hours"`
+	NetHours      float64     `json:"net_hours"`
+	RoundedHours  float64     `json:"rounded_hours"`
+	RequiredHours float64     `json:"required_hours"`
+	Absence       string      `json:"absence,omitempty"`       // Type of an approved absence on the day
+	AbsenceShare  float64     `json:"absence_share,omitempty"` // 0.5 for half days
+	Holiday       string      `json:"holiday,omitempty"`       // Name of a public holiday on the day
+}
+
+// TimesheetApproval is the sign-off state of one week of a monthly timesheet
+type TimesheetApproval struct {
+	WeekStart  time.Time  `json:"week_start"`
+	Status     string     `json:"status"` // Timesheet state, empty if no timesheet was generated
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	ReviewedBy string     `json:"reviewed_by"` // Admin username
+}
+
+// MonthlyTimesheet is a user's tracked and required time for one calendar month,
+// the basis of printable timesheets
+type MonthlyTimesheet struct {
+	User          User                  `json:"user"`
+	Month         time.Time             `json:"month"` // First day of the month
+	Days          []MonthlyTimesheetDay `json:"days"`  // Every day of the month
+	Absences      []Absence             `json:"absences"`
+	Approvals     []TimesheetApproval   `json:"approvals"` // Weeks starting in or overlapping the month
+	Approved      bool                  `json:"approved"`  // Every week is approved
+	Rounding      RoundingPolicy        `json:"rounding"`
+	GrossHours    float64               `json:"gross_hours"`
+	BreakHours    float64               `json:"break_deduction_hours"`
+	NetHours      float64               `json:"net_hours"`
+	RoundedHours  float64               `json:"rounded_hours"`
+	RequiredHours float64               `json:"required_hours"`
+	BalanceHours  float64               `json:"balance_hours"` // Net minus required hours
+	GeneratedAt   time.Time             `json:"generated_at"`
+}
+
+// GetMonthlyTimesheet returns a user's timesheet for the month containing month
+func GetMonthlyTimesheet(userID uint, month, now time.Time) (*MonthlyTimesheet, error) {
+	var user User
+	if err := DB.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return buildMonthlyTimesheet(user, month, now)
+}
+
+// GetMonthlyTimesheets returns the timesheets of the active users selected by the users
+// and teams of a query for the month containing month
+func GetMonthlyTimesheets(query ReportQuery, month, now time.Time) ([]MonthlyTimesheet, error) {
+	users, err := loadReportUsers(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var sheets []MonthlyTimesheet
+	for _, user := range users {
+		if !user.IsActive {
+			continue
+		}
+		sheet, err := buildMonthlyTimesheet(user, month, now)
+		if err != nil {
+			return nil, err
+		}
+		sheets = append(sheets, *sheet)
+	}
+	return sheets, nil
+}
+
+// buildMonthlyTimesheet collects the entries, hours, absences, holidays and weekly
+// sign-offs of a user in a month
+func buildMonthlyTimesheet(user User, month, now time.Time) (*MonthlyTimesheet, error) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+	userIDs := []uint{user.ID}
+
+	byUser, err := loadEntriesByUser(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := loadWorkSchedule(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	deductions, err := loadBreakDeductions(userIDs, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	rounding := ConfiguredRounding()
+	rounded, err := loadRoundedHours(rounding, userIDs, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &MonthlyTimesheet{
+		User:        user,
+		Month:       from,
+		Absences:    schedule.absences[user.ID],
+		Rounding:    rounding,
+		GeneratedAt: now,
+	}
+	if sheet.Absences == nil {
+		sheet.Absences = []Absence{}
+	}
+
+	gross := make(map[time.Time]time.Duration)
+	for _, day := range buildWorkDays(byUser[user.ID], now) {
+		gross[day.day] = day.worked
+	}
+	entries := make(map[time.Time][]TimeEntry)
+	for _, entry := range byUser[user.ID] {
+		day := dayStart(entry.StartTime)
+		entries[day] = append(entries[day], entry)
+	}
+
+	round := func(hours float64) float64 { return math.Round(hours*100) / 100 }
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		current := MonthlyTimesheetDay{
+			Date:          day,
+			Entries:       entries[day],
+			GrossHours:    round(gross[day].Hours()),
+			BreakHours:    round(deductions[user.ID][day].Hours()),
+			RoundedHours:  round(rounded[user.ID][day].Hours()),
+			RequiredHours: round(schedule.requiredHoursForDay(user.ID, day)),
+			Holiday:       schedule.holidays[user.ID][holidayDateKey(day)],
+		}
+		if current.Entries == nil {
+			current.Entries = []TimeEntry{}
+		}
+		current.NetHours = round(current.GrossHours - current.BreakHours)
+		for _, absence := range schedule.absences[user.ID] {
+			if share := absenceFraction(absence, day); share > current.AbsenceShare {
+				current.Absence, current.AbsenceShare = absence.Type, share
+			}
+		}
+
+		sheet.Days = append(sheet.Days, current)
+		sheet.GrossHours += current.GrossHours
+		sheet.BreakHours += current.BreakHours
+		sheet.NetHours += current.NetHours
+		sheet.RoundedHours += current.RoundedHours
+		sheet.RequiredHours += current.RequiredHours
+	}
+	sheet.GrossHours = round(sheet.GrossHours)
+	sheet.BreakHours = round(sheet.BreakHours)
+	sheet.NetHours = round(sheet.NetHours)
+	sheet.RoundedHours = round(sheet.RoundedHours)
+	sheet.RequiredHours = round(sheet.RequiredHours)
+	sheet.BalanceHours = round(sheet.NetHours - sheet.RequiredHours)
+
+	if err := fillTimesheetApprovals(sheet, from, to); err != nil {
+		return nil, err
+	}
+	return sheet, nil
+}
+
+// fillTimesheetApprovals adds the weekly sign-off state of every week overlapping [from, to)
+func fillTimesheetApprovals(sheet *MonthlyTimesheet, from, to time.Time) error {
+	var timesheets []Timesheet
+	err := DB.Where("user_id = ? AND week_start >= ? AND week_start < ?", sheet.User.ID, WeekStartOf(from), to).
+		Find(&timesheets).Error
+	if err != nil {
+		return err
+	}
+	byWeek := make(map[time.Time]Timesheet)
+	for _, timesheet := range timesheets {
+		byWeek[dayStart(timesheet.WeekStart)] = timesheet
+	}
+
+	reviewers := make(map[uint]string)
+	sheet.Approved = true
+	for week := WeekStartOf(from); week.Before(to); week = week.AddDate(0, 0, 7) {
+		approval := TimesheetApproval{WeekStart: week}
+		if timesheet, ok := byWeek[week]; ok {
+			approval.Status = timesheet.Status
+			approval.ReviewedAt = timesheet.ReviewedAt
+			if timesheet.ReviewedBy != 0 {
+				if _, ok := reviewers[timesheet.ReviewedBy]; !ok {
+					var admin Admin
+					if err := DB.Select("username").Where("id = ?", timesheet.ReviewedBy).Limit(1).Find(&admin).Error; err != nil {
+						return err
+					}
+					reviewers[timesheet.ReviewedBy] = admin.Username
+				}
+				approval.ReviewedBy = reviewers[timesheet.ReviewedBy]
+			}
+		}
+		if approval.Status != TimesheetStatusApproved {
+			sheet.Approved = false
+		}
+		sheet.Approvals = append(sheet.Approvals, approval)
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/utils"
+)
+
+// ErrInvalidProject is returned when a project fails validation or does not exist
+var ErrInvalidProject = errors.New("invalid project")
+
+// normalizeProjectTag returns a tag in the form it is stored and matched in
+func normalizeProjectTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// validateProject normalizes and checks a project. Tags are unique and contain no
+// whitespace or commas, since tags are stored and filtered as comma-separated lists.
+func validateProject(tx *gorm.DB, project *Project) error {
+	project.Tag = normalizeProjectTag(project.Tag)
+	project.Name = strings.TrimSpace(project.Name)
+	project.Client = strings.TrimSpace(project.Client)
+	if project.Tag == "" {
+		return fmt.Errorf("%w: tag is required", ErrInvalidProject)
+	}
+	if strings.ContainsAny(project.Tag, ", \t\n") {
+		return fmt.Errorf("%w: tag must not contain commas or whitespace", ErrInvalidProject)
+	}
+	if project.Name == "" {
+		project.Name = project.Tag
+	}
+	if project.HourlyRate < 0 {
+		return fmt.Errorf("%w: hourly rate must not be negative", ErrInvalidProject)
+	}
+
+	var existing int64
+	if err := tx.Model(&Project{}).Where("tag = ? AND id <> ?", project.Tag, project.ID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return fmt.Errorf("%w: a project tagged %q already exists", ErrInvalidProject, project.Tag)
+	}
+	return nil
+}
+
+// GetProjects returns the project catalogue ordered by client and name
+func GetProjects() ([]Project, error) {
+	var projects []Project
+	err := DB.Order("LOWER(client) ASC, LOWER(name) ASC").Find(&projects).Error
+	return projects, err
+}
+
+// GetProject returns a single project by ID
+func GetProject(projectID uint) (*Project, error) {
+	var project Project
+	if err := DB.First(&project, projectID).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// CreateProject validates and stores a new project
+func CreateProject(project *Project) error {
+	if err := validateProject(DB, project); err != nil {
+		return err
+	}
+	return DB.Create(project).Error
+}
+
+// UpdateProject validates and saves changes to a project. Entries stay booked on the
+// project if its tag changes.
+func UpdateProject(project *Project) error {
+	if err := validateProject(DB, project); err != nil {
+		return err
+	}
+	return DB.Save(project).Error
+}
+
+// DeleteProject removes a project. Its entries keep their tags but are no longer
+// booked on a project. Projects with entries in a locked period or an approved week
+// cannot be deleted.
+func DeleteProject(projectID uint) error {
+	var entries []TimeEntry
+	if err := DB.Where("project_id = ?", projectID).Find(&entries).Error; err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := checkEntryRangeLocked(&entry); err != nil {
+			return err
+		}
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&TimeEntry{}).Where("project_id = ?", projectID).Update("project_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Project{}, projectID).Error
+	})
+}
+
+// loadProjectsByID returns the project catalogue keyed by ID
+func loadProjectsByID() (map[uint]Project, error) {
+	projects, err := GetProjects()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Project, len(projects))
+	for _, project := range projects {
+		byID[project.ID] = project
+	}
+	return byID, nil
+}
+
+// checkProjectExists checks that the project an entry is booked on exists
+func checkProjectExists(projectID *uint) error {
+	if projectID == nil {
+		return nil
+	}
+	var existing int64
+	if err := DB.Model(&Project{}).Where("id = ?", *projectID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing == 0 {
+		return fmt.Errorf("%w: project %d does not exist", ErrInvalidProject, *projectID)
+	}
+	return nil
+}
+
+// ParseStatusTags returns the lowercase tags in a status text that match
+// PROJECT_TAG_PATTERN, in order and without duplicates. The first group of the pattern
+// is the tag, or the whole match if it has no group.
+func ParseStatusTags(statusText string) []string {
+	if config.AppConfig == nil || config.AppConfig.ProjectTagPattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(config.AppConfig.ProjectTagPattern)
+	if err != nil {
+		utils.LogError("Invalid PROJECT_TAG_PATTERN, no tags are parsed: %v", err)
+		return nil
+	}
+
+	var tags []string
+	seen := make(map[string]bool)
+	for _, match := range pattern.FindAllStringSubmatch(statusText, -1) {
+		tag := match[0]
+		if len(match) > 1 {
+			tag = match[1]
+		}
+		tag = normalizeProjectTag(tag)
+		if tag == "" || strings.ContainsAny(tag, ", \t\n") || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// findOrCreateProject returns the project of a tag. Unknown tags are added to the
+// catalogue if PROJECT_AUTO_CREATE is set, otherwise nil is returned.
+func findOrCreateProject(tx *gorm.DB, tag, name string) (*Project, error) {
+	var project Project
+	result := tx.Where("tag = ?", tag).Limit(1).Find(&project)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return &project, result.Error
+	}
+	if config.AppConfig == nil || !config.AppConfig.ProjectAutoCreate {
+		return nil, nil
+	}
+
+	project = Project{Tag: tag, Name: name}
+	if err := validateProject(tx, &project); err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&project).Error; err != nil {
+		// Another status change may have added the tag in the meantime
+		if retry := tx.Where("tag = ?", tag).First(&project).Error; retry != nil {
+			return nil, err
+		}
+	}
+	return &project, nil
+}
+
+// AssignEntryProject sets the tags of an entry from its status text and books it on
+// the project of the first tag in the catalogue. Without a known tag the first tag is
+// added to the catalogue if PROJECT_AUTO_CREATE is set.
+func AssignEntryProject(entry *TimeEntry) error {
+	tags := ParseStatusTags(entry.StatusText)
+	entry.Tags = strings.Join(tags, ",")
+	entry.ProjectID = nil
+	if len(tags) == 0 {
+		return nil
+	}
+
+	var known []Project
+	if err := DB.Where("tag IN ?", tags).Find(&known).Error; err != nil {
+		return err
+	}
+	byTag := make(map[string]uint, len(known))
+	for _, project := range known {
+		byTag[project.Tag] = project.ID
+	}
+	for _, tag := range tags {
+		if projectID, ok := byTag[tag]; ok {
+			entry.ProjectID = &projectID
+			return nil
+		}
+	}
+
+	project, err := findOrCreateProject(DB, tags[0], tags[0])
+	if err != nil || project == nil {
+		return err
+	}
+	entry.ProjectID = &project.ID
+	return nil
+}
+
+// importProjectID returns the project of an imported entry's project column, matched
+// by name or tag. Unknown projects are added to the catalogue if PROJECT_AUTO_CREATE is
+// set, tagged with the name in lowercase and with dashes for spaces.
+func importProjectID(tx *gorm.DB, name string, cache map[string]*uint) (*uint, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	if projectID, ok := cache[strings.ToLower(name)]; ok {
+		return projectID, nil
+	}
+
+	tag := normalizeProjectTag(strings.Join(strings.Fields(strings.ReplaceAll(name, ",", " ")), "-"))
+	var project Project
+	result := tx.Where("LOWER(name) = LOWER(?) OR tag = ?", name, tag).Order("id ASC").Limit(1).Find(&project)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var projectID *uint
+	if result.RowsAffected > 0 {
+		projectID = &project.ID
+	} else {
+		created, err := findOrCreateProject(tx, tag, name)
+		if err != nil {
+			return nil, err
+		}
+		if created != nil {
+			projectID = &created.ID
+		}
+	}
+	cache[strings.ToLower(name)] = projectID
+	return projectID, nil
+}
+
+// ProjectReportRow is the tracked time and billing of one project. Billable hours are
+// the rounded hours of billable projects, billed at the project's hourly rate.
+type ProjectReportRow struct {
+	ProjectID     *uint   `json:"project_id"`
+	Tag           string  `json:"tag"`
+	Name          string  `json:"name"`
+	Client        string  `json:"client"`
+	Billable      bool    `json:"billable"`
+	HourlyRate    float64 `json:"hourly_rate"`
+	Users         int     `json:"users"`
+	Entries       int     `json:"entries"`
+	GrossHours    float64 `json:"gross_hours"`
+	NetHours      float64 `json:"net_hours"`
+	RoundedHours  float64 `json:"rounded_hours"`
+	BillableHours float64 `json:"billable_hours"`
+	Amount        float64 `json:"amount"`
+}
+
+// ProjectReport is the per-project result of a report query
+type ProjectReport struct {
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"` // Last day included
+	Rounding RoundingPolicy     `json:"rounding"`
+	Rows     []ProjectReportRow `json:"rows"`
+	Totals   ProjectReportRow   `json:"totals"`
+}
+
+// RunProjectReport aggregates tracked time by project and prices the billable hours.
+// Entries without a project are reported in a row without project ID.
+func RunProjectReport(query ReportQuery) (*ProjectReport, error) {
+	query.GroupBy = ReportGroupProject
+	report, err := RunReport(query)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := GetProjects()
+	if err != nil {
+		return nil, err
+	}
+	byTag := make(map[string]Project, len(projects))
+	for _, project := range projects {
+		byTag[project.Tag] = project
+	}
+
+	round := func(value float64) float64 { return math.Round(value*100) / 100 }
+	result := &ProjectReport{From: report.From, To: report.To, Rounding: report.Rounding, Rows: []ProjectReportRow{}}
+	for _, row := range report.Rows {
+		projectRow := ProjectReportRow{
+			Name:         row.Label,
+			Users:        row.Users,
+			Entries:      row.Entries,
+			GrossHours:   row.GrossHours,
+			NetHours:     row.NetHours,
+			RoundedHours: row.RoundedHours,
+		}
+		if project, ok := byTag[row.Key]; ok {
+			projectRow.ProjectID = &project.ID
+			projectRow.Tag = project.Tag
+			projectRow.Client = project.Client
+			projectRow.Billable = project.Billable
+			projectRow.HourlyRate = project.HourlyRate
+			if project.Billable {
+				projectRow.BillableHours = row.RoundedHours
+				projectRow.Amount = round(row.RoundedHours * project.HourlyRate)
+			}
+		}
+		result.Rows = append(result.Rows, projectRow)
+
+		result.Totals.BillableHours += projectRow.BillableHours
+		result.Totals.Amount += projectRow.Amount
+	}
+
+	totals := report.Totals
+	result.Totals.Name = totals.Label
+	result.Totals.Users = totals.Users
+	result.Totals.Entries = totals.Entries
+	result.Totals.GrossHours = totals.GrossHours
+	result.Totals.NetHours = totals.NetHours
+	result.Totals.RoundedHours = totals.RoundedHours
+	result.Totals.BillableHours = round(result.Totals.BillableHours)
+	result.Totals.Amount = round(result.Totals.Amount)
+	return result, nil
+}
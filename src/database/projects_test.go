@@ -0,0 +1,56 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeleteProjectWithLockedEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		locked  bool
+		wantErr error
+	}{
+		{name: "entries in an open period", locked: false},
+		{name: "entries in a locked period", locked: true, wantErr: ErrPeriodLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			user := createTestUser(t, "U1")
+			project := Project{Tag: "matchday-app"}
+			if err := CreateProject(&project); err != nil {
+				t.Fatalf("CreateProject: %v", err)
+			}
+
+			start := time.Now().AddDate(0, 0, -3)
+			end := start.Add(2 * time.Hour)
+			entry := TimeEntry{UserID: user.ID, StartTime: start, EndTime: &end, Status: "Working",
+				Source: TimeEntrySourceManual, ProjectID: &project.ID}
+			if err := DB.Create(&entry).Error; err != nil {
+				t.Fatalf("creating entry: %v", err)
+			}
+			if tt.locked {
+				if _, err := CreatePeriodLock(start.AddDate(0, 0, -1), end.AddDate(0, 0, 1), "closed", 1, "admin"); err != nil {
+					t.Fatalf("CreatePeriodLock: %v", err)
+				}
+			}
+
+			err := DeleteProject(project.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteProject error = %v, want %v", err, tt.wantErr)
+			}
+
+			var stored TimeEntry
+			DB.First(&stored, entry.ID)
+			if tt.locked && (stored.ProjectID == nil || *stored.ProjectID != project.ID) {
+				t.Errorf("locked entry project_id = %v, want %d", stored.ProjectID, project.ID)
+			}
+			if !tt.locked && stored.ProjectID != nil {
+				t.Errorf("entry project_id = %v, want none", *stored.ProjectID)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tables of raw exports
+const (
+	RawExportTimeEntries  = "time_entries"
+	RawExportUserStatuses = "user_statuses"
+)
+
+// RawExportFilter selects the rows of a raw table export. Rows are exported in ID
+// order so that an interrupted export can resume after the last ID it received.
+type RawExportFilter struct {
+	From    time.Time // First included time, zero for no bound
+	To      time.Time // Exclusive end, zero for no bound
+	UserIDs []uint    // Empty for all users
+	AfterID uint      // Resume cursor: only rows with a larger ID
+	Limit   int       // Maximum number of rows, 0 for all
+}
+
+// rawExportQuery returns the query of a raw export of model filtered on timeColumn
+func rawExportQuery(model interface{}, timeColumn string, filter RawExportFilter) *gorm.DB {
+	query := DB.Model(model).Where("id > ?", filter.AfterID)
+	if !filter.From.IsZero() {
+		query = query.Where(timeColumn+" >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where(timeColumn+" < ?", filter.To)
+	}
+	if len(filter.UserIDs) > 0 {
+		query = query.Where("user_id IN ?", filter.UserIDs)
+	}
+	return query
+}
+
+// rawExportPageSize returns the size of the next page, or 0 when the limit is reached
+func rawExportPageSize(filter RawExportFilter, pageSize, exported int) int {
+	if filter.Limit > 0 && filter.Limit-exported < pageSize {
+		return filter.Limit - exported
+	}
+	return pageSize
+}
+
+// StreamTimeEntries pages through the time entries of a filter in ID order and calls
+// fn with every page, so exports never hold more than one page in memory
+func StreamTimeEntries(filter RawExportFilter, pageSize int, fn func([]TimeEntry) error) error {
+	afterID, exported := filter.AfterID, 0
+	for {
+		size := rawExportPageSize(filter, pageSize, exported)
+		if size <= 0 {
+			return nil
+		}
+
+		page := filter
+		page.AfterID = afterID
+		var entries []TimeEntry
+		if err := rawExportQuery(&TimeEntry{}, "start_time", page).Order("id ASC").Limit(size).Find(&entries).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+
+		afterID = entries[len(entries)-1].ID
+		exported += len(entries)
+		if len(entries) < size {
+			return nil
+		}
+	}
+}
+
+// StreamUserStatuses pages through the Slack status changes of a filter in ID order
+// and calls fn with every page
+func StreamUserStatuses(filter RawExportFilter, pageSize int, fn func([]UserStatus) error) error {
+	afterID, exported := filter.AfterID, 0
+	for {
+		size := rawExportPageSize(filter, pageSize, exported)
+		if size <= 0 {
+			return nil
+		}
+
+		page := filter
+		page.AfterID = afterID
+		var statuses []UserStatus
+		if err := rawExportQuery(&UserStatus{}, "timestamp", page).Order("id ASC").Limit(size).Find(&statuses).Error; err != nil {
+			return err
+		}
+		if len(statuses) == 0 {
+			return nil
+		}
+		if err := fn(statuses); err != nil {
+			return err
+		}
+
+		afterID = statuses[len(statuses)-1].ID
+		exported += len(statuses)
+		if len(statuses) < size {
+			return nil
+		}
+	}
+}
+
+// RawExportNextCursor returns the cursor continuing a limited export of a table, or 0 if
+// the export includes the last row
+func RawExportNextCursor(table string, filter RawExportFilter) (uint, error) {
+	if filter.Limit <= 0 {
+		return 0, nil
+	}
+
+	var query *gorm.DB
+	switch table {
+	case RawExportUserStatuses:
+		query = rawExportQuery(&UserStatus{}, "timestamp", filter)
+	default:
+		query = rawExportQuery(&TimeEntry{}, "start_time", filter)
+	}
+
+	// The ID of the last exported row, if another row follows it
+	var ids []uint
+	if err := query.Order("id ASC").Offset(filter.Limit-1).Limit(2).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) < 2 {
+		return 0, nil
+	}
+	return ids[0], nil
+}
@@ -0,0 +1,338 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report groupings
+const (
+	ReportGroupDay      = "day"
+	ReportGroupWeek     = "week"
+	ReportGroupMonth    = "month"
+	ReportGroupUser     = "user"
+	ReportGroupTeam     = "team"
+	ReportGroupCategory = "category"
+	ReportGroupProject  = "project"
+)
+
+// maxReportDays limits the length of a report range
+const maxReportDays = 366 * 3
+
+// ErrInvalidReportQuery is returned when a report query fails validation
+var ErrInvalidReportQuery = errors.New("invalid report query")
+
+// ReportQuery selects and groups the tracked time of a report. Days are local
+// calendar days, so the server time zone (TZ) decides where a day starts.
+type ReportQuery struct {
+	From       time.Time // First day
+	To         time.Time // Day after the last day
+	GroupBy    string
+	UserIDs    []uint
+	Teams      []string
+	Categories []string
+	Projects   []string // Project tags
+}
+
+// ReportRow is the aggregated time of one group of a report
+type ReportRow struct {
+	Key           string     `json:"key"`
+	Label         string     `json:"label"`
+	Start         *time.Time `json:"start,omitempty"` // Start of the period for day, week and month groups
+	Users         int        `json:"users"`           // Users with tracked time in the group
+	Entries       int        `json:"entries"`
+	GrossHours    float64    `json:"gross_hours"`
+	BreakHours    float64    `json:"break_deduction_hours"`
+	NetHours      float64    `json:"net_hours"`
+	RoundedHours  float64    `json:"rounded_hours"`
+	RequiredHours float64    `json:"required_hours"`
+
+	users map[uint]bool
+}
+
+// Report is the result of a report query
+type Report struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"` // Last day included
+	GroupBy  string         `json:"group_by"`
+	Rounding RoundingPolicy `json:"rounding"`
+	Rows     []ReportRow    `json:"rows"`
+	Totals   ReportRow      `json:"totals"`
+}
+
+// add accumulates a share of tracked time into the row
+func (r *ReportRow) add(userID uint, entries int, gross, breaks, rounded time.Duration) {
+	if r.users == nil {
+		r.users = make(map[uint]bool)
+	}
+	r.users[userID] = true
+	r.Entries += entries
+	r.GrossHours += gross.Hours()
+	r.BreakHours += breaks.Hours()
+	r.RoundedHours += rounded.Hours()
+}
+
+// finish rounds the row to two decimals and derives the net hours
+func (r *ReportRow) finish() {
+	round := func(hours float64) float64 { return math.Round(hours*100) / 100 }
+	r.Users = len(r.users)
+	r.GrossHours = round(r.GrossHours)
+	r.BreakHours = round(r.BreakHours)
+	r.RoundedHours = round(r.RoundedHours)
+	r.RequiredHours = round(r.RequiredHours)
+	r.NetHours = round(r.GrossHours - r.BreakHours)
+}
+
+// validateReportQuery normalizes and checks a report query
+func validateReportQuery(query *ReportQuery) error {
+	query.From, query.To = dayStart(query.From), dayStart(query.To)
+	if !query.To.After(query.From) {
+		return ErrInvalidTimeRange
+	}
+	if query.To.Sub(query.From).Hours()/24 > maxReportDays {
+		return fmt.Errorf("%w: the range must not exceed %d days", ErrInvalidReportQuery, maxReportDays)
+	}
+
+	switch query.GroupBy {
+	case "":
+		query.GroupBy = ReportGroupUser
+	case ReportGroupDay, ReportGroupWeek, ReportGroupMonth, ReportGroupUser, ReportGroupTeam, ReportGroupCategory, ReportGroupProject:
+	default:
+		return fmt.Errorf("%w: unknown group_by %q", ErrInvalidReportQuery, query.GroupBy)
+	}
+	return nil
+}
+
+// reportGroup returns the key, label and period start of the group a user's day or entry
+// belongs to. Teams are grouped by RunReport, as a user can be in several of them.
+func reportGroup(groupBy string, user User, day time.Time, category string, project *Project) (string, string, *time.Time) {
+	switch groupBy {
+	case ReportGroupDay:
+		return day.Format("2006-01-02"), day.Format("Mon 2006-01-02"), &day
+	case ReportGroupWeek:
+		weekStart := WeekStartOf(day)
+		year, week := weekStart.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), "Week of " + weekStart.Format("2006-01-02"), &weekStart
+	case ReportGroupMonth:
+		monthStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.Local)
+		return monthStart.Format("2006-01"), monthStart.Format("January 2006"), &monthStart
+	case ReportGroupCategory:
+		if category == "" {
+			return "", "Uncategorized", nil
+		}
+		return category, category, nil
+	case ReportGroupProject:
+		if project == nil {
+			return "", "No project", nil
+		}
+		return project.Tag, project.Name, nil
+	default:
+		name := user.Name
+		if user.RealName != "" {
+			name = user.RealName
+		}
+		return strconv.FormatUint(uint64(user.ID), 10), name, nil
+	}
+}
+
+// SplitList splits a comma-separated list such as a filter parameter, dropping empty values
+func SplitList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(strings.TrimSpace(candidate), value) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadReportUsers returns the users selected by the users and teams filters of a query.
+// The teams filter selects all members of a team, like group_by=team.
+func loadReportUsers(query ReportQuery) ([]User, error) {
+	userQuery := DB.Order("name ASC")
+	if len(query.UserIDs) > 0 {
+		userQuery = userQuery.Where("id IN ?", query.UserIDs)
+	}
+	if len(query.Teams) > 0 {
+		teamUserIDs, err := TeamUserIDs(query.Teams)
+		if err != nil {
+			return nil, err
+		}
+		if len(teamUserIDs) == 0 {
+			return nil, nil
+		}
+		userQuery = userQuery.Where("id IN ?", teamUserIDs)
+	}
+	var users []User
+	if err := userQuery.Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// RunReport aggregates tracked time by the query's grouping. Break deductions and
+// day-scoped rounding are computed from a user's whole day and shared out over the
+// day's entries by duration, so category groups and filters add up to the totals.
+// Required hours are only reported when the grouping and filters are not by category
+// or project.
+func RunReport(query ReportQuery) (*Report, error) {
+	if err := validateReportQuery(&query); err != nil {
+		return nil, err
+	}
+
+	users, err := loadReportUsers(query)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{From: query.From, To: query.To.AddDate(0, 0, -1), GroupBy: query.GroupBy, Rounding: ConfiguredRounding(), Rows: []ReportRow{}}
+	if len(users) == 0 {
+		return report, nil
+	}
+
+	userIDs := make([]uint, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	byUser, err := loadEntriesByUser(userIDs, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := loadWorkSchedule(userIDs, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := loadProjectsByID()
+	if err != nil {
+		return nil, err
+	}
+
+	var userTeams map[uint][]string
+	if query.GroupBy == ReportGroupTeam {
+		if userTeams, err = loadUserTeams(users, query.Teams); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make(map[string]*ReportRow)
+	row := func(key, label string, start *time.Time) *ReportRow {
+		current, ok := rows[key]
+		if !ok {
+			current = &ReportRow{Key: key, Label: label, Start: start}
+			rows[key] = current
+		}
+		return current
+	}
+	// groupRows returns the rows a user's day or entry counts in: one, or with team
+	// groups one per team of the user (within the teams filter)
+	groupRows := func(user User, day time.Time, category string, project *Project) []*ReportRow {
+		if query.GroupBy != ReportGroupTeam {
+			return []*ReportRow{row(reportGroup(query.GroupBy, user, day, category, project))}
+		}
+		teams := userTeams[user.ID]
+		if len(teams) == 0 {
+			return []*ReportRow{row("", "No team", nil)}
+		}
+		teamRows := make([]*ReportRow, len(teams))
+		for i, team := range teams {
+			teamRows[i] = row(team, team, nil)
+		}
+		return teamRows
+	}
+
+	// Totals are summed per user rather than per row, so that users in several teams
+	// count once
+	totals := ReportRow{Key: "total", Label: "Total", users: make(map[uint]bool)}
+
+	tiers := configuredBreakPolicy()
+	now := time.Now()
+	for _, user := range users {
+		entries := byUser[user.ID]
+		workDays := make(map[time.Time]workDay)
+		for _, day := range buildWorkDays(entries, now) {
+			workDays[day.day] = day
+		}
+
+		for _, entry := range entries {
+			if len(query.Categories) > 0 && !containsFold(query.Categories, entry.Status) {
+				continue
+			}
+			var project *Project
+			if entry.ProjectID != nil {
+				if known, ok := projects[*entry.ProjectID]; ok {
+					project = &known
+				}
+			}
+			if len(query.Projects) > 0 && (project == nil || !containsFold(query.Projects, project.Tag)) {
+				continue
+			}
+			end := now
+			if entry.EndTime != nil {
+				end = *entry.EndTime
+			}
+			if !end.After(entry.StartTime) {
+				continue
+			}
+
+			day := dayStart(entry.StartTime)
+			workDay := workDays[day]
+			gross := end.Sub(entry.StartTime)
+			share := float64(gross) / float64(workDay.worked)
+
+			breaks := time.Duration(float64(workDay.breakDeduction(tiers)) * share)
+			rounded := report.Rounding.Round(gross)
+			if report.Rounding.Scope == RoundingPerDay {
+				rounded = time.Duration(float64(report.Rounding.Round(workDay.worked)) * share)
+			}
+
+			for _, current := range groupRows(user, day, entry.Status, project) {
+				current.add(user.ID, 1, gross, breaks, rounded)
+			}
+			totals.add(user.ID, 1, gross, breaks, rounded)
+		}
+
+		if !user.IsActive || len(query.Categories) > 0 || len(query.Projects) > 0 ||
+			query.GroupBy == ReportGroupCategory || query.GroupBy == ReportGroupProject {
+			continue
+		}
+		for day := query.From; day.Before(query.To); day = day.AddDate(0, 0, 1) {
+			if required := schedule.requiredHoursForDay(user.ID, day); required > 0 {
+				for _, current := range groupRows(user, day, "", nil) {
+					current.RequiredHours += required
+				}
+				totals.RequiredHours += required
+			}
+		}
+	}
+
+	for _, current := range rows {
+		current.finish()
+		report.Rows = append(report.Rows, *current)
+	}
+	totals.finish()
+	report.Totals = totals
+
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Start != nil && b.Start != nil {
+			return a.Start.Before(*b.Start)
+		}
+		return strings.ToLower(a.Label) < strings.ToLower(b.Label)
+	})
+	return report, nil
+}
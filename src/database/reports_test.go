@@ -0,0 +1,96 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTeamGroupingUsesMemberships(t *testing.T) {
+	openTestDB(t)
+	alice := createTestUser(t, "alice") // Member of Coaches and Physios
+	bob := createTestUser(t, "bob")     // Primary team Coaches only
+	carol := createTestUser(t, "carol") // No team
+
+	bob.Team = "Coaches"
+	if err := DB.Save(bob).Error; err != nil {
+		t.Fatalf("saving user: %v", err)
+	}
+	if err := SyncPrimaryTeam(bob, ""); err != nil {
+		t.Fatalf("syncing primary team: %v", err)
+	}
+	physios := Team{Name: "Physios"}
+	if err := CreateTeam(&physios); err != nil {
+		t.Fatalf("creating team: %v", err)
+	}
+	teams, err := GetTeams()
+	if err != nil {
+		t.Fatalf("loading teams: %v", err)
+	}
+	for _, team := range teams {
+		if err := AddTeamMembers(&team, []uint{alice.ID}); err != nil {
+			t.Fatalf("adding team member: %v", err)
+		}
+	}
+
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	for i, user := range []*User{alice, bob, carol} {
+		start := day.Add(9 * time.Hour)
+		createClosedEntry(t, user.ID, start, start.Add(time.Duration(i+1)*time.Hour))
+	}
+
+	tests := []struct {
+		name  string
+		teams []string
+		want  map[string]float64 // Gross hours by row label
+		total float64
+	}{
+		{name: "all teams", want: map[string]float64{"Coaches": 3, "Physios": 1, "No team": 3}, total: 6},
+		{name: "teams filter", teams: []string{"coaches"}, want: map[string]float64{"Coaches": 3}, total: 3},
+		{name: "filter of a second team", teams: []string{"Physios"}, want: map[string]float64{"Physios": 1}, total: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := ReportQuery{From: day, To: day.AddDate(0, 0, 1), GroupBy: ReportGroupTeam, Teams: tt.teams}
+			report, err := RunReport(query)
+			if err != nil {
+				t.Fatalf("RunReport: %v", err)
+			}
+			got := make(map[string]float64)
+			for _, row := range report.Rows {
+				got[row.Label] = row.GrossHours
+			}
+			if !sameHours(got, tt.want) {
+				t.Errorf("report rows = %v, want %v", got, tt.want)
+			}
+			if report.Totals.GrossHours != tt.total {
+				t.Errorf("report total = %.2f, want %.2f", report.Totals.GrossHours, tt.total)
+			}
+
+			series, err := GetTimeSeries(query, SeriesIntervalDay, SeriesByTeam)
+			if err != nil {
+				t.Fatalf("GetTimeSeries: %v", err)
+			}
+			got = make(map[string]float64)
+			for _, line := range series.Series {
+				got[line.Label] = line.Total
+			}
+			if !sameHours(got, tt.want) {
+				t.Errorf("series = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// sameHours reports whether two maps of hours by label are equal
+func sameHours(got, want map[string]float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for label, hours := range want {
+		if got[label] != hours {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/utils"
+)
+
+// Rounding modes
+const (
+	RoundingNone    = "none"
+	RoundingNearest = "nearest"
+	RoundingUp      = "up"
+	RoundingDown    = "down"
+)
+
+// Rounding scopes
+const (
+	RoundingPerEntry = "entry"
+	RoundingPerDay   = "day"
+)
+
+// RoundingPolicy describes how tracked time is rounded in reports and exports.
+// Raw time entries and the dashboard always keep exact seconds.
+type RoundingPolicy struct {
+	Mode    string `json:"mode"`    // none, nearest, up or down
+	Minutes int    `json:"minutes"` // Rounding increment
+	Scope   string `json:"scope"`   // entry or day
+}
+
+// ConfiguredRounding returns the rounding policy from the ROUNDING_* settings.
+// Invalid settings are logged and disable rounding.
+func ConfiguredRounding() RoundingPolicy {
+	policy := RoundingPolicy{Mode: RoundingNone, Minutes: 15, Scope: RoundingPerEntry}
+	if config.AppConfig == nil {
+		return policy
+	}
+
+	policy.Mode = strings.ToLower(strings.TrimSpace(config.AppConfig.RoundingMode))
+	policy.Minutes = config.AppConfig.RoundingMinutes
+	policy.Scope = strings.ToLower(strings.TrimSpace(config.AppConfig.RoundingScope))
+
+	switch policy.Mode {
+	case "", RoundingNone:
+		policy.Mode = RoundingNone
+	case RoundingNearest, RoundingUp, RoundingDown:
+		if policy.Minutes <= 0 {
+			utils.LogError("Invalid ROUNDING_MINUTES %d, rounding is disabled", policy.Minutes)
+			policy.Mode = RoundingNone
+		}
+	default:
+		utils.LogError("Invalid ROUNDING_MODE %q, rounding is disabled", policy.Mode)
+		policy.Mode = RoundingNone
+	}
+	if policy.Scope != RoundingPerDay {
+		policy.Scope = RoundingPerEntry
+	}
+	return policy
+}
+
+// Enabled reports whether the policy changes any durations
+func (p RoundingPolicy) Enabled() bool {
+	return p.Mode != RoundingNone
+}
+
+// Round rounds a duration to the policy's increment
+func (p RoundingPolicy) Round(d time.Duration) time.Duration {
+	if !p.Enabled() {
+		return d
+	}
+	unit := time.Duration(p.Minutes) * time.Minute
+	switch p.Mode {
+	case RoundingUp:
+		if rounded := d.Truncate(unit); rounded < d {
+			return rounded + unit
+		}
+		return d
+	case RoundingDown:
+		return d.Truncate(unit)
+	default:
+		return d.Round(unit)
+	}
+}
+
+// loadRoundedHours returns the rounded tracked time per user and day for entries
+// starting in [from, to); active entries count as running until now
+func loadRoundedHours(policy RoundingPolicy, userIDs []uint, from, to, now time.Time) (map[uint]map[time.Time]time.Duration, error) {
+	byUser, err := loadEntriesByUser(userIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	rounded := make(map[uint]map[time.Time]time.Duration)
+	for userID, entries := range byUser {
+		days := make(map[time.Time]time.Duration)
+		for _, entry := range entries {
+			end := now
+			if entry.EndTime != nil {
+				end = *entry.EndTime
+			}
+			if !end.After(entry.StartTime) {
+				continue
+			}
+
+			duration := end.Sub(entry.StartTime)
+			if policy.Scope == RoundingPerEntry {
+				duration = policy.Round(duration)
+			}
+			days[dayStart(entry.StartTime)] += duration
+		}
+		if policy.Scope == RoundingPerDay {
+			for day, duration := range days {
+				days[day] = policy.Round(duration)
+			}
+		}
+		rounded[userID] = days
+	}
+	return rounded, nil
+}
+
+// loadSummaryRoundedHours loads the rounded time covering both summary windows
+func loadSummaryRoundedHours(userIDs []uint, now time.Time) (map[uint]map[time.Time]time.Duration, error) {
+	from, to := summaryHourRange(now)
+	return loadRoundedHours(ConfiguredRounding(), userIDs, from, to, now)
+}
+
+// fillSummaryRounding sets the rounded hours of a summary
+func fillSummaryRounding(summary *UserSummary, rounded map[uint]map[time.Time]time.Duration, now time.Time) {
+	weekFrom, monthFrom := summaryHourWindows(now)
+	_, end := summaryHourRange(now)
+
+	summary.WeeklyRoundedHours = sumDailyHours(rounded[summary.UserID], weekFrom, end)
+	summary.MonthlyRoundedHours = sumDailyHours(rounded[summary.UserID], monthFrom, end)
+}
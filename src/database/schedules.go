@@ -0,0 +1,294 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/utils"
+)
+
+// ErrInvalidReportSchedule is returned when a report schedule fails validation
+var ErrInvalidReportSchedule = errors.New("invalid report schedule")
+
+// reportArchiveDir returns the configured directory of the report archive
+func reportArchiveDir() string {
+	if config.AppConfig != nil && config.AppConfig.ReportArchiveDir != "" {
+		return config.AppConfig.ReportArchiveDir
+	}
+	return "./data/reports"
+}
+
+// ReportRangeBounds returns the first day and the day after the last day of a named
+// range relative to now
+func ReportRangeBounds(name string, now time.Time) (time.Time, time.Time, error) {
+	today := dayStart(now)
+	weekStart := WeekStartOf(today)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local)
+
+	switch name {
+	case ReportRangeToday:
+		return today, today.AddDate(0, 0, 1), nil
+	case ReportRangeYesterday:
+		return today.AddDate(0, 0, -1), today, nil
+	case ReportRangeCurrentWeek:
+		return weekStart, weekStart.AddDate(0, 0, 7), nil
+	case ReportRangePreviousWeek:
+		return weekStart.AddDate(0, 0, -7), weekStart, nil
+	case ReportRangeCurrentMonth:
+		return monthStart, monthStart.AddDate(0, 1, 0), nil
+	case ReportRangePreviousMonth:
+		return monthStart.AddDate(0, -1, 0), monthStart, nil
+	case ReportRangeLast7Days:
+		return today.AddDate(0, 0, -7), today, nil
+	case ReportRangeLast30Days:
+		return today.AddDate(0, 0, -30), today, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: unknown range %q", ErrInvalidReportSchedule, name)
+}
+
+// ReportQuery returns the report query of a schedule for the range [from, to)
+func (s ReportSchedule) ReportQuery(from, to time.Time) (ReportQuery, error) {
+	query := ReportQuery{
+		From:       from,
+		To:         to,
+		GroupBy:    s.GroupBy,
+		Teams:      SplitList(s.Teams),
+		Categories: SplitList(s.Categories),
+	}
+	for _, value := range SplitList(s.Users) {
+		userID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return query, fmt.Errorf("%w: invalid user ID %q", ErrInvalidReportSchedule, value)
+		}
+		query.UserIDs = append(query.UserIDs, uint(userID))
+	}
+	return query, nil
+}
+
+// validateReportSchedule normalizes and checks a schedule and computes its next run
+func validateReportSchedule(schedule *ReportSchedule, now time.Time) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	schedule.Cron = strings.TrimSpace(schedule.Cron)
+	schedule.SlackChannel = strings.TrimSpace(schedule.SlackChannel)
+	if schedule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidReportSchedule)
+	}
+
+	cron, err := utils.ParseCron(schedule.Cron)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReportSchedule, err)
+	}
+
+	// Filters a type does not apply are refused rather than stored and ignored
+	switch schedule.Type {
+	case ReportTypeUsers, ReportTypeWeekly:
+		if schedule.Format != "csv" && schedule.Format != "xlsx" {
+			return fmt.Errorf("%w: format must be csv or xlsx", ErrInvalidReportSchedule)
+		}
+		if schedule.GroupBy != "" || len(SplitList(schedule.Users)) > 0 || len(SplitList(schedule.Teams)) > 0 ||
+			len(SplitList(schedule.Categories)) > 0 {
+			return fmt.Errorf("%w: %s schedules cover all users and take no group_by, users, teams or categories",
+				ErrInvalidReportSchedule, schedule.Type)
+		}
+	case ReportTypeReport:
+		if schedule.Format != "csv" && schedule.Format != "xlsx" && schedule.Format != "json" {
+			return fmt.Errorf("%w: format must be csv, xlsx or json", ErrInvalidReportSchedule)
+		}
+	case ReportTypeTimesheets:
+		if schedule.Format != "pdf" {
+			return fmt.Errorf("%w: format must be pdf", ErrInvalidReportSchedule)
+		}
+		if schedule.GroupBy != "" || len(SplitList(schedule.Categories)) > 0 {
+			return fmt.Errorf("%w: timesheets schedules take no group_by or categories", ErrInvalidReportSchedule)
+		}
+		if _, err := schedule.ReportQuery(now, now); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidReportSchedule, schedule.Type)
+	}
+
+	from, to, err := ReportRangeBounds(schedule.Range, now)
+	if err != nil {
+		return err
+	}
+	if schedule.Type == ReportTypeReport {
+		query, err := schedule.ReportQuery(from, to)
+		if err != nil {
+			return err
+		}
+		if err := validateReportQuery(&query); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReportSchedule, err)
+		}
+	}
+
+	schedule.NextRunAt = nil
+	if next := cron.Next(now); schedule.Enabled && !next.IsZero() {
+		schedule.NextRunAt = &next
+	}
+	return nil
+}
+
+// GetReportSchedules returns all report schedules ordered by name
+func GetReportSchedules() ([]ReportSchedule, error) {
+	var schedules []ReportSchedule
+	err := DB.Order("name ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// GetReportSchedule returns a single report schedule by ID
+func GetReportSchedule(scheduleID uint) (*ReportSchedule, error) {
+	var schedule ReportSchedule
+	if err := DB.First(&schedule, scheduleID).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// CreateReportSchedule validates and stores a new report schedule
+func CreateReportSchedule(schedule *ReportSchedule) error {
+	if err := validateReportSchedule(schedule, time.Now()); err != nil {
+		return err
+	}
+	return DB.Create(schedule).Error
+}
+
+// UpdateReportSchedule validates and saves changes to a report schedule
+func UpdateReportSchedule(schedule *ReportSchedule) error {
+	if err := validateReportSchedule(schedule, time.Now()); err != nil {
+		return err
+	}
+	return DB.Save(schedule).Error
+}
+
+// DeleteReportSchedule removes a report schedule. Its archived reports are kept until
+// they expire.
+func DeleteReportSchedule(scheduleID uint) error {
+	return DB.Delete(&ReportSchedule{}, scheduleID).Error
+}
+
+// GetDueReportSchedules returns the enabled schedules whose next run is not after now
+func GetDueReportSchedules(now time.Time) ([]ReportSchedule, error) {
+	var schedules []ReportSchedule
+	err := DB.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// CompleteReportScheduleRun records a run of a schedule and moves its next run past now
+func CompleteReportScheduleRun(schedule *ReportSchedule, now time.Time, runErr error) error {
+	schedule.LastRunAt = &now
+	schedule.LastError = ""
+	if runErr != nil {
+		schedule.LastError = runErr.Error()
+	}
+
+	schedule.NextRunAt = nil
+	if cron, err := utils.ParseCron(schedule.Cron); err == nil {
+		if next := cron.Next(now); !next.IsZero() {
+			schedule.NextRunAt = &next
+		}
+	}
+	return DB.Model(schedule).Select("last_run_at", "last_error", "next_run_at").Updates(schedule).Error
+}
+
+// ArchiveReport writes a generated report into the archive directory and records it.
+// File names get a random suffix so that reports generated in the same second do not
+// overwrite each other.
+func ArchiveReport(report *ArchivedReport, data []byte) error {
+	dir := reportArchiveDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	report.Filename = fmt.Sprintf("%s-%s_%s", time.Now().Format("20060102-150405"), hex.EncodeToString(suffix),
+		filepath.Base(report.Filename))
+	if err := os.WriteFile(filepath.Join(dir, report.Filename), data, 0644); err != nil {
+		return err
+	}
+	report.Size = int64(len(data))
+
+	if err := DB.Create(report).Error; err != nil {
+		os.Remove(filepath.Join(dir, report.Filename))
+		return err
+	}
+	return nil
+}
+
+// UpdateArchivedReportSlack records the outcome of uploading an archived report to Slack
+func UpdateArchivedReportSlack(report *ArchivedReport) error {
+	return DB.Model(report).Select("slack_channel", "slack_file_id", "slack_error").Updates(report).Error
+}
+
+// GetArchivedReports returns archived reports, newest first, optionally of one schedule
+func GetArchivedReports(scheduleID uint, limit int) ([]ArchivedReport, error) {
+	query := DB.Order("created_at DESC, id DESC")
+	if scheduleID != 0 {
+		query = query.Where("schedule_id = ?", scheduleID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var reports []ArchivedReport
+	err := query.Find(&reports).Error
+	return reports, err
+}
+
+// GetArchivedReport returns a single archived report by ID
+func GetArchivedReport(reportID uint) (*ArchivedReport, error) {
+	var report ArchivedReport
+	if err := DB.First(&report, reportID).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ArchivedReportPath returns the location of an archived report's file
+func ArchivedReportPath(report *ArchivedReport) string {
+	return filepath.Join(reportArchiveDir(), filepath.Base(report.Filename))
+}
+
+// DeleteArchivedReport removes an archived report and its file
+func DeleteArchivedReport(report *ArchivedReport) error {
+	if err := os.Remove(ArchivedReportPath(report)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return DB.Delete(report).Error
+}
+
+// PruneReportArchive deletes archived reports older than the configured retention and
+// returns how many were removed
+func PruneReportArchive(now time.Time) (int, error) {
+	if config.AppConfig == nil || config.AppConfig.ReportRetainDays <= 0 {
+		return 0, nil
+	}
+
+	var expired []ArchivedReport
+	cutoff := now.AddDate(0, 0, -config.AppConfig.ReportRetainDays)
+	if err := DB.Where("created_at < ?", cutoff).Find(&expired).Error; err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for i := range expired {
+		if err := DeleteArchivedReport(&expired[i]); err != nil {
+			utils.LogError("Error deleting archived report %s: %v", expired[i].Filename, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
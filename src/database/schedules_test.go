@@ -0,0 +1,81 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestArchiveReportKeepsReportsOfTheSameSecond(t *testing.T) {
+	t.Setenv("REPORT_ARCHIVE_DIR", t.TempDir())
+	openTestDB(t)
+
+	first := ArchivedReport{Filename: "users_report.csv"}
+	second := ArchivedReport{Filename: "users_report.csv"}
+	if err := ArchiveReport(&first, []byte("first")); err != nil {
+		t.Fatalf("ArchiveReport: %v", err)
+	}
+	if err := ArchiveReport(&second, []byte("second")); err != nil {
+		t.Fatalf("ArchiveReport: %v", err)
+	}
+	if first.Filename == second.Filename {
+		t.Fatalf("both reports were archived as %s", first.Filename)
+	}
+
+	for report, want := range map[*ArchivedReport]string{&first: "first", &second: "second"} {
+		data, err := os.ReadFile(ArchivedReportPath(report))
+		if err != nil {
+			t.Fatalf("reading %s: %v", report.Filename, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s contains %q, want %q", report.Filename, data, want)
+		}
+	}
+}
+
+func TestValidateReportScheduleFilters(t *testing.T) {
+	valid := func(scheduleType, format string) ReportSchedule {
+		return ReportSchedule{Name: "Weekly", Cron: "0 7 * * mon", Type: scheduleType, Range: ReportRangePreviousWeek,
+			Format: format, Enabled: true}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(s *ReportSchedule)
+		kind    string
+		format  string
+		wantErr bool
+	}{
+		{name: "users without filters", kind: ReportTypeUsers, format: "csv"},
+		{name: "users with blank lists", kind: ReportTypeUsers, format: "csv", modify: func(s *ReportSchedule) { s.Teams = " , " }},
+		{name: "users with users", kind: ReportTypeUsers, format: "csv", modify: func(s *ReportSchedule) { s.Users = "1" }, wantErr: true},
+		{name: "weekly with teams", kind: ReportTypeWeekly, format: "xlsx", modify: func(s *ReportSchedule) { s.Teams = "Coaches" }, wantErr: true},
+		{name: "weekly with categories", kind: ReportTypeWeekly, format: "csv", modify: func(s *ReportSchedule) { s.Categories = "Meeting" }, wantErr: true},
+		{name: "weekly with group_by", kind: ReportTypeWeekly, format: "csv", modify: func(s *ReportSchedule) { s.GroupBy = ReportGroupTeam }, wantErr: true},
+		{name: "report with all filters", kind: ReportTypeReport, format: "json", modify: func(s *ReportSchedule) {
+			s.GroupBy, s.Users, s.Teams, s.Categories = ReportGroupTeam, "1,2", "Coaches", "Meeting"
+		}},
+		{name: "report with invalid user", kind: ReportTypeReport, format: "csv", modify: func(s *ReportSchedule) { s.Users = "bob" }, wantErr: true},
+		{name: "timesheets with users and teams", kind: ReportTypeTimesheets, format: "pdf", modify: func(s *ReportSchedule) {
+			s.Users, s.Teams = "1", "Coaches"
+		}},
+		{name: "timesheets with categories", kind: ReportTypeTimesheets, format: "pdf", modify: func(s *ReportSchedule) { s.Categories = "Meeting" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := valid(tt.kind, tt.format)
+			if tt.modify != nil {
+				tt.modify(&schedule)
+			}
+			err := validateReportSchedule(&schedule, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateReportSchedule error = %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidReportSchedule) {
+				t.Errorf("error %v is not ErrInvalidReportSchedule", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,484 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/utils"
+)
+
+// ErrInvalidTeam is returned when a team fails validation
+var ErrInvalidTeam = errors.New("invalid team")
+
+// validateTeam normalizes and checks a team. Names are unique regardless of case and
+// contain no commas, since team filters are comma-separated lists.
+func validateTeam(tx *gorm.DB, team *Team) error {
+	team.Name = strings.TrimSpace(team.Name)
+	team.Description = strings.TrimSpace(team.Description)
+	if team.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTeam)
+	}
+	if strings.Contains(team.Name, ",") {
+		return fmt.Errorf("%w: name must not contain commas", ErrInvalidTeam)
+	}
+	if team.WeeklyTarget < 0 || team.MonthlyTarget < 0 {
+		return fmt.Errorf("%w: targets must not be negative", ErrInvalidTeam)
+	}
+
+	var existing int64
+	if err := tx.Model(&Team{}).Where("LOWER(name) = LOWER(?) AND id <> ?", team.Name, team.ID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return fmt.Errorf("%w: a team named %q already exists", ErrInvalidTeam, team.Name)
+	}
+	return nil
+}
+
+// GetTeams returns all teams with their members, ordered by name
+func GetTeams() ([]Team, error) {
+	var teams []Team
+	err := DB.Preload("Members").Order("name ASC").Find(&teams).Error
+	return teams, err
+}
+
+// GetTeam returns a single team with its members
+func GetTeam(teamID uint) (*Team, error) {
+	var team Team
+	if err := DB.Preload("Members").First(&team, teamID).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// CreateTeam validates and stores a new team
+func CreateTeam(team *Team) error {
+	if err := validateTeam(DB, team); err != nil {
+		return err
+	}
+	return DB.Omit("Members").Create(team).Error
+}
+
+// UpdateTeam validates and saves changes to a team. A rename is carried over to the
+// users, holiday calendars, compliance rule sets and report schedules that refer to the
+// team by its old name.
+func UpdateTeam(team *Team, oldName string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := validateTeam(tx, team); err != nil {
+			return err
+		}
+		if err := tx.Omit("Members").Save(team).Error; err != nil {
+			return err
+		}
+		if team.Name == oldName {
+			return nil
+		}
+
+		for _, model := range []interface{}{&User{}, &HolidayCalendar{}, &ComplianceRuleSet{}} {
+			if err := tx.Model(model).Where("LOWER(team) = LOWER(?)", oldName).Update("team", team.Name).Error; err != nil {
+				return err
+			}
+		}
+		return renameScheduleTeam(tx, oldName, team.Name)
+	})
+}
+
+// renameScheduleTeam replaces a team name in the team filters of report schedules
+func renameScheduleTeam(tx *gorm.DB, oldName, newName string) error {
+	var schedules []ReportSchedule
+	if err := tx.Where("teams <> ''").Find(&schedules).Error; err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		teams := SplitList(schedule.Teams)
+		changed := false
+		for i, name := range teams {
+			if strings.EqualFold(name, oldName) {
+				teams[i] = newName
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if err := tx.Model(&ReportSchedule{}).Where("id = ?", schedule.ID).Update("teams", strings.Join(teams, ",")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteTeam removes a team and its memberships. Users whose primary team it was are
+// left without one; calendars and rule sets keep the name and apply again if a team of
+// that name is assigned later.
+func DeleteTeam(team *Team) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", team.ID).Delete(&TeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&User{}).Where("LOWER(team) = LOWER(?)", team.Name).Update("team", "").Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Team{}, team.ID).Error
+	})
+}
+
+// addTeamMember adds a user to a team unless they already belong to it
+func addTeamMember(tx *gorm.DB, teamID, userID uint, source string) error {
+	var existing int64
+	if err := tx.Model(&TeamMember{}).Where("team_id = ? AND user_id = ?", teamID, userID).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+	return tx.Create(&TeamMember{TeamID: teamID, UserID: userID, Source: source}).Error
+}
+
+// AddTeamMembers adds users to a team. Unknown user IDs are rejected.
+func AddTeamMembers(team *Team, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return fmt.Errorf("%w: user_ids is required", ErrInvalidTeam)
+	}
+
+	var found int64
+	if err := DB.Model(&User{}).Where("id IN ?", userIDs).Count(&found).Error; err != nil {
+		return err
+	}
+	if int(found) != len(uniqueIDs(userIDs)) {
+		return fmt.Errorf("%w: unknown user ID", ErrInvalidTeam)
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, userID := range userIDs {
+			if err := addTeamMember(tx, team.ID, userID, TeamMemberManual); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveTeamMember removes a user from a team. If it was the user's primary team they
+// are left without one.
+func RemoveTeamMember(team *Team, userID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("team_id = ? AND user_id = ?", team.ID, userID).Delete(&TeamMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&User{}).Where("id = ? AND LOWER(team) = LOWER(?)", userID, team.Name).Update("team", "").Error
+	})
+}
+
+// uniqueIDs returns ids without duplicates, in their original order
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// findOrCreateTeam returns the team of a name (ignoring case), creating it if needed
+func findOrCreateTeam(tx *gorm.DB, name string) (*Team, error) {
+	var team Team
+	err := tx.Where("LOWER(name) = LOWER(?)", name).First(&team).Error
+	if err == nil {
+		return &team, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	team = Team{Name: name}
+	if err := validateTeam(tx, &team); err != nil {
+		return nil, err
+	}
+	if err := tx.Create(&team).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// SyncPrimaryTeam keeps team memberships in line with a user's primary team after it
+// changed from oldTeam: the user joins the new team (created if it does not exist) and
+// leaves the old one unless they were added to it separately.
+func SyncPrimaryTeam(user *User, oldTeam string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if oldTeam != "" && !strings.EqualFold(oldTeam, user.Team) {
+			var old Team
+			err := tx.Where("LOWER(name) = LOWER(?)", oldTeam).First(&old).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				if err := tx.Where("team_id = ? AND user_id = ? AND source = ?", old.ID, user.ID, TeamMemberPrimary).Delete(&TeamMember{}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if user.Team == "" {
+			return nil
+		}
+
+		team, err := findOrCreateTeam(tx, user.Team)
+		if err != nil {
+			return err
+		}
+		if team.Name != user.Team {
+			// Use the team's spelling so calendars and rule sets match consistently
+			if err := tx.Model(user).Update("team", team.Name).Error; err != nil {
+				return err
+			}
+		}
+		return addTeamMember(tx, team.ID, user.ID, TeamMemberPrimary)
+	})
+}
+
+// ensurePrimaryTeams creates the teams and memberships of the primary teams set on users,
+// so that teams named on users before teams existed show up as teams
+func ensurePrimaryTeams() {
+	var users []User
+	if err := DB.Where("team <> ''").Find(&users).Error; err != nil {
+		utils.LogError("Failed to load users with teams: %v", err)
+		return
+	}
+	for i := range users {
+		if err := SyncPrimaryTeam(&users[i], ""); err != nil {
+			utils.LogError("Failed to create team %q for user %d: %v", users[i].Team, users[i].ID, err)
+		}
+	}
+}
+
+// TeamUserIDs returns the IDs of the users that belong to any of the named teams, as
+// members or by their primary team. Names are matched ignoring case.
+func TeamUserIDs(names []string) ([]uint, error) {
+	lowered := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			lowered = append(lowered, strings.ToLower(name))
+		}
+	}
+	if len(lowered) == 0 {
+		return nil, nil
+	}
+
+	var ids []uint
+	err := DB.Raw(`
+		SELECT tm.user_id FROM team_members tm
+		JOIN teams t ON t.id = tm.team_id
+		WHERE LOWER(t.name) IN ?
+		UNION
+		SELECT id FROM users WHERE LOWER(team) IN ?
+	`, lowered, lowered).Scan(&ids).Error
+	return ids, err
+}
+
+// loadUserTeams returns the names of the teams each user belongs to, as a member or by
+// their primary team, sorted by name. With filter set only the named teams are returned,
+// matched ignoring case like TeamUserIDs.
+func loadUserTeams(users []User, filter []string) (map[uint][]string, error) {
+	userIDs := make([]uint, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	var memberships []struct {
+		UserID uint
+		Name   string
+	}
+	if err := DB.Raw(`
+		SELECT tm.user_id, t.name FROM team_members tm
+		JOIN teams t ON t.id = tm.team_id
+		WHERE tm.user_id IN ?
+	`, userIDs).Scan(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint][]string, len(users))
+	addTeam := func(userID uint, name string) {
+		if name == "" || containsFold(byUser[userID], name) || (len(filter) > 0 && !containsFold(filter, name)) {
+			return
+		}
+		byUser[userID] = append(byUser[userID], name)
+	}
+	for _, membership := range memberships {
+		addTeam(membership.UserID, membership.Name)
+	}
+	for _, user := range users {
+		addTeam(user.ID, user.Team)
+		sort.Slice(byUser[user.ID], func(i, j int) bool {
+			return strings.ToLower(byUser[user.ID][i]) < strings.ToLower(byUser[user.ID][j])
+		})
+	}
+	return byUser, nil
+}
+
+// Slack sources of synced teams
+const (
+	SlackTeamUserGroup = "usergroup"
+	SlackTeamChannel   = "channel"
+)
+
+// slackTeamColumn returns the column linking teams to a Slack source
+func slackTeamColumn(source string) string {
+	if source == SlackTeamChannel {
+		return "slack_channel"
+	}
+	return "slack_group_id"
+}
+
+// GetSlackTeam returns the team linked to a Slack user group or channel
+func GetSlackTeam(source, slackID string) (*Team, error) {
+	var team Team
+	if err := DB.Where(slackTeamColumn(source)+" = ?", slackID).First(&team).Error; err != nil {
+		return nil, err
+	}
+	return &team, nil
+}
+
+// LinkSlackTeam returns the team linked to a Slack user group or channel. An unlinked
+// team of the same name is linked to it; otherwise a team is created. A linked team
+// follows renames in Slack unless another team already has the new name.
+func LinkSlackTeam(source, slackID, name, description string) (*Team, error) {
+	team, err := GetSlackTeam(source, slackID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if team != nil {
+		if name == "" || name == team.Name {
+			return team, nil
+		}
+		oldName := team.Name
+		team.Name = name
+		if err := UpdateTeam(team, oldName); err != nil {
+			if !errors.Is(err, ErrInvalidTeam) {
+				return nil, err
+			}
+			utils.LogError("Not renaming team %q to Slack name %q: %v", oldName, name, err)
+			team.Name = oldName
+		}
+		return team, nil
+	}
+
+	var named Team
+	err = DB.Where("LOWER(name) = LOWER(?) AND "+slackTeamColumn(source)+" = ''", name).First(&named).Error
+	switch {
+	case err == nil:
+		team = &named
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		team = &Team{Name: strings.ReplaceAll(name, ",", " "), Description: description}
+	default:
+		return nil, err
+	}
+
+	if source == SlackTeamChannel {
+		team.SlackChannel = slackID
+	} else {
+		team.SlackGroupID = slackID
+	}
+	if team.ID == 0 {
+		return team, CreateTeam(team)
+	}
+	return team, DB.Model(team).Update(slackTeamColumn(source), slackID).Error
+}
+
+// slackUserIDs returns the user IDs of Slack users that are tracked
+func slackUserIDs(tx *gorm.DB, slackIDs []string) ([]uint, error) {
+	if len(slackIDs) == 0 {
+		return nil, nil
+	}
+	var ids []uint
+	err := tx.Model(&User{}).Where("slack_user_id IN ?", slackIDs).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// SetSlackTeamMembers makes the Slack-synced members of a team match the given Slack
+// users. Members added by hand or by their primary team are kept; Slack users without a
+// tracked user are skipped. It returns the number of members added and removed.
+func SetSlackTeamMembers(team *Team, slackIDs []string) (int, int, error) {
+	added, removed := 0, 0
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		userIDs, err := slackUserIDs(tx, slackIDs)
+		if err != nil {
+			return err
+		}
+
+		var members []TeamMember
+		if err := tx.Where("team_id = ?", team.ID).Find(&members).Error; err != nil {
+			return err
+		}
+		current := make(map[uint]TeamMember, len(members))
+		for _, member := range members {
+			current[member.UserID] = member
+		}
+
+		wanted := make(map[uint]bool, len(userIDs))
+		for _, userID := range userIDs {
+			wanted[userID] = true
+			if _, ok := current[userID]; !ok {
+				if err := tx.Create(&TeamMember{TeamID: team.ID, UserID: userID, Source: TeamMemberSlack}).Error; err != nil {
+					return err
+				}
+				added++
+			}
+		}
+		for userID, member := range current {
+			if member.Source == TeamMemberSlack && !wanted[userID] {
+				if err := tx.Delete(&member).Error; err != nil {
+					return err
+				}
+				removed++
+			}
+		}
+		return nil
+	})
+	return added, removed, err
+}
+
+// AddSlackTeamMembers adds Slack users to a team as Slack-synced members
+func AddSlackTeamMembers(team *Team, slackIDs []string) (int, error) {
+	added := 0
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		userIDs, err := slackUserIDs(tx, slackIDs)
+		if err != nil {
+			return err
+		}
+		for _, userID := range userIDs {
+			var existing int64
+			if err := tx.Model(&TeamMember{}).Where("team_id = ? AND user_id = ?", team.ID, userID).Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing == 0 {
+				if err := tx.Create(&TeamMember{TeamID: team.ID, UserID: userID, Source: TeamMemberSlack}).Error; err != nil {
+					return err
+				}
+				added++
+			}
+		}
+		return nil
+	})
+	return added, err
+}
+
+// RemoveSlackTeamMembers removes the Slack-synced memberships of Slack users from a team
+func RemoveSlackTeamMembers(team *Team, slackIDs []string) (int, error) {
+	userIDs, err := slackUserIDs(DB, slackIDs)
+	if err != nil || len(userIDs) == 0 {
+		return 0, err
+	}
+	result := DB.Where("team_id = ? AND user_id IN ? AND source = ?", team.ID, userIDs, TeamMemberSlack).Delete(&TeamMember{})
+	return int(result.RowsAffected), result.Error
+}
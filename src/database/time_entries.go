@@ -0,0 +1,176 @@
+package database
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidTimeRange is returned when an entry ends before it starts
+	ErrInvalidTimeRange = errors.New("end time must be after start time")
+	// ErrTimeEntryOverlap is returned when an entry overlaps an existing entry of the same user
+	ErrTimeEntryOverlap = errors.New("time entry overlaps an existing entry")
+)
+
+// GetTimeEntry returns a single time entry by ID
+func GetTimeEntry(entryID uint) (*TimeEntry, error) {
+	var entry TimeEntry
+	err := DB.First(&entry, entryID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetUserTimeEntries returns a user's time entries starting within the given range, ordered by start time
+func GetUserTimeEntries(userID uint, from, to time.Time) ([]TimeEntry, error) {
+	var entries []TimeEntry
+	err := DB.Where("user_id = ? AND start_time >= ? AND start_time < ?", userID, from, to).
+		Order("start_time ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// FindOverlappingTimeEntries returns entries of a user that overlap the given range.
+// Active entries (without end time) are treated as running until now.
+func FindOverlappingTimeEntries(userID uint, start, end time.Time, excludeID uint) ([]TimeEntry, error) {
+	query := DB.Where("user_id = ? AND id <> ? AND start_time < ?", userID, excludeID, end)
+	if start.Before(time.Now()) {
+		query = query.Where("(end_time IS NULL OR end_time > ?)", start)
+	} else {
+		query = query.Where("end_time > ?", start)
+	}
+
+	var entries []TimeEntry
+	err := query.Order("start_time ASC").Find(&entries).Error
+	return entries, err
+}
+
+// validateTimeEntry checks the range of an entry, that it does not overlap other entries
+// and that its project exists. An active entry without end time is checked up to now.
+func validateTimeEntry(entry *TimeEntry) error {
+	end := time.Now()
+	if entry.EndTime != nil {
+		end = *entry.EndTime
+	}
+	if !end.After(entry.StartTime) {
+		return ErrInvalidTimeRange
+	}
+
+	overlapping, err := FindOverlappingTimeEntries(entry.UserID, entry.StartTime, end, entry.ID)
+	if err != nil {
+		return err
+	}
+	if len(overlapping) > 0 {
+		return ErrTimeEntryOverlap
+	}
+
+	return checkProjectExists(entry.ProjectID)
+}
+
+// CreateManualTimeEntry creates a completed time entry entered by an admin, optionally
+// booked on a project
+func CreateManualTimeEntry(userID uint, start, end time.Time, category, note string, projectID *uint) (*TimeEntry, error) {
+	if category == "" {
+		category = "Working"
+	}
+
+	// Store times in local time like Slack-derived entries so range queries compare consistently
+	start, end = start.Local(), end.Local()
+
+	entry := TimeEntry{
+		UserID:    userID,
+		StartTime: start,
+		EndTime:   &end,
+		Status:    category,
+		Source:    TimeEntrySourceManual,
+		Note:      note,
+		ProjectID: projectID,
+	}
+
+	if err := validateTimeEntry(&entry); err != nil {
+		return nil, err
+	}
+	if err := CheckTimeEntryLocked(userID, start, end); err != nil {
+		return nil, err
+	}
+
+	entry.Duration = int64(end.Sub(start).Seconds())
+
+	if err := DB.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+
+	RefreshUserCompliance(userID, start, end)
+	return &entry, nil
+}
+
+// UpdateTimeEntry validates and saves changes to an existing entry, recomputing its duration.
+// The active entry may be saved without end time and keeps running.
+func UpdateTimeEntry(entry *TimeEntry) error {
+	entry.StartTime = entry.StartTime.Local()
+	if entry.EndTime != nil {
+		endTime := entry.EndTime.Local()
+		entry.EndTime = &endTime
+	}
+
+	original, err := GetTimeEntry(entry.ID)
+	if err != nil {
+		return err
+	}
+	if entry.EndTime == nil && original.EndTime != nil {
+		return ErrInvalidTimeRange
+	}
+	if err := validateTimeEntry(entry); err != nil {
+		return err
+	}
+
+	// Both the original and the new range must be outside locked periods
+	if err := checkEntryRangeLocked(original); err != nil {
+		return err
+	}
+	if err := checkEntryRangeLocked(entry); err != nil {
+		return err
+	}
+
+	end := time.Now()
+	if entry.EndTime != nil {
+		end = *entry.EndTime
+		entry.Duration = int64(end.Sub(entry.StartTime).Seconds())
+	}
+
+	if err := DB.Save(entry).Error; err != nil {
+		return err
+	}
+
+	RefreshUserCompliance(entry.UserID, original.StartTime, original.StartTime)
+	RefreshUserCompliance(entry.UserID, entry.StartTime, end)
+	return nil
+}
+
+// DeleteTimeEntry removes a time entry unless it lies in a locked period
+func DeleteTimeEntry(entryID uint) error {
+	entry, err := GetTimeEntry(entryID)
+	if err != nil {
+		return err
+	}
+	if err := checkEntryRangeLocked(entry); err != nil {
+		return err
+	}
+
+	if err := DB.Delete(&TimeEntry{}, entryID).Error; err != nil {
+		return err
+	}
+
+	RefreshUserCompliance(entry.UserID, entry.StartTime, entry.StartTime)
+	return nil
+}
+
+// checkEntryRangeLocked checks the range covered by an entry, treating active entries as running until now
+func checkEntryRangeLocked(entry *TimeEntry) error {
+	end := time.Now()
+	if entry.EndTime != nil {
+		end = *entry.EndTime
+	}
+	return CheckTimeEntryLocked(entry.UserID, entry.StartTime, end)
+}
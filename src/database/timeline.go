@@ -0,0 +1,152 @@
+package database
+
+import (
+	"sort"
+	"time"
+)
+
+// Timeline segment kinds
+const (
+	TimelineStatus = "status"
+	TimelineEntry  = "entry"
+)
+
+// Timeline classifications of status segments
+const (
+	TimelineWorking    = "working"
+	TimelineNotWorking = "not_working"
+	TimelineOffline    = "offline"
+)
+
+// TimelineSegment is a status period or time entry on a user's timeline, clipped to the day
+type TimelineSegment struct {
+	Kind           string    `json:"kind"` // status or entry
+	ID             uint      `json:"id"`   // UserStatus or TimeEntry ID
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Open           bool      `json:"open"` // Still running
+	Classification string    `json:"classification"`
+	Emoji          string    `json:"emoji"`
+	Text           string    `json:"text"`
+	Source         string    `json:"source"`
+	Presence       string    `json:"presence"` // active or away, as seen by the Slack integration
+}
+
+// UserTimeline is a user's statuses and time entries on one day
+type UserTimeline struct {
+	UserID   uint              `json:"user_id"`
+	Date     time.Time         `json:"date"`
+	Start    time.Time         `json:"start"`
+	End      time.Time         `json:"end"`
+	Segments []TimelineSegment `json:"segments"`
+}
+
+// statusSegment classifies a status record. Offline users are recorded with an empty
+// emoji and the text "offline".
+func statusSegment(status UserStatus) TimelineSegment {
+	segment := TimelineSegment{
+		Kind:           TimelineStatus,
+		ID:             status.ID,
+		Start:          status.Timestamp,
+		Classification: TimelineNotWorking,
+		Emoji:          status.StatusEmoji,
+		Text:           status.StatusText,
+		Source:         "slack",
+		Presence:       "active",
+	}
+	switch {
+	case status.StatusEmoji == "" && status.StatusText == "offline":
+		segment.Classification = TimelineOffline
+		segment.Presence = "away"
+	case status.IsWorking:
+		segment.Classification = TimelineWorking
+	}
+	return segment
+}
+
+// GetUserTimeline returns the status periods and time entries of a user on the day of
+// date, ordered by start. The status in effect at midnight is carried into the day and
+// open segments end now.
+func GetUserTimeline(userID uint, date, now time.Time) (*UserTimeline, error) {
+	start := dayStart(date)
+	end := start.AddDate(0, 0, 1)
+	limit := end
+	if now.Before(limit) {
+		limit = now
+	}
+
+	timeline := &UserTimeline{UserID: userID, Date: start, Start: start, End: end, Segments: []TimelineSegment{}}
+	if !limit.After(start) {
+		return timeline, nil
+	}
+
+	var previous UserStatus
+	err := DB.Where("user_id = ? AND timestamp < ?", userID, start).Order("timestamp DESC, id DESC").Limit(1).Find(&previous).Error
+	if err != nil {
+		return nil, err
+	}
+	var statuses []UserStatus
+	err = DB.Where("user_id = ? AND timestamp >= ? AND timestamp < ?", userID, start, limit).Order("timestamp ASC, id ASC").Find(&statuses).Error
+	if err != nil {
+		return nil, err
+	}
+	if previous.ID != 0 {
+		statuses = append([]UserStatus{previous}, statuses...)
+	}
+
+	for i, status := range statuses {
+		segment := statusSegment(status)
+		if segment.Start.Before(start) {
+			segment.Start = start
+		}
+		if i+1 < len(statuses) {
+			segment.End = statuses[i+1].Timestamp
+		} else {
+			segment.End = limit
+			segment.Open = limit.Equal(now)
+		}
+		if segment.End.After(segment.Start) {
+			timeline.Segments = append(timeline.Segments, segment)
+		}
+	}
+
+	var entries []TimeEntry
+	err = DB.Where("user_id = ? AND start_time < ? AND (end_time IS NULL OR end_time > ?)", userID, limit, start).
+		Order("start_time ASC, id ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		segment := TimelineSegment{
+			Kind:           TimelineEntry,
+			ID:             entry.ID,
+			Start:          entry.StartTime,
+			End:            limit,
+			Open:           entry.EndTime == nil,
+			Classification: entry.Status,
+			Emoji:          entry.StatusEmoji,
+			Text:           entry.StatusText,
+			Source:         entry.Source,
+			Presence:       "active",
+		}
+		if entry.Note != "" && segment.Text == "" {
+			segment.Text = entry.Note
+		}
+		if segment.Start.Before(start) {
+			segment.Start = start
+		}
+		if entry.EndTime != nil && entry.EndTime.Before(limit) {
+			segment.End = *entry.EndTime
+		}
+		if segment.End.After(segment.Start) {
+			timeline.Segments = append(timeline.Segments, segment)
+		}
+	}
+
+	sort.SliceStable(timeline.Segments, func(i, j int) bool {
+		return timeline.Segments[i].Start.Before(timeline.Segments[j].Start)
+	})
+	return timeline, nil
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTimesheetTransition is returned for state changes the workflow does not allow
+var ErrInvalidTimesheetTransition = errors.New("invalid timesheet state transition")
+
+// timesheetTransitions lists the states each timesheet state may move to
+var timesheetTransitions = map[string][]string{
+	TimesheetStatusDraft:     {TimesheetStatusSubmitted},
+	TimesheetStatusSubmitted: {TimesheetStatusApproved, TimesheetStatusRejected, TimesheetStatusDraft},
+	TimesheetStatusRejected:  {TimesheetStatusSubmitted, TimesheetStatusDraft},
+	TimesheetStatusApproved:  {TimesheetStatusDraft}, // Reopening unlocks the week
+}
+
+// TimesheetFilter holds the optional filters for listing timesheets
+type TimesheetFilter struct {
+	UserID    uint
+	WeekStart *time.Time
+	Status    string
+}
+
+// WeekStartOf returns Monday 00:00 local time of the week containing t
+func WeekStartOf(t time.Time) time.Time {
+	day := dayStart(t)
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// refreshTimesheetTotals copies the user's tracked and required hours from the weekly report
+func refreshTimesheetTotals(timesheet *Timesheet) error {
+	reports, err := GetWeeklyReports(timesheet.WeekStart)
+	if err != nil {
+		return err
+	}
+
+	timesheet.TotalHours = 0
+	for _, report := range reports {
+		if report.UserID == timesheet.UserID {
+			timesheet.TotalHours = report.TotalHours
+			timesheet.RequiredHours = report.RequiredHours
+			break
+		}
+	}
+
+	return nil
+}
+
+// GetOrCreateTimesheet returns the user's timesheet for the week, creating a draft if needed.
+// Totals of draft and rejected timesheets are refreshed from the current entries.
+func GetOrCreateTimesheet(userID uint, weekStart time.Time) (*Timesheet, error) {
+	weekStart = WeekStartOf(weekStart)
+
+	var timesheet Timesheet
+	err := DB.Where("user_id = ? AND week_start = ?", userID, weekStart).First(&timesheet).Error
+	if err == nil {
+		if timesheet.Status == TimesheetStatusDraft || timesheet.Status == TimesheetStatusRejected {
+			if err := refreshTimesheetTotals(&timesheet); err != nil {
+				return nil, err
+			}
+			if err := DB.Save(&timesheet).Error; err != nil {
+				return nil, err
+			}
+		}
+		return &timesheet, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	timesheet = Timesheet{
+		UserID:    userID,
+		WeekStart: weekStart,
+		WeekEnd:   weekStart.AddDate(0, 0, 6),
+		Status:    TimesheetStatusDraft,
+	}
+	if err := refreshTimesheetTotals(&timesheet); err != nil {
+		return nil, err
+	}
+
+	err = DB.Create(&timesheet).Error
+	return &timesheet, err
+}
+
+// GetTimesheet returns a timesheet with its user and comments
+func GetTimesheet(timesheetID uint) (*Timesheet, error) {
+	var timesheet Timesheet
+	err := DB.Preload("User").
+		Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&timesheet, timesheetID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &timesheet, nil
+}
+
+// GetTimesheets returns timesheets matching the filter, newest week first
+func GetTimesheets(filter TimesheetFilter) ([]Timesheet, error) {
+	query := DB.Preload("User")
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.WeekStart != nil {
+		query = query.Where("week_start = ?", WeekStartOf(*filter.WeekStart))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var timesheets []Timesheet
+	err := query.Order("week_start DESC, user_id ASC").Find(&timesheets).Error
+	return timesheets, err
+}
+
+// TransitionTimesheet moves a timesheet to a new state if the workflow allows it
+func TransitionTimesheet(timesheet *Timesheet, status string, adminID uint) error {
+	allowed := false
+	for _, next := range timesheetTransitions[timesheet.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrInvalidTimesheetTransition
+	}
+
+	now := time.Now()
+	switch status {
+	case TimesheetStatusSubmitted:
+		// Freeze the totals at submission time
+		if err := refreshTimesheetTotals(timesheet); err != nil {
+			return err
+		}
+		timesheet.SubmittedAt = &now
+		timesheet.ReviewedAt = nil
+		timesheet.ReviewedBy = 0
+	case TimesheetStatusApproved, TimesheetStatusRejected:
+		if status == TimesheetStatusApproved {
+			// An approved week cannot be changed, so an entry running into it is ended first
+			weekStart := WeekStartOf(timesheet.WeekStart)
+			if err := endEntriesRunningInto(timesheet.UserID, weekStart, weekStart.AddDate(0, 0, 7)); err != nil {
+				return err
+			}
+		}
+		timesheet.ReviewedAt = &now
+		timesheet.ReviewedBy = adminID
+	case TimesheetStatusDraft:
+		timesheet.SubmittedAt = nil
+		timesheet.ReviewedAt = nil
+		timesheet.ReviewedBy = 0
+	}
+
+	timesheet.Status = status
+	return DB.Omit("User", "Comments").Save(timesheet).Error
+}
+
+// AddTimesheetComment adds a comment to a timesheet
+func AddTimesheetComment(timesheetID, authorID uint, authorName, status, body string) (*TimesheetComment, error) {
+	comment := TimesheetComment{
+		TimesheetID: timesheetID,
+		AuthorID:    authorID,
+		AuthorName:  authorName,
+		Status:      status,
+		Body:        body,
+	}
+
+	err := DB.Create(&comment).Error
+	return &comment, err
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidTrackingRule is returned when a tracking rule fails validation
+var ErrInvalidTrackingRule = errors.New("invalid tracking rule")
+
+// validateTrackingRule normalizes and checks a tracking rule
+func validateTrackingRule(rule *TrackingRule) error {
+	rule.Kind = strings.ToLower(strings.TrimSpace(rule.Kind))
+	rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+	rule.Value = strings.TrimSpace(rule.Value)
+	rule.Note = strings.TrimSpace(rule.Note)
+
+	switch rule.Action {
+	case "":
+		rule.Action = TrackingRuleInclude
+	case TrackingRuleInclude, TrackingRuleExclude:
+	default:
+		return fmt.Errorf("%w: action must be include or exclude", ErrInvalidTrackingRule)
+	}
+
+	switch rule.Kind {
+	case TrackingRuleGuest:
+		rule.Value = ""
+	case TrackingRuleEmailDomain:
+		rule.Value = strings.ToLower(strings.TrimPrefix(rule.Value, "@"))
+		if rule.Value == "" || strings.Contains(rule.Value, "@") {
+			return fmt.Errorf("%w: email_domain needs a domain such as example.com", ErrInvalidTrackingRule)
+		}
+	case TrackingRuleUserGroup, TrackingRuleChannel, TrackingRuleUser:
+		if rule.Value == "" {
+			return fmt.Errorf("%w: %s needs a value", ErrInvalidTrackingRule, rule.Kind)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be usergroup, channel, email_domain, guest or user", ErrInvalidTrackingRule)
+	}
+	return nil
+}
+
+// GetTrackingRules returns all tracking rules in the order they were created
+func GetTrackingRules() ([]TrackingRule, error) {
+	var rules []TrackingRule
+	err := DB.Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// GetTrackingRule returns a single tracking rule by ID
+func GetTrackingRule(ruleID uint) (*TrackingRule, error) {
+	var rule TrackingRule
+	if err := DB.First(&rule, ruleID).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateTrackingRule validates and stores a new tracking rule
+func CreateTrackingRule(rule *TrackingRule) error {
+	if err := validateTrackingRule(rule); err != nil {
+		return err
+	}
+	return DB.Create(rule).Error
+}
+
+// UpdateTrackingRule validates and saves changes to a tracking rule
+func UpdateTrackingRule(rule *TrackingRule) error {
+	if err := validateTrackingRule(rule); err != nil {
+		return err
+	}
+	return DB.Save(rule).Error
+}
+
+// DeleteTrackingRule removes a tracking rule
+func DeleteTrackingRule(ruleID uint) error {
+	return DB.Delete(&TrackingRule{}, ruleID).Error
+}
+
+// TrackingCandidate is a Slack user whose tracking is decided by the rules
+type TrackingCandidate struct {
+	SlackUserID string
+	Email       string
+	Guest       bool
+}
+
+// TrackingScope evaluates tracking rules. Members holds the Slack user IDs of the user
+// groups and channels the rules refer to, keyed by kind and ID (see ScopeMembersKey).
+type TrackingScope struct {
+	Rules   []TrackingRule
+	Members map[string]map[string]bool
+}
+
+// ScopeMembersKey returns the key of a user group's or channel's members in a scope
+func ScopeMembersKey(kind, id string) string {
+	return kind + ":" + id
+}
+
+// matches reports whether a rule applies to a candidate
+func (scope *TrackingScope) matches(rule TrackingRule, candidate TrackingCandidate) bool {
+	switch rule.Kind {
+	case TrackingRuleGuest:
+		return candidate.Guest
+	case TrackingRuleEmailDomain:
+		return strings.HasSuffix(strings.ToLower(candidate.Email), "@"+rule.Value)
+	case TrackingRuleUser:
+		return rule.Value == candidate.SlackUserID || (candidate.Email != "" && strings.EqualFold(rule.Value, candidate.Email))
+	case TrackingRuleUserGroup, TrackingRuleChannel:
+		return scope.Members[ScopeMembersKey(rule.Kind, rule.Value)][candidate.SlackUserID]
+	}
+	return false
+}
+
+// NeedsEmail reports whether any rule matches on email addresses
+func (scope *TrackingScope) NeedsEmail() bool {
+	if scope == nil {
+		return false
+	}
+	for _, rule := range scope.Rules {
+		if rule.Kind == TrackingRuleEmailDomain || (rule.Kind == TrackingRuleUser && strings.Contains(rule.Value, "@")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tracks reports whether a Slack user is tracked: they match no exclude rule and, if
+// there are include rules, at least one of them. Without rules everyone is tracked.
+func (scope *TrackingScope) Tracks(candidate TrackingCandidate) bool {
+	if scope == nil {
+		return true
+	}
+
+	hasIncludes, included := false, false
+	for _, rule := range scope.Rules {
+		matched := scope.matches(rule, candidate)
+		if rule.Action == TrackingRuleExclude {
+			if matched {
+				return false
+			}
+			continue
+		}
+		hasIncludes = true
+		included = included || matched
+	}
+	return !hasIncludes || included
+}
+
+// StoredUserEmail returns the email stored for a Slack user ID, empty if there is no
+// user row
+func StoredUserEmail(slackUserID string) (string, error) {
+	var user User
+	err := DB.Select("email").Where("slack_user_id = ?", slackUserID).Limit(1).Find(&user).Error
+	return user.Email, err
+}
+
+// StopTrackingUser deactivates the user of a Slack user ID that is no longer tracked and
+// ends their running time entry. It returns the user if they were active.
+func StopTrackingUser(slackUserID string) (*User, error) {
+	var user User
+	result := DB.Where("slack_user_id = ? AND is_active = ?", slackUserID, true).Limit(1).Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	// An entry in a locked period stays open until the period is unlocked
+	if err := EndTimeEntry(user.ID); err != nil && !errors.Is(err, ErrPeriodLocked) {
+		return nil, err
+	}
+	if err := DB.Model(&user).Updates(map[string]interface{}{
+		"is_active":   false,
+		"inactive_by": UserInactiveByTrackingScope,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResumeTrackingUser reactivates the user of a Slack user ID who was deactivated for
+// leaving the tracking scope and is back in it. Users deactivated by an admin stay
+// inactive. It returns the user if they were reactivated.
+func ResumeTrackingUser(slackUserID string) (*User, error) {
+	var user User
+	result := DB.Where("slack_user_id = ? AND is_active = ? AND inactive_by = ?", slackUserID, false, UserInactiveByTrackingScope).
+		Limit(1).
+		Find(&user)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	if err := DB.Model(&user).Updates(map[string]interface{}{
+		"is_active":   true,
+		"inactive_by": "",
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
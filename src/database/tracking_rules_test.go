@@ -0,0 +1,70 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResumeTrackingUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		inactiveBy string
+		wantActive bool
+	}{
+		{name: "deactivated by the tracking scope", inactiveBy: UserInactiveByTrackingScope, wantActive: true},
+		{name: "deactivated by an admin", inactiveBy: UserInactiveByAdmin, wantActive: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			user := createTestUser(t, "U1")
+			if err := DB.Model(user).Updates(map[string]interface{}{"is_active": false, "inactive_by": tt.inactiveBy}).Error; err != nil {
+				t.Fatalf("deactivating user: %v", err)
+			}
+
+			resumed, err := ResumeTrackingUser(user.SlackUserID)
+			if err != nil {
+				t.Fatalf("ResumeTrackingUser: %v", err)
+			}
+			if (resumed != nil) != tt.wantActive {
+				t.Errorf("ResumeTrackingUser returned %v, want a user: %v", resumed, tt.wantActive)
+			}
+
+			var stored User
+			DB.First(&stored, user.ID)
+			if stored.IsActive != tt.wantActive {
+				t.Errorf("is_active = %v, want %v", stored.IsActive, tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestStopTrackingUserEndsEntryAndRecordsReason(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+	entry := createRunningEntry(t, user.ID, time.Now().Add(-time.Hour))
+
+	if _, err := StopTrackingUser(user.SlackUserID); err != nil {
+		t.Fatalf("StopTrackingUser: %v", err)
+	}
+
+	var stored User
+	DB.First(&stored, user.ID)
+	if stored.IsActive || stored.InactiveBy != UserInactiveByTrackingScope {
+		t.Errorf("user is_active = %v, inactive_by = %q, want inactive by %q", stored.IsActive, stored.InactiveBy, UserInactiveByTrackingScope)
+	}
+	var ended TimeEntry
+	DB.First(&ended, entry.ID)
+	if ended.EndTime == nil {
+		t.Error("running entry was not ended")
+	}
+
+	if _, err := ResumeTrackingUser(user.SlackUserID); err != nil {
+		t.Fatalf("ResumeTrackingUser: %v", err)
+	}
+	DB.First(&stored, user.ID)
+	if !stored.IsActive || stored.InactiveBy != "" {
+		t.Errorf("after resuming is_active = %v, inactive_by = %q", stored.IsActive, stored.InactiveBy)
+	}
+}
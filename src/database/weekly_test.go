@@ -0,0 +1,53 @@
+package database
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetWeeklyReportsNormalizesWeekStart(t *testing.T) {
+	location := useLocation(t, "Europe/Berlin")
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, location)
+
+	tests := []struct {
+		name      string
+		weekStart time.Time
+	}{
+		{name: "local midnight", weekStart: monday},
+		{name: "UTC midnight", weekStart: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "time of day", weekStart: monday.Add(15*time.Hour + 20*time.Minute)},
+		{name: "later in the week", weekStart: monday.AddDate(0, 0, 3).Add(9 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			user := createTestUser(t, "U1")
+			createClosedEntry(t, user.ID, monday.Add(9*time.Hour), monday.Add(17*time.Hour))
+
+			reports, err := GetWeeklyReports(tt.weekStart)
+			if err != nil {
+				t.Fatalf("GetWeeklyReports: %v", err)
+			}
+			if len(reports) != 1 {
+				t.Fatalf("got %d reports, want 1", len(reports))
+			}
+			report := reports[0]
+			for _, check := range []struct {
+				field     string
+				got, want float64
+			}{
+				{"total hours", report.TotalHours, 8},
+				{"required hours", report.RequiredHours, 20}, // DEFAULT_WEEKLY_HOURS over mon-fri
+				{"break hours", report.BreakHours, 0.5},      // BREAK_DEDUCTION_POLICY 6:30 on Monday
+				{"net hours", report.NetHours, 7.5},
+				{"rounded hours", report.RoundedHours, 8},
+			} {
+				if math.Abs(check.got-check.want) > 0.01 {
+					t.Errorf("%s = %.2f, want %.2f", check.field, check.got, check.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/services"
+	"sports-excitement-team-management/src/utils"
+)
+
+// AbsenceRequest is the request body for creating or editing an absence
+type AbsenceRequest struct {
+	UserID       *uint   `json:"user_id"`
+	Type         *string `json:"type"`       // vacation, sick, unpaid or other
+	StartDate    *string `json:"start_date"` // YYYY-MM-DD
+	EndDate      *string `json:"end_date"`   // YYYY-MM-DD, inclusive
+	StartHalfDay *bool   `json:"start_half_day"`
+	EndHalfDay   *bool   `json:"end_half_day"`
+	Note         *string `json:"note"`
+}
+
+// apply copies the provided fields of the request onto an absence
+func (req AbsenceRequest) apply(absence *database.Absence) error {
+	if req.Type != nil {
+		absence.Type = *req.Type
+	}
+	if req.StartDate != nil {
+		startDate, err := time.ParseInLocation("2006-01-02", *req.StartDate, time.Local)
+		if err != nil {
+			return errors.New("invalid start_date format. Use YYYY-MM-DD")
+		}
+		absence.StartDate = startDate
+	}
+	if req.EndDate != nil {
+		endDate, err := time.ParseInLocation("2006-01-02", *req.EndDate, time.Local)
+		if err != nil {
+			return errors.New("invalid end_date format. Use YYYY-MM-DD")
+		}
+		absence.EndDate = endDate
+	}
+	if req.StartHalfDay != nil {
+		absence.StartHalfDay = *req.StartHalfDay
+	}
+	if req.EndHalfDay != nil {
+		absence.EndHalfDay = *req.EndHalfDay
+	}
+	if req.Note != nil {
+		absence.Note = *req.Note
+	}
+	return nil
+}
+
+// absenceErrorResponse maps absence validation errors to an HTTP response
+func absenceErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, database.ErrInvalidAbsence):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, database.ErrAbsenceOverlap):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// loadAbsence loads the absence referenced by the id route parameter
+func loadAbsence(c *fiber.Ctx) (*database.Absence, error) {
+	absenceID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid absence ID",
+		})
+	}
+
+	absence, err := database.GetAbsence(absenceID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Absence not found",
+		})
+	}
+
+	return absence, nil
+}
+
+// notifyAbsenceStatus sends the absent user a Slack DM about a review decision
+func notifyAbsenceStatus(absence *database.Absence) {
+	slackService := services.GetGlobalSlackService()
+	if slackService == nil || absence.User == nil {
+		return
+	}
+
+	text := fmt.Sprintf("Your %s absence from %s to %s has been *%s*.", absence.Type,
+		absence.StartDate.Format("Jan 02, 2006"), absence.EndDate.Format("Jan 02, 2006"), absence.Status)
+
+	if err := slackService.SendDirectMessage(absence.User.SlackUserID, text); err != nil {
+		utils.LogError("Error sending absence notification to %s: %v", absence.User.Name, err)
+	}
+}
+
+// GetAbsencesAPI lists absences filtered by user_id, status, type, from and to
+func GetAbsencesAPI(c *fiber.Ctx) error {
+	filter := database.AbsenceFilter{
+		UserID: uint(c.QueryInt("user_id", 0)),
+		Status: c.Query("status"),
+		Type:   c.Query("type"),
+	}
+
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from format. Use YYYY-MM-DD",
+			})
+		}
+		filter.From = &fromDate
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid to format. Use YYYY-MM-DD",
+			})
+		}
+		toDate = toDate.AddDate(0, 0, 1) // Include the whole last day
+		filter.To = &toDate
+	}
+
+	absences, err := database.GetAbsences(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load absences",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"absences": absences,
+	})
+}
+
+// CreateAbsenceAPI records an absence entered by an admin; it is approved right away
+func CreateAbsenceAPI(c *fiber.Ctx) error {
+	var req AbsenceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.UserID == nil || req.StartDate == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user_id and start_date are required",
+		})
+	}
+	if req.EndDate == nil {
+		req.EndDate = req.StartDate
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, *req.UserID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	adminID, _ := currentAdmin(c)
+	now := time.Now()
+	absence := database.Absence{
+		UserID:     user.ID,
+		Type:       database.AbsenceTypeVacation,
+		Status:     database.AbsenceStatusApproved,
+		Source:     database.AbsenceSourceManual,
+		ReviewedBy: adminID,
+		ReviewedAt: &now,
+	}
+	if err := req.apply(&absence); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := database.CreateAbsence(&absence); err != nil {
+		return absenceErrorResponse(c, err, "Failed to create absence")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityAbsence, absence.ID, nil, absence)
+	broadcastUserChange(user.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(absence)
+}
+
+// UpdateAbsenceAPI edits an absence
+func UpdateAbsenceAPI(c *fiber.Ctx) error {
+	absence, err := loadAbsence(c)
+	if absence == nil {
+		return err
+	}
+
+	var req AbsenceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.UserID != nil && *req.UserID != absence.UserID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "user_id cannot be changed",
+		})
+	}
+
+	before := *absence
+	if err := req.apply(absence); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := database.UpdateAbsence(absence); err != nil {
+		return absenceErrorResponse(c, err, "Failed to update absence")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityAbsence, absence.ID, before, absence)
+	broadcastUserChange(absence.UserID)
+
+	return c.JSON(absence)
+}
+
+// AbsenceReviewHandler returns a handler that approves or rejects an absence
+func AbsenceReviewHandler(status string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		absence, err := loadAbsence(c)
+		if absence == nil {
+			return err
+		}
+
+		if absence.Status == status {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": fmt.Sprintf("Absence is already %s", status),
+			})
+		}
+
+		before := *absence
+		adminID, _ := currentAdmin(c)
+		if err := database.ReviewAbsence(absence, status, adminID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to review absence",
+			})
+		}
+
+		recordAudit(c, database.AuditActionUpdate, database.AuditEntityAbsence, absence.ID, before, absence)
+		broadcastUserChange(absence.UserID)
+		go notifyAbsenceStatus(absence)
+
+		return c.JSON(absence)
+	}
+}
+
+// DeleteAbsenceAPI removes an absence
+func DeleteAbsenceAPI(c *fiber.Ctx) error {
+	absence, err := loadAbsence(c)
+	if absence == nil {
+		return err
+	}
+
+	if err := database.DeleteAbsence(absence.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete absence",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityAbsence, absence.ID, absence, nil)
+	broadcastUserChange(absence.UserID)
+
+	return c.JSON(fiber.Map{
+		"message": "Absence deleted",
+	})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// currentAdmin returns the ID and username of the admin from the session locals
+func currentAdmin(c *fiber.Ctx) (uint, string) {
+	var adminID uint
+	switch id := c.Locals("user_id").(type) {
+	case uint:
+		adminID = id
+	case int:
+		adminID = uint(id)
+	case uint64:
+		adminID = uint(id)
+	case int64:
+		adminID = uint(id)
+	}
+
+	username, _ := c.Locals("username").(string)
+	return adminID, username
+}
+
+// recordAudit writes an audit log entry for a mutation made by the current admin.
+// Failures are logged but do not fail the request, since the mutation already happened.
+func recordAudit(c *fiber.Ctx, action, entity string, entityID interface{}, before, after interface{}) {
+	adminID, username := currentAdmin(c)
+	if _, err := database.CreateAuditLog(adminID, username, action, entity, entityID, before, after); err != nil {
+		utils.LogError("Failed to record audit log for %s %s %v: %v", action, entity, entityID, err)
+	}
+}
+
+// parseAuditFilter reads audit log filters from the query string
+func parseAuditFilter(c *fiber.Ctx) (database.AuditFilter, error) {
+	filter := database.AuditFilter{
+		Action:   c.Query("action"),
+		Entity:   c.Query("entity"),
+		EntityID: c.Query("entity_id"),
+	}
+
+	if actorParam := c.Query("actor_id"); actorParam != "" {
+		actorID, err := strconv.ParseUint(actorParam, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid actor_id")
+		}
+		filter.ActorID = uint(actorID)
+	}
+
+	if fromParam := c.Query("from"); fromParam != "" {
+		from, err := time.ParseInLocation("2006-01-02", fromParam, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from format. Use YYYY-MM-DD")
+		}
+		filter.From = &from
+	}
+
+	if toParam := c.Query("to"); toParam != "" {
+		to, err := time.ParseInLocation("2006-01-02", toParam, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to format. Use YYYY-MM-DD")
+		}
+		to = to.AddDate(0, 0, 1) // Include the whole end day
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// GetAuditLogsAPI returns a filtered, paginated list of audit log entries
+func GetAuditLogsAPI(c *fiber.Ctx) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	perPage := c.QueryInt("per_page", 50)
+	if perPage < 1 || perPage > 500 {
+		perPage = 50
+	}
+
+	logs, total, err := database.GetAuditLogs(filter, page, perPage)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load audit log",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"entries":  logs,
+		"total":    total,
+		"page":     page,
+		"per_page": perPage,
+	})
+}
+
+// ExportAuditLogsCSV exports the filtered audit log as CSV
+func ExportAuditLogsCSV(c *fiber.Ctx) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	logs, err := database.GetAllAuditLogs(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load audit log",
+		})
+	}
+
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=audit_log_%s.csv", time.Now().Format("2006-01-02")))
+
+	writer := csv.NewWriter(c)
+	writer.Write([]string{"Timestamp", "Actor ID", "Actor", "Action", "Entity", "Entity ID", "Before", "After"})
+	for _, log := range logs {
+		writer.Write([]string{
+			log.Timestamp.Format("2006-01-02 15:04:05"),
+			strconv.FormatUint(uint64(log.ActorID), 10),
+			log.ActorName,
+			log.Action,
+			log.Entity,
+			log.EntityID,
+			log.Before,
+			log.After,
+		})
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
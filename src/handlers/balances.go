@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/database"
+)
+
+// BalanceAdjustmentRequest is the request body for adjusting a flextime balance
+type BalanceAdjustmentRequest struct {
+	Date   string  `json:"date"`  // YYYY-MM-DD, defaults to today
+	Kind   string  `json:"kind"`  // "adjustment" (default) or "payout"
+	Hours  float64 `json:"hours"` // Signed for adjustments, positive for payouts
+	Reason string  `json:"reason"`
+}
+
+// GetUserBalanceAPI returns a user's flextime balance week by week, up to today or the until date
+func GetUserBalanceAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	until := time.Now()
+	if untilParam := c.Query("until"); untilParam != "" {
+		if until, err = time.ParseInLocation("2006-01-02", untilParam, time.Local); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid until format. Use YYYY-MM-DD",
+			})
+		}
+	}
+
+	balance, err := database.GetUserBalance(userID, until)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate balance",
+		})
+	}
+
+	return c.JSON(balance)
+}
+
+// CreateBalanceAdjustmentAPI records a manual adjustment or payout on a user's balance
+func CreateBalanceAdjustmentAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	var req BalanceAdjustmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	date := time.Now()
+	if req.Date != "" {
+		if date, err = time.ParseInLocation("2006-01-02", req.Date, time.Local); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid date format. Use YYYY-MM-DD",
+			})
+		}
+	}
+	if req.Kind == "" {
+		req.Kind = database.BalanceAdjustmentManual
+	}
+
+	adminID, username := currentAdmin(c)
+	adjustment := database.BalanceAdjustment{
+		UserID:        user.ID,
+		Date:          date,
+		Kind:          req.Kind,
+		Hours:         req.Hours,
+		Reason:        req.Reason,
+		CreatedBy:     adminID,
+		CreatedByName: username,
+	}
+	if err := database.CreateBalanceAdjustment(&adjustment); err != nil {
+		if errors.Is(err, database.ErrInvalidBalanceAdjustment) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save balance adjustment",
+		})
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityBalance, adjustment.ID, nil, adjustment)
+
+	return c.Status(fiber.StatusCreated).JSON(adjustment)
+}
+
+// DeleteBalanceAdjustmentAPI removes a balance adjustment or payout
+func DeleteBalanceAdjustmentAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	adjustmentID, err := parseIDParam(c, "adjustmentId")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid adjustment ID",
+		})
+	}
+
+	adjustment, err := database.GetBalanceAdjustment(adjustmentID)
+	if err != nil || adjustment.UserID != userID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Balance adjustment not found",
+		})
+	}
+
+	if err := database.DeleteBalanceAdjustment(adjustment.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete balance adjustment",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityBalance, adjustment.ID, adjustment, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Balance adjustment deleted",
+	})
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// calendarFeedURL returns the subscription URL of a feed token
+func calendarFeedURL(c *fiber.Ctx, token string) string {
+	base := c.BaseURL()
+	if config.AppConfig != nil && config.AppConfig.PublicURL != "" {
+		base = strings.TrimRight(config.AppConfig.PublicURL, "/")
+	}
+	return fmt.Sprintf("%s/calendar/%s.ics", base, token)
+}
+
+// loadFeedUser returns the user of the id parameter, or nil after writing an error response
+func loadFeedUser(c *fiber.Ctx) (*database.User, error) {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	return &user, nil
+}
+
+// GetCalendarFeedAPI returns whether a user has a calendar feed and when it was last used
+func GetCalendarFeedAPI(c *fiber.Ctx) error {
+	user, err := loadFeedUser(c)
+	if user == nil {
+		return err
+	}
+
+	feed, err := database.GetCalendarFeed(user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load calendar feed",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"active": feed != nil,
+		"feed":   feed,
+	})
+}
+
+// CreateCalendarFeedAPI issues a new calendar feed URL for a user. Any previous URL
+// stops working; the new one is only shown in this response.
+func CreateCalendarFeedAPI(c *fiber.Ctx) error {
+	user, err := loadFeedUser(c)
+	if user == nil {
+		return err
+	}
+
+	var before interface{}
+	if previous, err := database.GetCalendarFeed(user.ID); err == nil {
+		before = previous
+	}
+	feed, token, err := database.CreateCalendarFeed(user.ID)
+	if err != nil {
+		utils.LogError("Error creating calendar feed for user %d: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create calendar feed",
+		})
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityCalendarFeed, feed.ID, before, feed)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"feed": feed,
+		"url":  calendarFeedURL(c, token),
+	})
+}
+
+// RevokeCalendarFeedAPI revokes a user's calendar feed URL
+func RevokeCalendarFeedAPI(c *fiber.Ctx) error {
+	user, err := loadFeedUser(c)
+	if user == nil {
+		return err
+	}
+
+	feed, err := database.GetCalendarFeed(user.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User has no calendar feed",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load calendar feed",
+		})
+	}
+
+	if err := database.RevokeCalendarFeed(user.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke calendar feed",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityCalendarFeed, feed.ID, feed, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Calendar feed revoked",
+	})
+}
+
+// sessionEvent converts a time entry into a calendar event. Running sessions end now.
+func sessionEvent(entry database.TimeEntry, now time.Time) utils.ICSEvent {
+	summary := entry.StatusText
+	if summary == "" {
+		summary = entry.Status
+	}
+	if entry.StatusEmoji != "" {
+		summary = utils.SlackEmoji(entry.StatusEmoji) + " " + summary
+	}
+
+	end := now
+	if entry.EndTime != nil {
+		end = *entry.EndTime
+	} else {
+		summary += " (ongoing)"
+	}
+
+	description := fmt.Sprintf("Category: %s\nSource: %s", entry.Status, entry.Source)
+	if entry.Note != "" {
+		description += "\nNote: " + entry.Note
+	}
+
+	return utils.ICSEvent{
+		UID:         fmt.Sprintf("time-entry-%d@time-tracker", entry.ID),
+		Summary:     summary,
+		Description: description,
+		Start:       entry.StartTime,
+		End:         end,
+	}
+}
+
+// CalendarFeedICS serves the iCalendar feed of the user a token belongs to. It is
+// public; the token in the URL is the only credential.
+func CalendarFeedICS(c *fiber.Ctx) error {
+	now := time.Now()
+	feed, err := database.UseCalendarFeed(c.Params("token"), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).SendString("Calendar feed not found")
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load calendar feed")
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, feed.UserID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).SendString("Calendar feed not found")
+	}
+
+	days := 90
+	if config.AppConfig != nil && config.AppConfig.CalendarFeedDays > 0 {
+		days = config.AppConfig.CalendarFeedDays
+	}
+	entries, err := database.GetUserTimeEntries(user.ID, now.AddDate(0, 0, -days), now.AddDate(0, 0, 1))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to load time entries")
+	}
+
+	events := make([]utils.ICSEvent, 0, len(entries))
+	for _, entry := range entries {
+		events = append(events, sessionEvent(entry, now))
+	}
+
+	var buf bytes.Buffer
+	if err := utils.WriteICS(&buf, "Worked sessions - "+userDisplayName(user), events); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Failed to write calendar")
+	}
+
+	c.Set("Content-Type", "text/calendar; charset=utf-8")
+	c.Set("Content-Disposition", "inline; filename=sessions.ics")
+	c.Set("Cache-Control", "private, max-age=300")
+	return c.Send(buf.Bytes())
+}
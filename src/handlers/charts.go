@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+)
+
+// GetActivityHeatmapAPI returns tracked hours by weekday and hour of day for the
+// from, to, users and teams parameters of /api/reports
+func GetActivityHeatmapAPI(c *fiber.Ctx) error {
+	query, err := parseReportQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	heatmap, err := database.GetActivityHeatmap(query)
+	if err != nil {
+		return reportErrorResponse(c, err, "Failed to build heatmap")
+	}
+
+	return c.JSON(heatmap)
+}
+
+// GetTimeSeriesAPI returns tracked hours per day or week (interval) in total or per
+// user or team (by), for the from, to, users and teams parameters of /api/reports
+func GetTimeSeriesAPI(c *fiber.Ctx) error {
+	query, err := parseReportQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	series, err := database.GetTimeSeries(query, c.Query("interval"), c.Query("by"))
+	if err != nil {
+		return reportErrorResponse(c, err, "Failed to build time series")
+	}
+
+	return c.JSON(series)
+}
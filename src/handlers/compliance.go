@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// complianceWindowDays is how far back compliance is re-evaluated after rule changes
+const complianceWindowDays = 30
+
+// ComplianceEvaluateRequest is the request body for re-evaluating compliance
+type ComplianceEvaluateRequest struct {
+	UserID uint   `json:"user_id"` // Omit to evaluate all active users
+	From   string `json:"from"`    // YYYY-MM-DD, defaults to 30 days ago
+	To     string `json:"to"`      // YYYY-MM-DD inclusive, defaults to today
+}
+
+// ComplianceRuleSetRequest is the request body for creating or editing a rule set
+type ComplianceRuleSetRequest struct {
+	Name            *string  `json:"name"`
+	Country         *string  `json:"country"`
+	Team            *string  `json:"team"`
+	MaxDailyHours   *float64 `json:"max_daily_hours"`
+	MinRestHours    *float64 `json:"min_rest_hours"`
+	BreakAfterHours *float64 `json:"break_after_hours"`
+	MinBreakMinutes *int     `json:"min_break_minutes"`
+	WarnBeforeMins  *int     `json:"warn_before_minutes"`
+}
+
+// apply copies the provided fields of the request onto a rule set
+func (req ComplianceRuleSetRequest) apply(rules *database.ComplianceRuleSet) {
+	if req.Name != nil {
+		rules.Name = *req.Name
+	}
+	if req.Country != nil {
+		rules.Country = *req.Country
+	}
+	if req.Team != nil {
+		rules.Team = *req.Team
+	}
+	if req.MaxDailyHours != nil {
+		rules.MaxDailyHours = *req.MaxDailyHours
+	}
+	if req.MinRestHours != nil {
+		rules.MinRestHours = *req.MinRestHours
+	}
+	if req.BreakAfterHours != nil {
+		rules.BreakAfterHours = *req.BreakAfterHours
+	}
+	if req.MinBreakMinutes != nil {
+		rules.MinBreakMinutes = *req.MinBreakMinutes
+	}
+	if req.WarnBeforeMins != nil {
+		rules.WarnBeforeMins = *req.WarnBeforeMins
+	}
+}
+
+// ruleSetErrorResponse maps rule set validation errors to an HTTP response
+func ruleSetErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, database.ErrInvalidRuleSet) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// reevaluateRecentCompliance re-checks the recent history of all users after a rule change
+func reevaluateRecentCompliance() {
+	today := time.Now()
+	if _, err := database.EvaluateCompliance(nil, today.AddDate(0, 0, -complianceWindowDays), today.AddDate(0, 0, 1)); err != nil {
+		utils.LogError("Error re-evaluating compliance: %v", err)
+	}
+}
+
+// loadRuleSet loads the rule set referenced by the id route parameter
+func loadRuleSet(c *fiber.Ctx) (*database.ComplianceRuleSet, error) {
+	ruleSetID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule set ID",
+		})
+	}
+
+	rules, err := database.GetComplianceRuleSet(ruleSetID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Rule set not found",
+		})
+	}
+
+	return rules, nil
+}
+
+// GetComplianceViolationsAPI lists violations filtered by user_id, rule, from and to
+func GetComplianceViolationsAPI(c *fiber.Ctx) error {
+	filter := database.ComplianceFilter{
+		UserID: uint(c.QueryInt("user_id", 0)),
+		Rule:   c.Query("rule"),
+	}
+
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from format. Use YYYY-MM-DD",
+			})
+		}
+		filter.From = &fromDate
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid to format. Use YYYY-MM-DD",
+			})
+		}
+		toDate = toDate.AddDate(0, 0, 1) // Include the whole last day
+		filter.To = &toDate
+	}
+
+	violations, err := database.GetComplianceViolations(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load violations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"violations": violations,
+	})
+}
+
+// EvaluateComplianceAPI re-evaluates time entry history against the rule sets
+func EvaluateComplianceAPI(c *fiber.Ctx) error {
+	var req ComplianceEvaluateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	today := time.Now()
+	from := today.AddDate(0, 0, -complianceWindowDays)
+	to := today
+	var err error
+	if req.From != "" {
+		if from, err = time.ParseInLocation("2006-01-02", req.From, time.Local); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from format. Use YYYY-MM-DD",
+			})
+		}
+	}
+	if req.To != "" {
+		if to, err = time.ParseInLocation("2006-01-02", req.To, time.Local); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid to format. Use YYYY-MM-DD",
+			})
+		}
+	}
+
+	var userIDs []uint
+	if req.UserID != 0 {
+		userIDs = []uint{req.UserID}
+	}
+
+	count, err := database.EvaluateCompliance(userIDs, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidTimeRange) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "to must not be before from",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to evaluate compliance",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"violations": count,
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+	})
+}
+
+// GetComplianceRuleSetsAPI lists stored rule sets and the configured defaults
+func GetComplianceRuleSetsAPI(c *fiber.Ctx) error {
+	ruleSets, err := database.GetComplianceRuleSets()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load rule sets",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"rule_sets": ruleSets,
+		"default":   database.DefaultRuleSet(),
+	})
+}
+
+// CreateComplianceRuleSetAPI adds a rule set, starting from the configured defaults
+func CreateComplianceRuleSetAPI(c *fiber.Ctx) error {
+	var req ComplianceRuleSetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rules := database.DefaultRuleSet()
+	rules.Name = ""
+	req.apply(&rules)
+	if err := database.CreateComplianceRuleSet(&rules); err != nil {
+		return ruleSetErrorResponse(c, err, "Failed to create rule set")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityComplianceRules, rules.ID, nil, rules)
+	reevaluateRecentCompliance()
+
+	return c.Status(fiber.StatusCreated).JSON(rules)
+}
+
+// UpdateComplianceRuleSetAPI edits a rule set
+func UpdateComplianceRuleSetAPI(c *fiber.Ctx) error {
+	rules, err := loadRuleSet(c)
+	if rules == nil {
+		return err
+	}
+
+	var req ComplianceRuleSetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *rules
+	req.apply(rules)
+	if err := database.UpdateComplianceRuleSet(rules); err != nil {
+		return ruleSetErrorResponse(c, err, "Failed to update rule set")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityComplianceRules, rules.ID, before, rules)
+	reevaluateRecentCompliance()
+
+	return c.JSON(rules)
+}
+
+// DeleteComplianceRuleSetAPI removes a rule set
+func DeleteComplianceRuleSetAPI(c *fiber.Ctx) error {
+	rules, err := loadRuleSet(c)
+	if rules == nil {
+		return err
+	}
+
+	if err := database.DeleteComplianceRuleSet(rules.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete rule set",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityComplianceRules, rules.ID, rules, nil)
+	reevaluateRecentCompliance()
+
+	return c.JSON(fiber.Map{
+		"message": "Rule set deleted",
+	})
+}
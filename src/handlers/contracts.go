@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+)
+
+// ContractRequest is the request body for creating or editing a contract
+type ContractRequest struct {
+	ContractType  *string  `json:"contract_type"`
+	WeeklyHours   *float64 `json:"weekly_hours"`
+	WorkingDays   *string  `json:"working_days"`   // e.g. "mon,tue,wed,thu,fri"
+	EffectiveFrom *string  `json:"effective_from"` // YYYY-MM-DD
+	EffectiveTo   *string  `json:"effective_to"`   // YYYY-MM-DD, empty string for open-ended
+}
+
+// apply copies the provided fields of the request onto a contract
+func (req ContractRequest) apply(contract *database.Contract) error {
+	if req.ContractType != nil {
+		contract.ContractType = *req.ContractType
+	}
+	if req.WeeklyHours != nil {
+		contract.WeeklyHours = *req.WeeklyHours
+	}
+	if req.WorkingDays != nil {
+		contract.WorkingDays = *req.WorkingDays
+	}
+	if req.EffectiveFrom != nil {
+		effectiveFrom, err := time.ParseInLocation("2006-01-02", *req.EffectiveFrom, time.Local)
+		if err != nil {
+			return errors.New("invalid effective_from format. Use YYYY-MM-DD")
+		}
+		contract.EffectiveFrom = effectiveFrom
+	}
+	if req.EffectiveTo != nil {
+		contract.EffectiveTo = nil
+		if *req.EffectiveTo != "" {
+			effectiveTo, err := time.ParseInLocation("2006-01-02", *req.EffectiveTo, time.Local)
+			if err != nil {
+				return errors.New("invalid effective_to format. Use YYYY-MM-DD")
+			}
+			contract.EffectiveTo = &effectiveTo
+		}
+	}
+	return nil
+}
+
+// contractErrorResponse maps contract validation errors to an HTTP response
+func contractErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, database.ErrInvalidContract) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// loadUserContract loads a contract from the route parameters and checks it belongs to the user
+func loadUserContract(c *fiber.Ctx) (*database.Contract, error) {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	contractID, err := parseIDParam(c, "contractId")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid contract ID",
+		})
+	}
+
+	contract, err := database.GetContract(contractID)
+	if err != nil || contract.UserID != userID {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Contract not found",
+		})
+	}
+
+	return contract, nil
+}
+
+// GetUserContractsAPI lists a user's contracts together with the current weekly target
+func GetUserContractsAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	contracts, err := database.GetUserContracts(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load contracts",
+		})
+	}
+
+	weekStart := database.WeekStartOf(time.Now())
+	required, err := database.GetRequiredHours(userID, weekStart, weekStart.AddDate(0, 0, 7))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to calculate required hours",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"contracts":                   contracts,
+		"current_week_required_hours": required,
+	})
+}
+
+// CreateUserContractAPI adds a contract to a user
+func CreateUserContractAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	var req ContractRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.WeeklyHours == nil || req.EffectiveFrom == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "weekly_hours and effective_from are required",
+		})
+	}
+
+	contract := database.Contract{
+		UserID:      user.ID,
+		WorkingDays: "mon,tue,wed,thu,fri",
+	}
+	if err := req.apply(&contract); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := database.CreateContract(&contract); err != nil {
+		return contractErrorResponse(c, err, "Failed to create contract")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityContract, contract.ID, nil, contract)
+	broadcastUserChange(user.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(contract)
+}
+
+// UpdateUserContractAPI edits a user's contract
+func UpdateUserContractAPI(c *fiber.Ctx) error {
+	contract, err := loadUserContract(c)
+	if contract == nil {
+		return err
+	}
+
+	var req ContractRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *contract
+	if err := req.apply(contract); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := database.UpdateContract(contract); err != nil {
+		return contractErrorResponse(c, err, "Failed to update contract")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityContract, contract.ID, before, contract)
+	broadcastUserChange(contract.UserID)
+
+	return c.JSON(contract)
+}
+
+// DeleteUserContractAPI removes a user's contract
+func DeleteUserContractAPI(c *fiber.Ctx) error {
+	contract, err := loadUserContract(c)
+	if contract == nil {
+		return err
+	}
+
+	if err := database.DeleteContract(contract.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete contract",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityContract, contract.ID, contract, nil)
+	broadcastUserChange(contract.UserID)
+
+	return c.JSON(fiber.Map{
+		"message": "Contract deleted",
+	})
+}
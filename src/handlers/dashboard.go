@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -34,9 +32,10 @@ func ShowDashboard(c *fiber.Ctx) error {
 	})
 }
 
-// GetUsersAPI returns user data as JSON for API calls
+// GetUsersAPI returns user data as JSON for API calls, optionally limited to the
+// members of the comma-separated teams parameter
 func GetUsersAPI(c *fiber.Ctx) error {
-	summaries, err := database.GetUserSummaries()
+	summaries, err := teamSummaries(c)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to load user data",
@@ -48,9 +47,10 @@ func GetUsersAPI(c *fiber.Ctx) error {
 	})
 }
 
-// GetAnalyticsAPI returns analytics data as JSON
+// GetAnalyticsAPI returns analytics data as JSON, optionally for the members of the
+// comma-separated teams parameter
 func GetAnalyticsAPI(c *fiber.Ctx) error {
-	summaries, err := database.GetUserSummaries()
+	summaries, err := teamSummaries(c)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to load analytics data",
@@ -64,27 +64,12 @@ func GetAnalyticsAPI(c *fiber.Ctx) error {
 
 // GetWeeklyReports returns weekly time tracking reports
 func GetWeeklyReports(c *fiber.Ctx) error {
-	// Parse week parameter (optional)
-	weekParam := c.Query("week")
-	var weekStart time.Time
-	var err error
-
-	if weekParam != "" {
-		weekStart, err = time.Parse("2006-01-02", weekParam)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid week format. Use YYYY-MM-DD",
-			})
-		}
-	} else {
-		// Default to current week (Monday)
-		now := time.Now()
-		weekStart = now.AddDate(0, 0, -int(now.Weekday())+1)
-	}
-
-	// Ensure weekStart is Monday
-	for weekStart.Weekday() != time.Monday {
-		weekStart = weekStart.AddDate(0, 0, -1)
+	// Monday of the week parameter (optional), or of the current week
+	weekStart, err := exportWeekStart(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid week format. Use YYYY-MM-DD",
+		})
 	}
 
 	reports, err := database.GetWeeklyReports(weekStart)
@@ -98,130 +83,62 @@ func GetWeeklyReports(c *fiber.Ctx) error {
 		"reports":    reports,
 		"week_start": weekStart.Format("2006-01-02"),
 		"week_end":   weekStart.AddDate(0, 0, 6).Format("2006-01-02"),
+		"rounding":   database.ConfiguredRounding(),
 	})
 }
 
-// ExportExcel generates Excel export for user data
+// ExportExcel exports user summaries, weekly reports or a grouped date-range report
+// (type=report, see GetReportAPI) as an .xlsx workbook, or as CSV with format=csv
 func ExportExcel(c *fiber.Ctx) error {
 	reportType := c.Query("type", "users")
+	format := c.Query("format", "xlsx")
 
-	switch reportType {
-	case "users":
-		return exportUsersExcel(c)
-	case "weekly":
-		return exportWeeklyExcel(c)
-	default:
+	if format != "xlsx" && format != "csv" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid report type",
+			"error": "Invalid format. Use xlsx or csv",
 		})
 	}
-}
 
-// exportUsersExcel exports user summaries to Excel
-func exportUsersExcel(c *fiber.Ctx) error {
-	summaries, err := database.GetUserSummaries()
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to load user data",
-		})
-	}
-
-	// Create CSV content (simplified Excel export)
-	csvContent := "Name,Email,Total Working Time (hours),Weekly Hours,Monthly Hours,Last Activity,Currently Working\n"
-
-	for _, summary := range summaries {
-		totalHours := float64(summary.TotalWorkingTime) / 3600.0
-		workingStatus := "No"
-		if summary.IsCurrentlyWorking {
-			workingStatus = "Yes"
+	switch reportType {
+	case "users":
+		if format == "xlsx" {
+			now := time.Now()
+			monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+			return exportWorkbook(c, "users_report", "User report", monthStart, now.AddDate(0, 0, 1))
 		}
-
-		csvContent += fmt.Sprintf("%s,%s,%.2f,%.2f,%.2f,%s,%s\n",
-			summary.Name,
-			summary.Email,
-			totalHours,
-			summary.WeeklyHours,
-			summary.MonthlyHours,
-			summary.LastActivity.Format("2006-01-02 15:04:05"),
-			workingStatus,
-		)
-	}
-
-	// Set proper headers for file download
-	c.Set("Content-Type", "application/octet-stream")
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=users_report_%s.csv", time.Now().Format("2006-01-02")))
-	c.Set("Content-Length", fmt.Sprintf("%d", len(csvContent)))
-
-	return c.SendString(csvContent)
-}
-
-// exportWeeklyExcel exports weekly reports to Excel
-func exportWeeklyExcel(c *fiber.Ctx) error {
-	weekParam := c.Query("week")
-	var weekStart time.Time
-	var err error
-
-	if weekParam != "" {
-		weekStart, err = time.Parse("2006-01-02", weekParam)
+		return sendExport(c, usersExporter, ExportParams{})
+	case "weekly":
+		weekStart, err := exportWeekStart(c)
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"error": "Invalid week format",
 			})
 		}
-	} else {
-		now := time.Now()
-		weekStart = now.AddDate(0, 0, -int(now.Weekday())+1)
-	}
-
-	reports, err := database.GetWeeklyReports(weekStart)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to load weekly reports",
+		if format == "xlsx" {
+			return exportWorkbook(c, "weekly_report", "Weekly report", weekStart, weekStart.AddDate(0, 0, 7))
+		}
+		return sendExport(c, weeklyExporter, ExportParams{WeekStart: weekStart})
+	case "report":
+		return exportReport(c, format)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid report type",
 		})
 	}
-
-	// Create CSV content
-	csvContent := "Name,Email,Week Start,Week End,Total Hours,Required Hours,Completion Rate (%)\n"
-
-	for _, report := range reports {
-		csvContent += fmt.Sprintf("%s,%s,%s,%s,%.2f,%.2f,%.2f\n",
-			report.Name,
-			report.Email,
-			report.WeekStart.Format("2006-01-02"),
-			report.WeekEnd.Format("2006-01-02"),
-			report.TotalHours,
-			report.RequiredHours,
-			report.CompletionRate,
-		)
-	}
-
-	// Set proper headers for file download
-	c.Set("Content-Type", "application/octet-stream")
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=weekly_report_%s.csv", weekStart.Format("2006-01-02")))
-	c.Set("Content-Length", fmt.Sprintf("%d", len(csvContent)))
-
-	return c.SendString(csvContent)
 }
 
-// GetUserDetails returns detailed information about a specific user
-func GetUserDetails(c *fiber.Ctx) error {
-	userIDStr := c.Params("id")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID",
-		})
+// exportWeekStart returns the Monday of the week query parameter, or of the current week
+func exportWeekStart(c *fiber.Ctx) (time.Time, error) {
+	weekParam := c.Query("week")
+	if weekParam == "" {
+		return database.WeekStartOf(time.Now()), nil
 	}
 
-	var user database.User
-	result := database.DB.Preload("TimeEntries").First(&user, uint(userID))
-	if result.Error != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "User not found",
-		})
+	weekStart, err := time.ParseInLocation("2006-01-02", weekParam, time.Local)
+	if err != nil {
+		return time.Time{}, err
 	}
-
-	return c.JSON(user)
+	return database.WeekStartOf(weekStart), nil
 }
 
 // SyncSlackUsers manually syncs users from Slack
@@ -245,6 +162,8 @@ func calculateAnalytics(summaries []database.UserSummary) map[string]interface{}
 	totalWeeklyHours := 0.0
 	totalMonthlyHours := 0.0
 	totalWorkingTime := int64(0)
+	weeklyTarget := 0.0
+	monthlyTarget := 0.0
 
 	for _, summary := range summaries {
 		if summary.IsCurrentlyWorking {
@@ -253,6 +172,8 @@ func calculateAnalytics(summaries []database.UserSummary) map[string]interface{}
 		totalWeeklyHours += summary.WeeklyHours
 		totalMonthlyHours += summary.MonthlyHours
 		totalWorkingTime += summary.TotalWorkingTime
+		weeklyTarget += summary.WeeklyTarget
+		monthlyTarget += summary.MonthlyTarget
 	}
 
 	avgWeeklyHours := 0.0
@@ -262,10 +183,7 @@ func calculateAnalytics(summaries []database.UserSummary) map[string]interface{}
 		avgMonthlyHours = totalMonthlyHours / float64(totalUsers)
 	}
 
-	// Calculate completion rates (based on 20 hours per week requirement)
-	weeklyTarget := 20.0 * float64(totalUsers)
-	monthlyTarget := 80.0 * float64(totalUsers) // 4 weeks
-
+	// Calculate completion rates against the users' contracted hours
 	weeklyCompletion := 0.0
 	monthlyCompletion := 0.0
 
@@ -286,6 +204,8 @@ func calculateAnalytics(summaries []database.UserSummary) map[string]interface{}
 		"total_working_time":  float64(totalWorkingTime) / 3600.0, // Convert to hours
 		"weekly_completion":   weeklyCompletion,
 		"monthly_completion":  monthlyCompletion,
+		"weekly_target":       weeklyTarget,
+		"monthly_target":      monthlyTarget,
 	}
 }
 
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+)
+
+func TestGetWeeklyReportsCountsMonday(t *testing.T) {
+	useLocation(t, "Europe/Berlin")
+	thisMonday := database.WeekStartOf(time.Now())
+
+	tests := []struct {
+		name   string
+		query  string
+		monday time.Time
+	}{
+		{name: "week parameter", query: "?week=2026-03-04", monday: time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)},
+		{name: "current week", query: "", monday: thisMonday},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			user := createTestUser(t, "U1")
+			createClosedEntry(t, user.ID, tt.monday.Add(9*time.Hour), tt.monday.Add(17*time.Hour))
+
+			app := fiber.New()
+			app.Get("/api/reports/weekly", GetWeeklyReports)
+			resp, err := app.Test(httptest.NewRequest("GET", "/api/reports/weekly"+tt.query, nil))
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			if resp.StatusCode != fiber.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+
+			var body struct {
+				Reports   []database.WeeklyReport `json:"reports"`
+				WeekStart string                  `json:"week_start"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if body.WeekStart != tt.monday.Format("2006-01-02") {
+				t.Errorf("week_start = %s, want %s", body.WeekStart, tt.monday.Format("2006-01-02"))
+			}
+			if len(body.Reports) != 1 {
+				t.Fatalf("got %d reports, want 1", len(body.Reports))
+			}
+			report := body.Reports[0]
+			for _, check := range []struct {
+				field     string
+				got, want float64
+			}{
+				{"total hours", report.TotalHours, 8},
+				{"required hours", report.RequiredHours, 20},
+				{"break hours", report.BreakHours, 0.5},
+				{"net hours", report.NetHours, 7.5},
+				{"rounded hours", report.RoundedHours, 8},
+				{"rounded net hours", report.RoundedNet, 7.5},
+			} {
+				if math.Abs(check.got-check.want) > 0.01 {
+					t.Errorf("%s = %.2f, want %.2f", check.field, check.got, check.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// Export column types, which select how a value is formatted
+const (
+	ExportText   = "text"   // Written as is
+	ExportDate   = "date"   // time.Time in the exporter's date format
+	ExportHours  = "hours"  // float64 hours, rounded and with the exporter's decimals
+	ExportNumber = "number" // int, or float64 with the exporter's decimals
+)
+
+// Export file encodings
+const (
+	ExportUTF8        = "utf-8"
+	ExportUTF8BOM     = "utf-8-bom" // UTF-8 with byte order mark, which Excel needs to detect UTF-8
+	ExportWindows1252 = "windows-1252"
+)
+
+// ExportParams are the query parameters of an export
+type ExportParams struct {
+	From      time.Time // First day of the period
+	To        time.Time // Day after the last day of the period
+	WeekStart time.Time // Monday of the selected week
+
+	Query database.ReportQuery // Users and teams filters of the period, over From and To
+}
+
+// ExportColumn is one column of an export format
+type ExportColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // text, date, hours or number
+}
+
+// Exporter is a file format of /api/export. Rows returns one value per column for
+// every line; the exporter's settings decide how they are written.
+type Exporter struct {
+	Name         string         `json:"name"` // Value of the format query parameter
+	Description  string         `json:"description"`
+	Extension    string         `json:"extension"`
+	Delimiter    string         `json:"delimiter"`     // Single field separator character
+	Encoding     string         `json:"encoding"`      // utf-8, utf-8-bom or windows-1252
+	Header       bool           `json:"header"`        // Write the column names as the first line
+	Decimals     int            `json:"decimals"`      // Decimal places of hours and numbers
+	DecimalComma bool           `json:"decimal_comma"` // Write "1,50" instead of "1.50"
+	RoundTo      float64        `json:"round_to"`      // Round hours to a multiple of this, e.g. 0.25; 0 keeps them
+	DateFormat   string         `json:"date_format"`   // Go layout of date columns
+	Columns      []ExportColumn `json:"columns"`
+
+	Filename func(params ExportParams) string                   `json:"-"` // File name without extension
+	Rows     func(params ExportParams) ([][]interface{}, error) `json:"-"`
+}
+
+// exporters are the registered export formats in registration order
+var exporters = []*Exporter{
+	usersExporter,
+	weeklyExporter,
+	payrollExporter,
+	datevExporter,
+}
+
+// validate checks that an export format can be rendered
+func (e *Exporter) validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("export format has no name")
+	}
+	if e.Rows == nil || e.Filename == nil {
+		return fmt.Errorf("export format %s needs Rows and Filename", e.Name)
+	}
+	if delimiter := []rune(e.Delimiter); len(delimiter) != 1 || delimiter[0] == '"' || delimiter[0] == '\r' ||
+		delimiter[0] == '\n' || delimiter[0] == utf8.RuneError {
+		return fmt.Errorf("export format %s needs a single delimiter character, got %q", e.Name, e.Delimiter)
+	}
+	switch e.Encoding {
+	case ExportUTF8, ExportUTF8BOM, ExportWindows1252:
+	default:
+		return fmt.Errorf("export format %s has unknown encoding %q", e.Name, e.Encoding)
+	}
+	for _, column := range e.Columns {
+		switch column.Type {
+		case ExportText, ExportDate, ExportHours, ExportNumber:
+		default:
+			return fmt.Errorf("export format %s has column %q of unknown type %q", e.Name, column.Name, column.Type)
+		}
+	}
+	return nil
+}
+
+// RegisterExporter adds an export format, replacing a registered format of the same
+// name. Formats that cannot be rendered are refused.
+func RegisterExporter(exporter *Exporter) error {
+	if err := exporter.validate(); err != nil {
+		return err
+	}
+	for i, registered := range exporters {
+		if registered.Name == exporter.Name {
+			exporters[i] = exporter
+			return nil
+		}
+	}
+	exporters = append(exporters, exporter)
+	return nil
+}
+
+// findExporter returns the registered export format with the given name, or nil
+func findExporter(name string) *Exporter {
+	for _, exporter := range exporters {
+		if exporter.Name == name {
+			return exporter
+		}
+	}
+	return nil
+}
+
+// ContentType returns the MIME type of the exporter's files
+func (e *Exporter) ContentType() string {
+	if e.Encoding == ExportWindows1252 {
+		return "text/csv; charset=windows-1252"
+	}
+	return csvContentType
+}
+
+// formatValue formats a row value for a column
+func (e *Exporter) formatValue(column ExportColumn, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(e.DateFormat)
+	case int:
+		return strconv.Itoa(v)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case float64:
+		if column.Type == ExportHours && e.RoundTo > 0 {
+			v = math.Round(v/e.RoundTo) * e.RoundTo
+		}
+		text := strconv.FormatFloat(v, 'f', e.Decimals, 64)
+		if e.DecimalComma {
+			text = strings.Replace(text, ".", ",", 1)
+		}
+		return text
+	}
+	return fmt.Sprint(value)
+}
+
+// Render generates the export file for params
+func (e *Exporter) Render(params ExportParams) ([]byte, error) {
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	rows, err := e.Rows(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var records [][]string
+	if e.Header {
+		header := make([]string, len(e.Columns))
+		for i, column := range e.Columns {
+			header[i] = column.Name
+		}
+		records = append(records, header)
+	}
+	for _, row := range rows {
+		record := make([]string, len(e.Columns))
+		for i, column := range e.Columns {
+			if i < len(row) {
+				record[i] = e.formatValue(column, row[i])
+			}
+		}
+		records = append(records, record)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = []rune(e.Delimiter)[0]
+	writer.UseCRLF = true
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	switch e.Encoding {
+	case ExportWindows1252:
+		return utils.EncodeWindows1252(buf.String()), nil
+	case ExportUTF8BOM:
+		return append([]byte("\xef\xbb\xbf"), buf.Bytes()...), nil
+	}
+	return buf.Bytes(), nil
+}
+
+// parseExportParams reads month (YYYY-MM) or from and to (inclusive, default: the
+// current month up to today), week (default: the current week) and the users and
+// teams filters of the report query
+func parseExportParams(c *fiber.Ctx) (ExportParams, error) {
+	query, err := parseReportQuery(c)
+	if err != nil {
+		return ExportParams{}, err
+	}
+	params := ExportParams{From: query.From, To: query.To, Query: query}
+
+	if monthParam := c.Query("month"); monthParam != "" {
+		month, err := time.ParseInLocation("2006-01", monthParam, time.Local)
+		if err != nil {
+			return params, fmt.Errorf("invalid month format. Use YYYY-MM")
+		}
+		params.From, params.To = month, month.AddDate(0, 1, 0)
+		// from and to still override the month
+		if c.Query("from") != "" {
+			params.From = query.From
+		}
+		if c.Query("to") != "" {
+			params.To = query.To
+		}
+	}
+	params.Query.From, params.Query.To = params.From, params.To
+
+	weekStart, err := exportWeekStart(c)
+	if err != nil {
+		return params, fmt.Errorf("invalid week format. Use YYYY-MM-DD")
+	}
+	params.WeekStart = weekStart
+
+	return params, nil
+}
+
+// sendExport sends the file of an export format as a download
+func sendExport(c *fiber.Ctx, exporter *Exporter, params ExportParams) error {
+	data, err := exporter.Render(params)
+	if err != nil {
+		utils.LogError("Error rendering %s export: %v", exporter.Name, err)
+		return reportErrorResponse(c, err, "Failed to generate export")
+	}
+
+	c.Set("Content-Type", exporter.ContentType())
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.%s", exporter.Filename(params), exporter.Extension))
+	return c.Send(data)
+}
+
+// ExportAPI downloads a file in a registered export format (format, default users)
+func ExportAPI(c *fiber.Ctx) error {
+	exporter := findExporter(c.Query("format", "users"))
+	if exporter == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown export format",
+		})
+	}
+
+	params, err := parseExportParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return sendExport(c, exporter, params)
+}
+
+// GetExportFormatsAPI lists the registered export formats with their columns and settings
+func GetExportFormatsAPI(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"formats": exporters,
+	})
+}
+
+// usersExporter writes the current user summaries
+var usersExporter = &Exporter{
+	Name:        "users",
+	Description: "User summaries with weekly and monthly hours",
+	Extension:   "csv",
+	Delimiter:   ",",
+	Encoding:    ExportUTF8,
+	Header:      true,
+	Decimals:    2,
+	DateFormat:  "2006-01-02 15:04:05",
+	Columns: []ExportColumn{
+		{"Name", ExportText}, {"Email", ExportText}, {"Total Working Time (hours)", ExportHours},
+		{"Weekly Hours", ExportHours}, {"Weekly Break Deduction", ExportHours},
+		{"Weekly Net Hours", ExportHours}, {"Weekly Rounded Hours", ExportHours},
+		{"Monthly Hours", ExportHours}, {"Monthly Break Deduction", ExportHours},
+		{"Monthly Net Hours", ExportHours}, {"Monthly Rounded Hours", ExportHours},
+		{"Last Activity", ExportDate}, {"Currently Working", ExportText},
+	},
+	Filename: func(params ExportParams) string {
+		return fmt.Sprintf("users_report_%s", time.Now().Format("2006-01-02"))
+	},
+	Rows: func(params ExportParams) ([][]interface{}, error) {
+		summaries, err := database.GetUserSummaries()
+		if err != nil {
+			return nil, err
+		}
+
+		var rows [][]interface{}
+		for _, summary := range summaries {
+			workingStatus := "No"
+			if summary.IsCurrentlyWorking {
+				workingStatus = "Yes"
+			}
+			rows = append(rows, []interface{}{
+				summary.Name, summary.Email, float64(summary.TotalWorkingTime) / 3600.0,
+				summary.WeeklyHours, summary.WeeklyBreakDeduction, summary.WeeklyNetHours, summary.WeeklyRoundedHours,
+				summary.MonthlyHours, summary.MonthlyBreakDeduction, summary.MonthlyNetHours, summary.MonthlyRoundedHours,
+				summary.LastActivity, workingStatus,
+			})
+		}
+		return rows, nil
+	},
+}
+
+// weeklyExporter writes the weekly reports of the selected week
+var weeklyExporter = &Exporter{
+	Name:        "weekly",
+	Description: "Weekly hours, completion and flextime balance per user",
+	Extension:   "csv",
+	Delimiter:   ",",
+	Encoding:    ExportUTF8,
+	Header:      true,
+	Decimals:    2,
+	DateFormat:  "2006-01-02",
+	Columns: []ExportColumn{
+		{"Name", ExportText}, {"Email", ExportText}, {"Week Start", ExportDate}, {"Week End", ExportDate},
+		{"Gross Hours", ExportHours}, {"Break Deduction", ExportHours}, {"Net Hours", ExportHours},
+		{"Rounded Hours", ExportHours}, {"Rounded Net Hours", ExportHours}, {"Required Hours", ExportHours},
+		{"Completion Rate (%)", ExportNumber}, {"Week Balance", ExportHours}, {"Overtime Balance", ExportHours},
+	},
+	Filename: func(params ExportParams) string {
+		return fmt.Sprintf("weekly_report_%s", params.WeekStart.Format("2006-01-02"))
+	},
+	Rows: func(params ExportParams) ([][]interface{}, error) {
+		reports, err := database.GetWeeklyReports(params.WeekStart)
+		if err != nil {
+			return nil, err
+		}
+
+		var rows [][]interface{}
+		for _, report := range reports {
+			// Flextime balance at the end of the reported week
+			weekBalance, overtimeBalance := 0.0, 0.0
+			balance, err := database.GetUserBalance(report.UserID, params.WeekStart.AddDate(0, 0, 6))
+			if err != nil {
+				utils.LogError("Error calculating balance for user %d: %v", report.UserID, err)
+			} else if len(balance.Weeks) > 0 {
+				last := balance.Weeks[len(balance.Weeks)-1]
+				weekBalance = last.WorkedHours - last.RequiredHours + last.Adjustments - last.Payouts
+				overtimeBalance = balance.Balance
+			}
+
+			rows = append(rows, []interface{}{
+				report.Name, report.Email, report.WeekStart, report.WeekEnd,
+				report.TotalHours, report.BreakHours, report.NetHours, report.RoundedHours, report.RoundedNet,
+				report.RequiredHours, report.CompletionRate, weekBalance, overtimeBalance,
+			})
+		}
+		return rows, nil
+	},
+}
+
+// payrollPeriod sums the daily hours of one user in an export period
+type payrollPeriod struct {
+	personnelNo, name, email string
+	days                     int
+	net, rounded, required   float64
+}
+
+// payrollPeriods sums the daily hours of every active user selected by the export's
+// users and teams filters in the export period
+func payrollPeriods(params ExportParams) ([]*payrollPeriod, error) {
+	days, err := database.GetDailyHoursForQuery(params.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var periods []*payrollPeriod
+	byUser := make(map[uint]*payrollPeriod)
+	for _, day := range days {
+		period, ok := byUser[day.UserID]
+		if !ok {
+			period = &payrollPeriod{personnelNo: day.PersonnelNo, name: day.Name, email: day.Email}
+			if period.personnelNo == "" {
+				period.personnelNo = strconv.FormatUint(uint64(day.UserID), 10)
+			}
+			byUser[day.UserID] = period
+			periods = append(periods, period)
+		}
+		if day.Entries > 0 {
+			period.days++
+		}
+		period.net += day.NetHours
+		period.rounded += day.RoundedHours
+		period.required += day.RequiredHours
+	}
+	return periods, nil
+}
+
+// payrollExporter writes one line per user with the hours of the period for payroll
+// providers importing fixed-column CSV files
+var payrollExporter = &Exporter{
+	Name:        "payroll",
+	Description: "Hours per employee for payroll imports, rounded to quarter hours",
+	Extension:   "csv",
+	Delimiter:   ";",
+	Encoding:    ExportUTF8,
+	Header:      true,
+	Decimals:    2,
+	RoundTo:     0.25,
+	DateFormat:  "2006-01-02",
+	Columns: []ExportColumn{
+		{"Personnel Number", ExportText}, {"Name", ExportText}, {"Email", ExportText},
+		{"Period Start", ExportDate}, {"Period End", ExportDate}, {"Days Worked", ExportNumber},
+		{"Worked Hours", ExportHours}, {"Rounded Hours", ExportHours}, {"Required Hours", ExportHours},
+		{"Overtime Hours", ExportHours},
+	},
+	Filename: func(params ExportParams) string {
+		return fmt.Sprintf("payroll_%s_%s", params.From.Format("2006-01-02"), params.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	},
+	Rows: func(params ExportParams) ([][]interface{}, error) {
+		periods, err := payrollPeriods(params)
+		if err != nil {
+			return nil, err
+		}
+
+		var rows [][]interface{}
+		for _, period := range periods {
+			rows = append(rows, []interface{}{
+				period.personnelNo, period.name, period.email,
+				params.From, params.To.AddDate(0, 0, -1), period.days,
+				period.net, period.rounded, period.required, math.Max(period.net-period.required, 0),
+			})
+		}
+		return rows, nil
+	},
+}
+
+// datevExporter writes one line per user and day in the style of DATEV payroll imports:
+// semicolon-separated Windows-1252 with German dates and decimal commas
+var datevExporter = &Exporter{
+	Name:         "datev",
+	Description:  "DATEV-style daily hours per employee",
+	Extension:    "csv",
+	Delimiter:    ";",
+	Encoding:     ExportWindows1252,
+	Header:       true,
+	Decimals:     2,
+	DecimalComma: true,
+	DateFormat:   "02.01.2006",
+	Columns: []ExportColumn{
+		{"Personalnummer", ExportText}, {"Name", ExportText}, {"Datum", ExportDate},
+		{"Stunden", ExportHours}, {"Sollstunden", ExportHours},
+	},
+	Filename: func(params ExportParams) string {
+		return fmt.Sprintf("datev_%s_%s", params.From.Format("2006-01-02"), params.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	},
+	Rows: func(params ExportParams) ([][]interface{}, error) {
+		days, err := database.GetDailyHoursForQuery(params.Query)
+		if err != nil {
+			return nil, err
+		}
+
+		var rows [][]interface{}
+		for _, day := range days {
+			personnelNo := day.PersonnelNo
+			if personnelNo == "" {
+				personnelNo = strconv.FormatUint(uint64(day.UserID), 10)
+			}
+			rows = append(rows, []interface{}{personnelNo, day.Name, day.Date, day.NetHours, day.RequiredHours})
+		}
+		return rows, nil
+	},
+}
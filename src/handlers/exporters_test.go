@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+)
+
+func TestBuiltInExportersAreValid(t *testing.T) {
+	for _, exporter := range exporters {
+		if err := exporter.validate(); err != nil {
+			t.Errorf("%s: %v", exporter.Name, err)
+		}
+	}
+}
+
+func TestRegisterExporter(t *testing.T) {
+	rows := func(ExportParams) ([][]interface{}, error) { return [][]interface{}{{"a", 1.5}}, nil }
+	filename := func(ExportParams) string { return "test" }
+	valid := func() *Exporter {
+		return &Exporter{Name: "test", Extension: "csv", Delimiter: ";", Encoding: ExportUTF8, Decimals: 2,
+			Columns: []ExportColumn{{"Name", ExportText}, {"Hours", ExportHours}}, Rows: rows, Filename: filename}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(e *Exporter)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(e *Exporter) {}},
+		{name: "tab delimiter", modify: func(e *Exporter) { e.Delimiter = "\t" }},
+		{name: "empty delimiter", modify: func(e *Exporter) { e.Delimiter = "" }, wantErr: true},
+		{name: "two character delimiter", modify: func(e *Exporter) { e.Delimiter = ";;" }, wantErr: true},
+		{name: "quote delimiter", modify: func(e *Exporter) { e.Delimiter = `"` }, wantErr: true},
+		{name: "unknown encoding", modify: func(e *Exporter) { e.Encoding = "latin-1" }, wantErr: true},
+		{name: "unknown column type", modify: func(e *Exporter) { e.Columns[0].Type = "money" }, wantErr: true},
+		{name: "no rows", modify: func(e *Exporter) { e.Rows = nil }, wantErr: true},
+		{name: "no filename", modify: func(e *Exporter) { e.Filename = nil }, wantErr: true},
+		{name: "no name", modify: func(e *Exporter) { e.Name = "" }, wantErr: true},
+	}
+
+	registered := exporters
+	t.Cleanup(func() { exporters = registered })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporters = append([]*Exporter(nil), registered...)
+			exporter := valid()
+			tt.modify(exporter)
+
+			err := RegisterExporter(exporter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RegisterExporter error = %v, want error: %v", err, tt.wantErr)
+			}
+			if registered := findExporter(exporter.Name) == exporter; registered == tt.wantErr {
+				t.Errorf("format registered: %v, want %v", registered, !tt.wantErr)
+			}
+			if _, err := exporter.Render(ExportParams{}); (err != nil) != tt.wantErr {
+				t.Errorf("Render error = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPayrollExportsApplyUserAndTeamFilters(t *testing.T) {
+	openTestDB(t)
+	alice := createTestUser(t, "alice")
+	bob := createTestUser(t, "bob")
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	for _, user := range []*database.User{alice, bob} {
+		createClosedEntry(t, user.ID, day.Add(9*time.Hour), day.Add(11*time.Hour))
+	}
+	team := database.Team{Name: "Coaches"}
+	if err := database.CreateTeam(&team); err != nil {
+		t.Fatalf("creating team: %v", err)
+	}
+	if err := database.AddTeamMembers(&team, []uint{bob.ID}); err != nil {
+		t.Fatalf("adding team member: %v", err)
+	}
+
+	app := fiber.New()
+	app.Get("/api/export", ExportAPI)
+
+	tests := []struct {
+		name    string
+		filters string
+		want    []string
+	}{
+		{name: "no filters", filters: "", want: []string{"alice", "bob"}},
+		{name: "users", filters: "&users=" + uintString(alice.ID), want: []string{"alice"}},
+		{name: "teams", filters: "&teams=coaches", want: []string{"bob"}},
+		{name: "users and teams", filters: "&users=" + uintString(alice.ID) + "&teams=Coaches", want: nil},
+	}
+
+	for _, format := range []string{"payroll", "datev"} {
+		for _, tt := range tests {
+			t.Run(format+" "+tt.name, func(t *testing.T) {
+				url := "/api/export?format=" + format + "&from=2026-03-01&to=2026-03-07" + tt.filters
+				resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+				if err != nil {
+					t.Fatalf("request: %v", err)
+				}
+				if resp.StatusCode != fiber.StatusOK {
+					t.Fatalf("status = %d, want 200", resp.StatusCode)
+				}
+				body, _ := io.ReadAll(resp.Body)
+
+				// DATEV has one line per day, so each user is counted once
+				var got []string
+				for _, line := range strings.Split(strings.TrimSpace(string(body)), "\r\n")[1:] {
+					if name := strings.Split(line, ";")[1]; len(got) == 0 || got[len(got)-1] != name {
+						got = append(got, name)
+					}
+				}
+				if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("exported users = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestExportRejectsInvalidUserFilter(t *testing.T) {
+	app := fiber.New()
+	app.Get("/api/export", ExportAPI)
+	resp, err := app.Test(httptest.NewRequest("GET", "/api/export?format=payroll&users=alice", nil))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func uintString(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// Content types of file exports
+const (
+	csvContentType  = "text/csv; charset=utf-8"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	pdfContentType  = "application/pdf"
+)
+
+// formatHours formats hours with two decimals for CSV exports
+func formatHours(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', 2, 64)
+}
+
+// encodeCSV writes rows as RFC 4180 CSV
+func encodeCSV(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.UseCRLF = true
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeXLSX writes a workbook as an .xlsx file
+func encodeXLSX(workbook *utils.XLSXWorkbook) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := workbook.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sendCSV sends rows as an RFC 4180 CSV file download
+func sendCSV(c *fiber.Ctx, filename string, rows [][]string) error {
+	data, err := encodeCSV(rows)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to write CSV",
+		})
+	}
+
+	c.Set("Content-Type", csvContentType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.Send(data)
+}
+
+// sendXLSX sends a workbook as an .xlsx file download
+func sendXLSX(c *fiber.Ctx, filename string, workbook *utils.XLSXWorkbook) error {
+	data, err := encodeXLSX(workbook)
+	if err != nil {
+		utils.LogError("Error writing workbook: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to write workbook",
+		})
+	}
+
+	c.Set("Content-Type", xlsxContentType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	return c.Send(data)
+}
+
+// userHours aggregates the daily hours of one user
+type userHours struct {
+	name, email                           string
+	days, entries                         int
+	gross, breaks, net, rounded, required float64
+}
+
+// aggregateUserHours sums daily hours per user, returning the user IDs in the order
+// of the days, each user's hours and the totals
+func aggregateUserHours(days []database.DailyHours) ([]uint, map[uint]*userHours, userHours) {
+	var order []uint
+	users := make(map[uint]*userHours)
+	for _, day := range days {
+		user, ok := users[day.UserID]
+		if !ok {
+			user = &userHours{name: day.Name, email: day.Email}
+			users[day.UserID] = user
+			order = append(order, day.UserID)
+		}
+		if day.Entries > 0 {
+			user.days++
+		}
+		user.entries += day.Entries
+		user.gross += day.GrossHours
+		user.breaks += day.BreakHours
+		user.net += day.NetHours
+		user.rounded += day.RoundedHours
+		user.required += day.RequiredHours
+	}
+	total := userHours{}
+	for _, user := range users {
+		total.entries += user.entries
+		total.gross += user.gross
+		total.breaks += user.breaks
+		total.net += user.net
+		total.rounded += user.rounded
+		total.required += user.required
+	}
+	return order, users, total
+}
+
+// usersRangeCSVRows returns the CSV rows of the per-user hours for the days in [from, to),
+// the same figures as the Users sheet of the report workbook
+func usersRangeCSVRows(from, to time.Time) ([][]string, error) {
+	days, err := database.GetDailyHours(from, to)
+	if err != nil {
+		return nil, err
+	}
+	order, users, total := aggregateUserHours(days)
+
+	rows := [][]string{{"Name", "Email", "Days Worked", "Entries", "Gross Hours", "Break Deduction",
+		"Net Hours", "Rounded Hours", "Required Hours"}}
+	for _, userID := range order {
+		user := users[userID]
+		rows = append(rows, []string{user.name, user.email, strconv.Itoa(user.days), strconv.Itoa(user.entries),
+			formatHours(user.gross), formatHours(user.breaks), formatHours(user.net),
+			formatHours(user.rounded), formatHours(user.required)})
+	}
+	rows = append(rows, []string{"Total", "", "", strconv.Itoa(total.entries), formatHours(total.gross),
+		formatHours(total.breaks), formatHours(total.net), formatHours(total.rounded), formatHours(total.required)})
+	return rows, nil
+}
+
+// buildReportWorkbook builds a workbook with summary, per-user, daily and entries sheets
+// for the days in [from, to)
+func buildReportWorkbook(title string, from, to time.Time) (*utils.XLSXWorkbook, error) {
+	days, err := database.GetDailyHours(from, to)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := database.GetTimeEntriesInRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	order, users, total := aggregateUserHours(days)
+
+	rounding := database.ConfiguredRounding()
+	roundingText := "none"
+	if rounding.Enabled() {
+		roundingText = fmt.Sprintf("%s to %d minutes per %s", rounding.Mode, rounding.Minutes, rounding.Scope)
+	}
+
+	workbook := utils.NewXLSXWorkbook()
+
+	summary := workbook.AddSheet("Summary")
+	summary.AddHeader(title, "")
+	summary.AddRow("From", from)
+	summary.AddRow("To", to.AddDate(0, 0, -1))
+	summary.AddRow("Generated", time.Now())
+	summary.AddRow("Rounding", roundingText)
+	summary.AddRow("Users", len(users))
+	summary.AddRow("Time entries", total.entries)
+	summary.AddRow("Gross hours", total.gross)
+	summary.AddRow("Break deduction", total.breaks)
+	summary.AddRow("Net hours", total.net)
+	summary.AddRow("Rounded hours", total.rounded)
+	summary.AddRow("Required hours", total.required)
+
+	perUser := workbook.AddSheet("Users")
+	perUser.AddHeader("Name", "Email", "Days Worked", "Entries", "Gross Hours", "Break Deduction",
+		"Net Hours", "Rounded Hours", "Required Hours", "Completion Rate (%)")
+	for _, userID := range order {
+		user := users[userID]
+		completion := 0.0
+		if user.required > 0 {
+			completion = user.gross / user.required * 100
+		}
+		perUser.AddRow(user.name, user.email, user.days, user.entries, user.gross, user.breaks,
+			user.net, user.rounded, user.required, completion)
+	}
+	perUser.AddTotalRow("Total", "", nil, total.entries, total.gross, total.breaks, total.net, total.rounded, total.required)
+
+	daily := workbook.AddSheet("Daily")
+	daily.AddHeader("Date", "Name", "Email", "Entries", "Gross Hours", "Break Deduction",
+		"Net Hours", "Rounded Hours", "Required Hours")
+	for _, day := range days {
+		daily.AddRow(day.Date, day.Name, day.Email, day.Entries, day.GrossHours, day.BreakHours,
+			day.NetHours, day.RoundedHours, day.RequiredHours)
+	}
+	daily.AddTotalRow("Total", "", "", total.entries, total.gross, total.breaks, total.net, total.rounded, total.required)
+
+	entrySheet := workbook.AddSheet("Entries")
+	entrySheet.AddHeader("ID", "Name", "Email", "Start", "End", "Hours", "Category", "Project", "Status Text", "Source", "Note")
+	entryHours := 0.0
+	for _, entry := range entries {
+		name, email := "", ""
+		if entry.User != nil {
+			name, email = entry.User.Name, entry.User.Email
+			if entry.User.RealName != "" {
+				name = entry.User.RealName
+			}
+		}
+		var end interface{}
+		if entry.EndTime != nil {
+			end = entry.EndTime.Local()
+		}
+		project := ""
+		if entry.Project != nil {
+			project = entry.Project.Name
+		}
+		hours := float64(entry.Duration) / 3600.0
+		entryHours += hours
+		entrySheet.AddRow(entry.ID, name, email, entry.StartTime.Local(), end, hours,
+			entry.Status, project, entry.StatusText, entry.Source, entry.Note)
+	}
+	entrySheet.AddTotalRow("Total", "", "", nil, nil, entryHours)
+
+	return workbook, nil
+}
+
+// exportWorkbook sends the report workbook for the days in [from, to)
+func exportWorkbook(c *fiber.Ctx, name, title string, from, to time.Time) error {
+	workbook, err := buildReportWorkbook(title, from, to)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidTimeRange) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "to must be after from",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load report data",
+		})
+	}
+
+	return sendXLSX(c, fmt.Sprintf("%s_%s.xlsx", name, from.Format("2006-01-02")), workbook)
+}
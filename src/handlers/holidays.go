@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+)
+
+// HolidayCalendarRequest is the request body for editing a holiday calendar
+type HolidayCalendarRequest struct {
+	Name    *string `json:"name"`
+	Country *string `json:"country"` // ISO country code, empty to unassign
+	Team    *string `json:"team"`    // Team name, empty to unassign
+}
+
+// apply copies the provided fields of the request onto a calendar
+func (req HolidayCalendarRequest) apply(calendar *database.HolidayCalendar) {
+	if req.Name != nil {
+		calendar.Name = *req.Name
+	}
+	if req.Country != nil {
+		calendar.Country = strings.ToUpper(strings.TrimSpace(*req.Country))
+	}
+	if req.Team != nil {
+		calendar.Team = strings.TrimSpace(*req.Team)
+	}
+}
+
+// holidayCalendarErrorResponse maps calendar errors to an HTTP response
+func holidayCalendarErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, database.ErrInvalidHolidayCalendar) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// uploadedICS returns the uploaded .ics file from the "file" form field, or the raw request body
+func uploadedICS(c *fiber.Ctx) (io.ReadCloser, error) {
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		return fileHeader.Open()
+	}
+	if len(c.Body()) == 0 {
+		return nil, errors.New("an .ics file is required")
+	}
+	return io.NopCloser(bytes.NewReader(c.Body())), nil
+}
+
+// auditableCalendar returns a copy of the calendar without its holidays for the audit log
+func auditableCalendar(calendar *database.HolidayCalendar) database.HolidayCalendar {
+	snapshot := *calendar
+	snapshot.Holidays = nil
+	return snapshot
+}
+
+// GetHolidayCalendarsAPI lists holiday calendars
+func GetHolidayCalendarsAPI(c *fiber.Ctx) error {
+	calendars, err := database.GetHolidayCalendars()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load holiday calendars",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"calendars": calendars,
+	})
+}
+
+// GetHolidayCalendarAPI returns a holiday calendar with its holidays
+func GetHolidayCalendarAPI(c *fiber.Ctx) error {
+	calendarID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid calendar ID",
+		})
+	}
+
+	calendar, err := database.GetHolidayCalendar(calendarID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Holiday calendar not found",
+		})
+	}
+
+	return c.JSON(calendar)
+}
+
+// CreateHolidayCalendarAPI imports an uploaded .ics file as a new calendar.
+// The name, country and team are read from the form fields or query string.
+func CreateHolidayCalendarAPI(c *fiber.Ctx) error {
+	file, err := uploadedICS(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer file.Close()
+
+	formValue := func(key string) string {
+		if value := c.FormValue(key); value != "" {
+			return value
+		}
+		return c.Query(key)
+	}
+	name, country, team := formValue("name"), formValue("country"), formValue("team")
+
+	var existing int64
+	database.DB.Model(&database.HolidayCalendar{}).Where("name = ?", name).Count(&existing)
+	if existing > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A holiday calendar with this name already exists",
+		})
+	}
+
+	calendar := database.HolidayCalendar{}
+	HolidayCalendarRequest{Name: &name, Country: &country, Team: &team}.apply(&calendar)
+	if err := database.ImportHolidayCalendar(&calendar, file); err != nil {
+		return holidayCalendarErrorResponse(c, err, "Failed to import holiday calendar")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityHolidayCalendar, calendar.ID, nil, auditableCalendar(&calendar))
+
+	return c.Status(fiber.StatusCreated).JSON(calendar)
+}
+
+// ReimportHolidayCalendarAPI replaces a calendar's holidays from an uploaded .ics file,
+// or from the file it was originally imported from when nothing is uploaded
+func ReimportHolidayCalendarAPI(c *fiber.Ctx) error {
+	calendarID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid calendar ID",
+		})
+	}
+
+	calendar, err := database.GetHolidayCalendar(calendarID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Holiday calendar not found",
+		})
+	}
+
+	file, err := uploadedICS(c)
+	if err != nil && calendar.SourcePath != "" {
+		file, err = os.Open(calendar.SourcePath)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer file.Close()
+
+	before := auditableCalendar(calendar)
+	if err := database.ImportHolidayCalendar(calendar, file); err != nil {
+		return holidayCalendarErrorResponse(c, err, "Failed to import holiday calendar")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityHolidayCalendar, calendar.ID, before, auditableCalendar(calendar))
+
+	return c.JSON(calendar)
+}
+
+// UpdateHolidayCalendarAPI renames a calendar or changes the country or team it applies to
+func UpdateHolidayCalendarAPI(c *fiber.Ctx) error {
+	calendarID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid calendar ID",
+		})
+	}
+
+	var calendar database.HolidayCalendar
+	if err := database.DB.First(&calendar, calendarID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Holiday calendar not found",
+		})
+	}
+
+	var req HolidayCalendarRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := calendar
+	req.apply(&calendar)
+	if err := database.UpdateHolidayCalendar(&calendar); err != nil {
+		return holidayCalendarErrorResponse(c, err, "Failed to update holiday calendar")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityHolidayCalendar, calendar.ID, before, calendar)
+
+	return c.JSON(calendar)
+}
+
+// DeleteHolidayCalendarAPI removes a calendar and its holidays
+func DeleteHolidayCalendarAPI(c *fiber.Ctx) error {
+	calendarID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid calendar ID",
+		})
+	}
+
+	var calendar database.HolidayCalendar
+	if err := database.DB.First(&calendar, calendarID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Holiday calendar not found",
+		})
+	}
+
+	if err := database.DeleteHolidayCalendar(calendar.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete holiday calendar",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityHolidayCalendar, calendar.ID, calendar, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Holiday calendar deleted",
+	})
+}
+
+// GetUserHolidaysAPI lists the public holidays that apply to a user, by default for the current year
+func GetUserHolidaysAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(1, 0, 0)
+	if fromParam := c.Query("from"); fromParam != "" {
+		if from, err = time.ParseInLocation("2006-01-02", fromParam, time.Local); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from format. Use YYYY-MM-DD",
+			})
+		}
+	}
+	if toParam := c.Query("to"); toParam != "" {
+		if to, err = time.ParseInLocation("2006-01-02", toParam, time.Local); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid to format. Use YYYY-MM-DD",
+			})
+		}
+		to = to.AddDate(0, 0, 1) // Include the whole last day
+	}
+
+	holidays, err := database.GetUserHolidays(userID, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load holidays",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"holidays": holidays,
+	})
+}
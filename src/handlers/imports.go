@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/services"
+	"sports-excitement-team-management/src/utils"
+)
+
+// importErrorResponse maps import errors to an HTTP response
+func importErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, database.ErrInvalidImport):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, database.ErrImportRolledBack), errors.Is(err, database.ErrPeriodLocked):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	utils.LogError("%s: %v", fallback, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// uploadedCSV returns the uploaded file from the "file" form field and its name, or the
+// raw request body
+func uploadedCSV(c *fiber.Ctx) (io.ReadCloser, string, error) {
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		return file, fileHeader.Filename, err
+	}
+	if len(c.Body()) == 0 {
+		return nil, "", errors.New("a CSV file is required")
+	}
+	return io.NopCloser(bytes.NewReader(c.Body())), "upload.csv", nil
+}
+
+// ImportTimeEntriesAPI imports time entries from an uploaded CSV file. The form fields
+// (or query parameters) select the format (toggl, clockify or csv), a JSON column
+// mapping overriding the format's columns, the delimiter and dry_run for a preview.
+func ImportTimeEntriesAPI(c *fiber.Ctx) error {
+	formValue := func(key string) string {
+		if value := c.FormValue(key); value != "" {
+			return value
+		}
+		return c.Query(key)
+	}
+
+	options := database.ImportOptions{
+		Format: strings.ToLower(formValue("format")),
+		DryRun: formValue("dry_run") == "true" || formValue("dry_run") == "1",
+	}
+	if options.Format == "" {
+		options.Format = "csv"
+	}
+	if mapping := formValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid mapping. Use a JSON object of column names",
+			})
+		}
+	}
+	switch delimiter := formValue("delimiter"); {
+	case delimiter == "tab":
+		options.Delimiter = '\t'
+	case len([]rune(delimiter)) == 1:
+		options.Delimiter = []rune(delimiter)[0]
+	case delimiter != "":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid delimiter. Use a single character or tab",
+		})
+	}
+
+	file, filename, err := uploadedCSV(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	defer file.Close()
+	options.Filename = filename
+	_, options.CreatedBy = currentAdmin(c)
+
+	result, err := database.ImportTimeEntries(file, options)
+	if err != nil {
+		return importErrorResponse(c, err, "Failed to import time entries")
+	}
+
+	if result.Batch == nil {
+		return c.JSON(result)
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityImportBatch, result.Batch.ID, nil, result.Batch)
+	affected := make(map[uint]bool)
+	for _, row := range result.Items {
+		if row.Error == "" && !affected[row.UserID] {
+			affected[row.UserID] = true
+			broadcastUserChange(row.UserID)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
+
+// GetImportBatchesAPI lists import batches, newest first
+func GetImportBatchesAPI(c *fiber.Ctx) error {
+	batches, err := database.GetImportBatches()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load imports",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"imports": batches,
+		"formats": database.ImportFormats(),
+	})
+}
+
+// RollbackImportBatchAPI deletes the entries created by an import batch
+func RollbackImportBatchAPI(c *fiber.Ctx) error {
+	batchID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid import ID",
+		})
+	}
+
+	batch, err := database.GetImportBatch(batchID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Import not found",
+		})
+	}
+
+	before := *batch
+	_, adminName := currentAdmin(c)
+	if err := database.RollbackImportBatch(batch, adminName); err != nil {
+		return importErrorResponse(c, err, "Failed to roll back import")
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityImportBatch, batch.ID, before, batch)
+	if hub := services.GetGlobalHub(); hub != nil {
+		hub.BroadcastAnalyticsUpdate()
+	}
+
+	return c.JSON(batch)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+)
+
+// PeriodLockRequest is the request body for locking a date range
+type PeriodLockRequest struct {
+	From   string `json:"from"` // First locked day (YYYY-MM-DD)
+	To     string `json:"to"`   // Last locked day (YYYY-MM-DD), inclusive
+	Reason string `json:"reason"`
+}
+
+// GetPeriodLocksAPI lists period locks; pass active=true to hide lifted locks
+func GetPeriodLocksAPI(c *fiber.Ctx) error {
+	locks, err := database.GetPeriodLocks(c.QueryBool("active", false))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load period locks",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"locks": locks,
+	})
+}
+
+// CreatePeriodLockAPI locks a date range against time entry changes
+func CreatePeriodLockAPI(c *fiber.Ctx) error {
+	var req PeriodLockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", req.From, time.Local)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid from format. Use YYYY-MM-DD",
+		})
+	}
+	to, err := time.ParseInLocation("2006-01-02", req.To, time.Local)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid to format. Use YYYY-MM-DD",
+		})
+	}
+
+	adminID, username := currentAdmin(c)
+	lock, err := database.CreatePeriodLock(from, to.AddDate(0, 0, 1), req.Reason, adminID, username)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidTimeRange) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "to must not be before from",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to lock period",
+		})
+	}
+
+	recordAudit(c, database.AuditActionLock, database.AuditEntityPeriodLock, lock.ID, nil, lock)
+
+	return c.Status(fiber.StatusCreated).JSON(lock)
+}
+
+// UnlockPeriodAPI lifts a period lock
+func UnlockPeriodAPI(c *fiber.Ctx) error {
+	lockID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid lock ID",
+		})
+	}
+
+	lock, err := database.GetPeriodLock(lockID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Period lock not found",
+		})
+	}
+
+	before := *lock
+	adminID, username := currentAdmin(c)
+	if err := database.UnlockPeriod(lock, adminID, username); err != nil {
+		if errors.Is(err, database.ErrLockNotActive) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to unlock period",
+		})
+	}
+
+	recordAudit(c, database.AuditActionUnlock, database.AuditEntityPeriodLock, lock.ID, before, lock)
+
+	return c.JSON(lock)
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// ProjectRequest is the request body for creating or editing a project
+type ProjectRequest struct {
+	Tag        *string  `json:"tag"` // Matched in status texts, e.g. "matchday-app" for "#matchday-app"
+	Name       *string  `json:"name"`
+	Client     *string  `json:"client"`
+	Billable   *bool    `json:"billable"`
+	HourlyRate *float64 `json:"hourly_rate"`
+}
+
+// apply copies the provided fields of the request onto a project
+func (req ProjectRequest) apply(project *database.Project) {
+	if req.Tag != nil {
+		project.Tag = *req.Tag
+	}
+	if req.Name != nil {
+		project.Name = *req.Name
+	}
+	if req.Client != nil {
+		project.Client = *req.Client
+	}
+	if req.Billable != nil {
+		project.Billable = *req.Billable
+	}
+	if req.HourlyRate != nil {
+		project.HourlyRate = *req.HourlyRate
+	}
+}
+
+// projectErrorResponse maps project validation errors to an HTTP response
+func projectErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, database.ErrInvalidProject) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// loadProject loads the project referenced by the id route parameter
+func loadProject(c *fiber.Ctx) (*database.Project, error) {
+	projectID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid project ID",
+		})
+	}
+
+	project, err := database.GetProject(projectID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Project not found",
+		})
+	}
+
+	return project, nil
+}
+
+// GetProjectsAPI lists the project catalogue
+func GetProjectsAPI(c *fiber.Ctx) error {
+	projects, err := database.GetProjects()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load projects",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"projects": projects,
+	})
+}
+
+// CreateProjectAPI adds a project to the catalogue
+func CreateProjectAPI(c *fiber.Ctx) error {
+	var req ProjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var project database.Project
+	req.apply(&project)
+	if err := database.CreateProject(&project); err != nil {
+		return projectErrorResponse(c, err, "Failed to create project")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityProject, project.ID, nil, project)
+
+	return c.Status(fiber.StatusCreated).JSON(project)
+}
+
+// UpdateProjectAPI edits a project
+func UpdateProjectAPI(c *fiber.Ctx) error {
+	project, err := loadProject(c)
+	if project == nil {
+		return err
+	}
+
+	var req ProjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *project
+	req.apply(project)
+	if err := database.UpdateProject(project); err != nil {
+		return projectErrorResponse(c, err, "Failed to update project")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityProject, project.ID, before, project)
+
+	return c.JSON(project)
+}
+
+// DeleteProjectAPI removes a project; its entries are no longer booked on a project
+func DeleteProjectAPI(c *fiber.Ctx) error {
+	project, err := loadProject(c)
+	if project == nil {
+		return err
+	}
+
+	if err := database.DeleteProject(project.ID); err != nil {
+		if errors.Is(err, database.ErrPeriodLocked) {
+			return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete project",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityProject, project.ID, project, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Project deleted",
+	})
+}
+
+// projectReportRows returns the header and rows of a project report for tabular exports
+func projectReportRows(report *database.ProjectReport) ([]string, [][]interface{}) {
+	header := []string{"Project", "Tag", "Client", "Billable", "Hourly Rate", "Users", "Entries",
+		"Gross Hours", "Net Hours", "Rounded Hours", "Billable Hours", "Amount"}
+	rows := make([][]interface{}, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		billable := "no"
+		if row.Billable {
+			billable = "yes"
+		}
+		rows = append(rows, []interface{}{row.Name, row.Tag, row.Client, billable, row.HourlyRate, row.Users,
+			row.Entries, row.GrossHours, row.NetHours, row.RoundedHours, row.BillableHours, row.Amount})
+	}
+	return header, rows
+}
+
+// projectReportCSVRows returns the CSV rows of a project report including the totals
+func projectReportCSVRows(report *database.ProjectReport) [][]string {
+	header, rows := projectReportRows(report)
+	totals := report.Totals
+
+	records := [][]string{header}
+	for _, row := range append(rows, []interface{}{totals.Name, "", "", "", "", totals.Users, totals.Entries,
+		totals.GrossHours, totals.NetHours, totals.RoundedHours, totals.BillableHours, totals.Amount}) {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if hours, ok := value.(float64); ok {
+				record[i] = formatHours(hours)
+			} else {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// projectReportWorkbook builds the workbook of a project report
+func projectReportWorkbook(report *database.ProjectReport, filters map[string]string) *utils.XLSXWorkbook {
+	header, rows := projectReportRows(report)
+	totals := report.Totals
+
+	workbook := utils.NewXLSXWorkbook()
+	summary := workbook.AddSheet("Summary")
+	summary.AddHeader("Project Report", "")
+	summary.AddRow("From", report.From)
+	summary.AddRow("To", report.To)
+	for _, filter := range []string{"users", "teams", "categories", "projects"} {
+		if value := filters[filter]; value != "" {
+			summary.AddRow("Filter "+filter, value)
+		}
+	}
+	summary.AddRow("Generated", time.Now())
+	summary.AddRow("Rounded hours", totals.RoundedHours)
+	summary.AddRow("Billable hours", totals.BillableHours)
+	summary.AddRow("Amount", totals.Amount)
+
+	sheet := workbook.AddSheet("Projects")
+	sheet.AddHeader(header...)
+	for _, row := range rows {
+		sheet.AddRow(row...)
+	}
+	sheet.AddTotalRow(totals.Name, "", "", "", nil, totals.Users, totals.Entries, totals.GrossHours,
+		totals.NetHours, totals.RoundedHours, totals.BillableHours, totals.Amount)
+	return workbook
+}
+
+// GetProjectReportAPI returns tracked hours, billable hours and amounts per project
+// for a date range, as JSON or with format=csv or format=xlsx. It takes the filters of
+// the report API.
+func GetProjectReportAPI(c *fiber.Ctx) error {
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" && format != "xlsx" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid format. Use json, csv or xlsx",
+		})
+	}
+
+	query, err := parseReportQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report, err := database.RunProjectReport(query)
+	if err != nil {
+		return reportErrorResponse(c, err, "Failed to build project report")
+	}
+
+	filename := fmt.Sprintf("projects_%s_%s", report.From.Format("2006-01-02"), report.To.Format("2006-01-02"))
+	switch format {
+	case "csv":
+		return sendCSV(c, filename+".csv", projectReportCSVRows(report))
+	case "xlsx":
+		filters := map[string]string{
+			"users":      c.Query("users"),
+			"teams":      c.Query("teams"),
+			"categories": c.Query("categories"),
+			"projects":   c.Query("projects"),
+		}
+		return sendXLSX(c, filename+".xlsx", projectReportWorkbook(report, filters))
+	default:
+		return c.JSON(report)
+	}
+}
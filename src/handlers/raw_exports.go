@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// rawExportPageSize is the number of rows read from the database at a time
+const rawExportPageSize = 1000
+
+// RawTimeEntry is the export schema of a time entry. Its field names are stable even
+// if the database model changes.
+type RawTimeEntry struct {
+	ID              uint       `json:"id"`
+	UserID          uint       `json:"user_id"`
+	StartTime       time.Time  `json:"start_time"`
+	EndTime         *time.Time `json:"end_time"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	Category        string     `json:"category"`
+	StatusText      string     `json:"status_text"`
+	StatusEmoji     string     `json:"status_emoji"`
+	Source          string     `json:"source"`
+	Note            string     `json:"note"`
+	ImportID        *uint      `json:"import_id"`
+	ProjectID       *uint      `json:"project_id"`
+	Tags            string     `json:"tags"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// RawUserStatus is the export schema of a Slack status change
+type RawUserStatus struct {
+	ID          uint      `json:"id"`
+	UserID      uint      `json:"user_id"`
+	StatusEmoji string    `json:"status_emoji"`
+	StatusText  string    `json:"status_text"`
+	IsWorking   bool      `json:"is_working"`
+	Timestamp   time.Time `json:"timestamp"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// parseRawExportFilter reads from and to (inclusive, default: no bound), users, cursor
+// and limit
+func parseRawExportFilter(c *fiber.Ctx) (database.RawExportFilter, error) {
+	var filter database.RawExportFilter
+
+	if fromParam := c.Query("from"); fromParam != "" {
+		from, err := time.ParseInLocation("2006-01-02", fromParam, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from format. Use YYYY-MM-DD")
+		}
+		filter.From = from
+	}
+	if toParam := c.Query("to"); toParam != "" {
+		to, err := time.ParseInLocation("2006-01-02", toParam, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to format. Use YYYY-MM-DD")
+		}
+		filter.To = to.AddDate(0, 0, 1) // Include the whole last day
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return filter, fmt.Errorf("to must not be before from")
+	}
+
+	for _, userParam := range database.SplitList(c.Query("users")) {
+		userID, err := strconv.ParseUint(userParam, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid user ID %q", userParam)
+		}
+		filter.UserIDs = append(filter.UserIDs, uint(userID))
+	}
+
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := strconv.ParseUint(cursorParam, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid cursor")
+		}
+		filter.AfterID = uint(cursor)
+	}
+	if limitParam := c.Query("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 0 {
+			return filter, fmt.Errorf("invalid limit")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// rawExportWriter writes records as NDJSON or as the elements of a JSON array
+type rawExportWriter struct {
+	w       *bufio.Writer
+	array   bool
+	written int
+}
+
+// begin writes the start of the export
+func (r *rawExportWriter) begin() {
+	if r.array {
+		r.w.WriteString("[")
+	}
+}
+
+// record writes one record
+func (r *rawExportWriter) record(value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if r.array && r.written > 0 {
+		r.w.WriteString(",")
+	}
+	r.w.Write(data)
+	if !r.array {
+		r.w.WriteString("\n")
+	}
+	r.written++
+	return nil
+}
+
+// end writes the end of the export
+func (r *rawExportWriter) end() {
+	if r.array {
+		r.w.WriteString("]\n")
+	}
+}
+
+// streamRawTable writes the rows of a table selected by filter, one page at a time
+func (r *rawExportWriter) streamRawTable(table string, filter database.RawExportFilter) error {
+	if table == database.RawExportUserStatuses {
+		return database.StreamUserStatuses(filter, rawExportPageSize, func(statuses []database.UserStatus) error {
+			for _, status := range statuses {
+				if err := r.record(RawUserStatus{
+					ID:          status.ID,
+					UserID:      status.UserID,
+					StatusEmoji: status.StatusEmoji,
+					StatusText:  status.StatusText,
+					IsWorking:   status.IsWorking,
+					Timestamp:   status.Timestamp,
+					CreatedAt:   status.CreatedAt,
+				}); err != nil {
+					return err
+				}
+			}
+			return r.w.Flush()
+		})
+	}
+
+	return database.StreamTimeEntries(filter, rawExportPageSize, func(entries []database.TimeEntry) error {
+		for _, entry := range entries {
+			if err := r.record(RawTimeEntry{
+				ID:              entry.ID,
+				UserID:          entry.UserID,
+				StartTime:       entry.StartTime,
+				EndTime:         entry.EndTime,
+				DurationSeconds: entry.Duration,
+				Category:        entry.Status,
+				StatusText:      entry.StatusText,
+				StatusEmoji:     entry.StatusEmoji,
+				Source:          entry.Source,
+				Note:            entry.Note,
+				ImportID:        entry.ImportID,
+				ProjectID:       entry.ProjectID,
+				Tags:            entry.Tags,
+				CreatedAt:       entry.CreatedAt,
+				UpdatedAt:       entry.UpdatedAt,
+			}); err != nil {
+				return err
+			}
+		}
+		return r.w.Flush()
+	})
+}
+
+// RawExportAPI streams the rows of time_entries or user_statuses as NDJSON (default)
+// or a JSON array (format=json), in ID order. Rows are read from the database page by
+// page and written as they are read. An interrupted export resumes with cursor set to
+// the last received ID; with a limit the X-Next-Cursor header holds the cursor of the
+// next chunk.
+func RawExportAPI(c *fiber.Ctx) error {
+	table := c.Params("table")
+	if table != database.RawExportTimeEntries && table != database.RawExportUserStatuses {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Unknown table. Use time_entries or user_statuses",
+		})
+	}
+
+	format := c.Query("format", "ndjson")
+	if format != "ndjson" && format != "json" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid format. Use ndjson or json",
+		})
+	}
+
+	filter, err := parseRawExportFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	nextCursor, err := database.RawExportNextCursor(table, filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to prepare export",
+		})
+	}
+	if nextCursor != 0 {
+		c.Set("X-Next-Cursor", strconv.FormatUint(uint64(nextCursor), 10))
+	}
+
+	contentType := "application/x-ndjson"
+	if format == "json" {
+		contentType = fiber.MIMEApplicationJSON
+	}
+	c.Set("Content-Type", contentType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s_%s.%s", table, time.Now().Format("20060102-150405"), format))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer := &rawExportWriter{w: w, array: format == "json"}
+		writer.begin()
+		if err := writer.streamRawTable(table, filter); err != nil {
+			// The response has started; stopping leaves truncated output the client resumes
+			utils.LogError("Error streaming %s export after %d rows: %v", table, writer.written, err)
+			return
+		}
+		writer.end()
+		w.Flush()
+	})
+	return nil
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// parseReportQuery reads from, to (inclusive, default: the current month up to today),
+// group_by and the users, teams, categories and projects filters
+func parseReportQuery(c *fiber.Ctx) (database.ReportQuery, error) {
+	now := time.Now()
+	query := database.ReportQuery{
+		From:       time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local),
+		To:         now.AddDate(0, 0, 1),
+		GroupBy:    c.Query("group_by"),
+		Teams:      database.SplitList(c.Query("teams")),
+		Categories: database.SplitList(c.Query("categories")),
+		Projects:   database.SplitList(c.Query("projects")),
+	}
+
+	if fromParam := c.Query("from"); fromParam != "" {
+		from, err := time.ParseInLocation("2006-01-02", fromParam, time.Local)
+		if err != nil {
+			return query, fmt.Errorf("invalid from format. Use YYYY-MM-DD")
+		}
+		query.From = from
+	}
+	if toParam := c.Query("to"); toParam != "" {
+		to, err := time.ParseInLocation("2006-01-02", toParam, time.Local)
+		if err != nil {
+			return query, fmt.Errorf("invalid to format. Use YYYY-MM-DD")
+		}
+		query.To = to.AddDate(0, 0, 1) // Include the whole last day
+	}
+
+	for _, userParam := range database.SplitList(c.Query("users")) {
+		userID, err := strconv.ParseUint(userParam, 10, 32)
+		if err != nil {
+			return query, fmt.Errorf("invalid user ID %q", userParam)
+		}
+		query.UserIDs = append(query.UserIDs, uint(userID))
+	}
+
+	return query, nil
+}
+
+// reportErrorResponse maps report query errors to an HTTP response
+func reportErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, database.ErrInvalidTimeRange):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "to must not be before from",
+		})
+	case errors.Is(err, database.ErrInvalidReportQuery):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// reportRows returns the header and rows of a report for tabular exports
+func reportRows(report *database.Report) ([]string, [][]interface{}) {
+	header := []string{"Group", "Users", "Entries", "Gross Hours", "Break Deduction", "Net Hours", "Rounded Hours", "Required Hours"}
+	rows := make([][]interface{}, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		rows = append(rows, []interface{}{row.Label, row.Users, row.Entries, row.GrossHours, row.BreakHours,
+			row.NetHours, row.RoundedHours, row.RequiredHours})
+	}
+	return header, rows
+}
+
+// reportFilename returns the file name of a report without extension
+func reportFilename(report *database.Report) string {
+	return fmt.Sprintf("report_%s_%s_%s", report.GroupBy, report.From.Format("2006-01-02"), report.To.Format("2006-01-02"))
+}
+
+// reportCSVRows returns the CSV rows of a report including the totals
+func reportCSVRows(report *database.Report) [][]string {
+	header, rows := reportRows(report)
+	totals := report.Totals
+
+	records := [][]string{header}
+	for _, row := range append(rows, []interface{}{totals.Label, totals.Users, totals.Entries, totals.GrossHours,
+		totals.BreakHours, totals.NetHours, totals.RoundedHours, totals.RequiredHours}) {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if hours, ok := value.(float64); ok {
+				record[i] = formatHours(hours)
+			} else {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// reportWorkbook builds the workbook of a report. filters lists the users, teams,
+// categories and projects filters as given in the request or schedule.
+func reportWorkbook(report *database.Report, filters map[string]string) *utils.XLSXWorkbook {
+	header, rows := reportRows(report)
+	totals := report.Totals
+
+	workbook := utils.NewXLSXWorkbook()
+	summary := workbook.AddSheet("Summary")
+	summary.AddHeader("Report", "")
+	summary.AddRow("From", report.From)
+	summary.AddRow("To", report.To)
+	summary.AddRow("Grouped by", report.GroupBy)
+	for _, filter := range []string{"users", "teams", "categories", "projects"} {
+		if value := filters[filter]; value != "" {
+			summary.AddRow("Filter "+filter, value)
+		}
+	}
+	summary.AddRow("Generated", time.Now())
+	summary.AddRow("Gross hours", totals.GrossHours)
+	summary.AddRow("Break deduction", totals.BreakHours)
+	summary.AddRow("Net hours", totals.NetHours)
+	summary.AddRow("Rounded hours", totals.RoundedHours)
+	summary.AddRow("Required hours", totals.RequiredHours)
+
+	sheet := workbook.AddSheet("Report")
+	sheet.AddHeader(header...)
+	for _, row := range rows {
+		sheet.AddRow(row...)
+	}
+	sheet.AddTotalRow(totals.Label, totals.Users, totals.Entries, totals.GrossHours, totals.BreakHours,
+		totals.NetHours, totals.RoundedHours, totals.RequiredHours)
+	return workbook
+}
+
+// sendReport sends a report as JSON, CSV or an .xlsx workbook
+func sendReport(c *fiber.Ctx, report *database.Report, format string) error {
+	filename := reportFilename(report)
+
+	switch format {
+	case "csv":
+		return sendCSV(c, filename+".csv", reportCSVRows(report))
+	case "xlsx":
+		filters := map[string]string{
+			"users":      c.Query("users"),
+			"teams":      c.Query("teams"),
+			"categories": c.Query("categories"),
+			"projects":   c.Query("projects"),
+		}
+		return sendXLSX(c, filename+".xlsx", reportWorkbook(report, filters))
+	default:
+		return c.JSON(report)
+	}
+}
+
+// GetReportAPI returns tracked time for an arbitrary date range grouped by day, week,
+// month, user, team, category or project, as JSON or with format=csv or format=xlsx
+func GetReportAPI(c *fiber.Ctx) error {
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" && format != "xlsx" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid format. Use json, csv or xlsx",
+		})
+	}
+	return exportReport(c, format)
+}
+
+// exportReport runs the report query of the request and sends it in the given format
+func exportReport(c *fiber.Ctx, format string) error {
+	query, err := parseReportQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	report, err := database.RunReport(query)
+	if err != nil {
+		return reportErrorResponse(c, err, "Failed to build report")
+	}
+
+	return sendReport(c, report, format)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
 
+	"sports-excitement-team-management/src/database"
 	"sports-excitement-team-management/src/services"
 )
 
@@ -22,6 +23,9 @@ func SetupRoutes(app *fiber.App, wsHub *services.WebSocketHub) {
 	app.Post("/login", AuthMiddleware, HandleLogin)
 	app.Get("/logout", AuthMiddleware, HandleLogout)
 
+	// Calendar feeds are authenticated by the token in their URL
+	app.Get("/calendar/:token.ics", CalendarFeedICS)
+
 	// WebSocket route with authentication check
 	app.Use("/ws", func(c *fiber.Ctx) error {
 		// Check if request is websocket upgrade
@@ -56,9 +60,115 @@ func SetupRoutes(app *fiber.App, wsHub *services.WebSocketHub) {
 
 	// API routes
 	protected.Get("/api/users", GetUsersAPI)
+	protected.Get("/api/users/:id", GetUserDetails)
+	protected.Put("/api/users/:id", UpdateUserAPI)
+	protected.Get("/api/users/:id/entries", GetUserTimeEntries)
+	protected.Get("/api/users/:id/timeline", GetUserTimelineAPI)
+	protected.Get("/api/users/:id/timesheet", GetUserMonthlyTimesheetAPI)
+	protected.Get("/api/users/:id/calendar-feed", GetCalendarFeedAPI)
+	protected.Post("/api/users/:id/calendar-feed", CreateCalendarFeedAPI)
+	protected.Delete("/api/users/:id/calendar-feed", RevokeCalendarFeedAPI)
+	protected.Post("/api/users/:id/entries", CreateUserTimeEntry)
+	protected.Put("/api/users/:id/entries/:entryId", UpdateUserTimeEntry)
+	protected.Delete("/api/users/:id/entries/:entryId", DeleteUserTimeEntry)
+	protected.Get("/api/users/:id/contracts", GetUserContractsAPI)
+	protected.Post("/api/users/:id/contracts", CreateUserContractAPI)
+	protected.Put("/api/users/:id/contracts/:contractId", UpdateUserContractAPI)
+	protected.Delete("/api/users/:id/contracts/:contractId", DeleteUserContractAPI)
+	protected.Get("/api/users/:id/holidays", GetUserHolidaysAPI)
+	protected.Get("/api/users/:id/balance", GetUserBalanceAPI)
+	protected.Post("/api/users/:id/balance/adjustments", CreateBalanceAdjustmentAPI)
+	protected.Delete("/api/users/:id/balance/adjustments/:adjustmentId", DeleteBalanceAdjustmentAPI)
 	protected.Get("/api/analytics", GetAnalyticsAPI)
+	protected.Get("/api/reports", GetReportAPI)
+	protected.Get("/api/charts/heatmap", GetActivityHeatmapAPI)
+	protected.Get("/api/charts/timeseries", GetTimeSeriesAPI)
 	protected.Get("/api/reports/weekly", GetWeeklyReports)
 	protected.Get("/api/export/excel", ExportExcel)
+	protected.Get("/api/export", ExportAPI)
+	protected.Get("/api/export/formats", GetExportFormatsAPI)
+	protected.Get("/api/export/raw/:table", RawExportAPI)
+
+	// Team API routes
+	protected.Get("/api/teams", GetTeamsAPI)
+	protected.Get("/api/teams/analytics", GetTeamAnalyticsAPI)
+	protected.Post("/api/teams", CreateTeamAPI)
+	protected.Post("/api/teams/sync", SyncTeamsAPI)
+	protected.Put("/api/teams/:id", UpdateTeamAPI)
+	protected.Delete("/api/teams/:id", DeleteTeamAPI)
+	protected.Post("/api/teams/:id/members", AddTeamMembersAPI)
+	protected.Delete("/api/teams/:id/members/:userId", RemoveTeamMemberAPI)
+
+	// Tracking rule API routes
+	protected.Get("/api/tracking-rules", GetTrackingRulesAPI)
+	protected.Post("/api/tracking-rules", CreateTrackingRuleAPI)
+	protected.Put("/api/tracking-rules/:id", UpdateTrackingRuleAPI)
+	protected.Delete("/api/tracking-rules/:id", DeleteTrackingRuleAPI)
+
+	// Project API routes
+	protected.Get("/api/projects", GetProjectsAPI)
+	protected.Get("/api/projects/report", GetProjectReportAPI)
+	protected.Post("/api/projects", CreateProjectAPI)
+	protected.Put("/api/projects/:id", UpdateProjectAPI)
+	protected.Delete("/api/projects/:id", DeleteProjectAPI)
+
+	// Time entry import API routes
+	protected.Get("/api/imports", GetImportBatchesAPI)
+	protected.Post("/api/imports", ImportTimeEntriesAPI)
+	protected.Post("/api/imports/:id/rollback", RollbackImportBatchAPI)
+
+	// Report schedule and archive API routes
+	protected.Get("/api/reports/schedules", GetReportSchedulesAPI)
+	protected.Post("/api/reports/schedules", CreateReportScheduleAPI)
+	protected.Put("/api/reports/schedules/:id", UpdateReportScheduleAPI)
+	protected.Delete("/api/reports/schedules/:id", DeleteReportScheduleAPI)
+	protected.Post("/api/reports/schedules/:id/run", RunReportScheduleAPI)
+	protected.Get("/api/reports/archive", GetReportArchiveAPI)
+	protected.Get("/api/reports/archive/:id/download", DownloadArchivedReportAPI)
+	protected.Delete("/api/reports/archive/:id", DeleteArchivedReportAPI)
+
+	// Timesheet API routes
+	protected.Get("/api/timesheets", GetTimesheetsAPI)
+	protected.Post("/api/timesheets", CreateTimesheetsAPI)
+	protected.Get("/api/timesheets/:id", GetTimesheetAPI)
+	protected.Post("/api/timesheets/:id/submit", TimesheetTransitionHandler(database.TimesheetStatusSubmitted))
+	protected.Post("/api/timesheets/:id/approve", TimesheetTransitionHandler(database.TimesheetStatusApproved))
+	protected.Post("/api/timesheets/:id/reject", TimesheetTransitionHandler(database.TimesheetStatusRejected))
+	protected.Post("/api/timesheets/:id/reopen", TimesheetTransitionHandler(database.TimesheetStatusDraft))
+	protected.Post("/api/timesheets/:id/comments", AddTimesheetCommentAPI)
+
+	// Period lock API routes
+	protected.Get("/api/locks", GetPeriodLocksAPI)
+	protected.Post("/api/locks", CreatePeriodLockAPI)
+	protected.Post("/api/locks/:id/unlock", UnlockPeriodAPI)
+
+	// Absence API routes
+	protected.Get("/api/absences", GetAbsencesAPI)
+	protected.Post("/api/absences", CreateAbsenceAPI)
+	protected.Put("/api/absences/:id", UpdateAbsenceAPI)
+	protected.Delete("/api/absences/:id", DeleteAbsenceAPI)
+	protected.Post("/api/absences/:id/approve", AbsenceReviewHandler(database.AbsenceStatusApproved))
+	protected.Post("/api/absences/:id/reject", AbsenceReviewHandler(database.AbsenceStatusRejected))
+
+	// Holiday calendar API routes
+	protected.Get("/api/holidays/calendars", GetHolidayCalendarsAPI)
+	protected.Post("/api/holidays/calendars", CreateHolidayCalendarAPI)
+	protected.Get("/api/holidays/calendars/:id", GetHolidayCalendarAPI)
+	protected.Put("/api/holidays/calendars/:id", UpdateHolidayCalendarAPI)
+	protected.Delete("/api/holidays/calendars/:id", DeleteHolidayCalendarAPI)
+	protected.Post("/api/holidays/calendars/:id/import", ReimportHolidayCalendarAPI)
+
+	// Compliance API routes
+	protected.Get("/api/compliance/violations", GetComplianceViolationsAPI)
+	protected.Post("/api/compliance/evaluate", EvaluateComplianceAPI)
+	protected.Get("/api/compliance/rulesets", GetComplianceRuleSetsAPI)
+	protected.Post("/api/compliance/rulesets", CreateComplianceRuleSetAPI)
+	protected.Put("/api/compliance/rulesets/:id", UpdateComplianceRuleSetAPI)
+	protected.Delete("/api/compliance/rulesets/:id", DeleteComplianceRuleSetAPI)
+
+	// Audit log API routes
+	protected.Get("/api/audit", GetAuditLogsAPI)
+	protected.Get("/api/audit/export", ExportAuditLogsCSV)
 
 	// Log management API routes
 	protected.Get("/api/logs/stats", GetLogStatsAPI)
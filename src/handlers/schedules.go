@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/services"
+	"sports-excitement-team-management/src/utils"
+)
+
+// reportArchiveLimit is the default number of archived reports listed
+const reportArchiveLimit = 100
+
+// ReportScheduleRequest is the request body for creating or editing a report schedule
+type ReportScheduleRequest struct {
+	Name         *string `json:"name"`
+	Cron         *string `json:"cron"`
+	Type         *string `json:"type"`
+	Range        *string `json:"range"`
+	Format       *string `json:"format"`
+	GroupBy      *string `json:"group_by"`
+	Users        *string `json:"users"`
+	Teams        *string `json:"teams"`
+	Categories   *string `json:"categories"`
+	SlackChannel *string `json:"slack_channel"`
+	Enabled      *bool   `json:"enabled"`
+}
+
+// apply copies the provided fields of the request onto a schedule
+func (req ReportScheduleRequest) apply(schedule *database.ReportSchedule) {
+	if req.Name != nil {
+		schedule.Name = *req.Name
+	}
+	if req.Cron != nil {
+		schedule.Cron = *req.Cron
+	}
+	if req.Type != nil {
+		schedule.Type = *req.Type
+	}
+	if req.Range != nil {
+		schedule.Range = *req.Range
+	}
+	if req.Format != nil {
+		schedule.Format = *req.Format
+	}
+	if req.GroupBy != nil {
+		schedule.GroupBy = *req.GroupBy
+	}
+	if req.Users != nil {
+		schedule.Users = *req.Users
+	}
+	if req.Teams != nil {
+		schedule.Teams = *req.Teams
+	}
+	if req.Categories != nil {
+		schedule.Categories = *req.Categories
+	}
+	if req.SlackChannel != nil {
+		schedule.SlackChannel = *req.SlackChannel
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+}
+
+// scheduleErrorResponse maps report schedule validation errors to an HTTP response
+func scheduleErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, database.ErrInvalidReportSchedule) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// renderExport generates the file of an export format and returns its file name,
+// content type and content
+func renderExport(exporter *Exporter, params ExportParams) (string, string, []byte, error) {
+	data, err := exporter.Render(params)
+	return exporter.Filename(params) + "." + exporter.Extension, exporter.ContentType(), data, err
+}
+
+// renderScheduledReport generates the file of a schedule for the days in [from, to)
+// and returns its file name, content type and content
+func renderScheduledReport(schedule database.ReportSchedule, from, to time.Time) (string, string, []byte, error) {
+	switch schedule.Type {
+	case database.ReportTypeUsers:
+		if schedule.Format == "xlsx" {
+			workbook, err := buildReportWorkbook("User report", from, to)
+			if err != nil {
+				return "", "", nil, err
+			}
+			data, err := encodeXLSX(workbook)
+			return fmt.Sprintf("users_report_%s.xlsx", from.Format("2006-01-02")), xlsxContentType, data, err
+		}
+		// The users export format is a snapshot of the current summaries, so the
+		// schedule's range is rendered from the daily hours instead
+		rows, err := usersRangeCSVRows(from, to)
+		if err != nil {
+			return "", "", nil, err
+		}
+		data, err := encodeCSV(rows)
+		return fmt.Sprintf("users_report_%s.csv", from.Format("2006-01-02")), csvContentType, data, err
+
+	case database.ReportTypeWeekly:
+		weekStart := database.WeekStartOf(from)
+		if schedule.Format == "xlsx" {
+			workbook, err := buildReportWorkbook("Weekly report", weekStart, weekStart.AddDate(0, 0, 7))
+			if err != nil {
+				return "", "", nil, err
+			}
+			data, err := encodeXLSX(workbook)
+			return fmt.Sprintf("weekly_report_%s.xlsx", weekStart.Format("2006-01-02")), xlsxContentType, data, err
+		}
+		return renderExport(weeklyExporter, ExportParams{From: from, To: to, WeekStart: weekStart})
+
+	case database.ReportTypeTimesheets:
+		query, err := schedule.ReportQuery(from, to)
+		if err != nil {
+			return "", "", nil, err
+		}
+		sheets, err := database.GetMonthlyTimesheets(query, from, time.Now())
+		if err != nil {
+			return "", "", nil, err
+		}
+		data, err := renderTimesheetsPDF(sheets)
+		return fmt.Sprintf("timesheets_%s.pdf", from.Format("2006-01")), pdfContentType, data, err
+
+	default:
+		query, err := schedule.ReportQuery(from, to)
+		if err != nil {
+			return "", "", nil, err
+		}
+		report, err := database.RunReport(query)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		filename := reportFilename(report)
+		switch schedule.Format {
+		case "csv":
+			data, err := encodeCSV(reportCSVRows(report))
+			return filename + ".csv", csvContentType, data, err
+		case "xlsx":
+			filters := map[string]string{"users": schedule.Users, "teams": schedule.Teams, "categories": schedule.Categories}
+			data, err := encodeXLSX(reportWorkbook(report, filters))
+			return filename + ".xlsx", xlsxContentType, data, err
+		default:
+			data, err := json.MarshalIndent(report, "", "  ")
+			return filename + ".json", "application/json", data, err
+		}
+	}
+}
+
+// runReportSchedule generates a schedule's report for its range relative to now, stores
+// it in the archive and uploads it to the schedule's Slack channel. A failed upload is
+// recorded on the archived report and does not fail the run.
+func runReportSchedule(schedule database.ReportSchedule, now time.Time) (*database.ArchivedReport, error) {
+	from, to, err := database.ReportRangeBounds(schedule.Range, now)
+	if err != nil {
+		return nil, err
+	}
+
+	filename, contentType, data, err := renderScheduledReport(schedule, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	archived := &database.ArchivedReport{
+		ScheduleID:   schedule.ID,
+		ScheduleName: schedule.Name,
+		Filename:     filename,
+		ContentType:  contentType,
+		From:         from,
+		To:           to.AddDate(0, 0, -1),
+	}
+	if err := database.ArchiveReport(archived, data); err != nil {
+		return nil, err
+	}
+	utils.LogInfo("Generated scheduled report %s (%s)", schedule.Name, archived.Filename)
+
+	if schedule.SlackChannel == "" {
+		return archived, nil
+	}
+
+	archived.SlackChannel = schedule.SlackChannel
+	slackService := services.GetGlobalSlackService()
+	if slackService == nil || config.AppConfig == nil || config.AppConfig.SlackBotToken == "" {
+		archived.SlackError = "Slack is not configured"
+	} else {
+		comment := fmt.Sprintf("%s: %s to %s", schedule.Name, archived.From.Format("2006-01-02"), archived.To.Format("2006-01-02"))
+		fileID, err := slackService.UploadFile(schedule.SlackChannel, filename, schedule.Name, comment, data)
+		if err != nil {
+			archived.SlackError = err.Error()
+		}
+		archived.SlackFileID = fileID
+	}
+	if archived.SlackError != "" {
+		utils.LogError("Error uploading report %s to Slack channel %s: %s", schedule.Name, schedule.SlackChannel, archived.SlackError)
+	}
+	if err := database.UpdateArchivedReportSlack(archived); err != nil {
+		utils.LogError("Error recording Slack upload of report %s: %v", archived.Filename, err)
+	}
+	return archived, nil
+}
+
+// runDueReportSchedules runs every schedule whose next run has come. A schedule that
+// was missed while the server was down runs once on the next check.
+func runDueReportSchedules(now time.Time) {
+	schedules, err := database.GetDueReportSchedules(now)
+	if err != nil {
+		utils.LogError("Error loading due report schedules: %v", err)
+		return
+	}
+
+	for i := range schedules {
+		schedule := &schedules[i]
+		_, runErr := runReportSchedule(*schedule, now)
+		if runErr != nil {
+			utils.LogError("Error generating scheduled report %s: %v", schedule.Name, runErr)
+		}
+		if err := database.CompleteReportScheduleRun(schedule, now, runErr); err != nil {
+			utils.LogError("Error updating report schedule %s: %v", schedule.Name, err)
+		}
+	}
+}
+
+// pruneReportArchive removes archived reports past the retention period
+func pruneReportArchive(now time.Time) {
+	removed, err := database.PruneReportArchive(now)
+	if err != nil {
+		utils.LogError("Error pruning report archive: %v", err)
+	} else if removed > 0 {
+		utils.LogInfo("Removed %d expired reports from the archive", removed)
+	}
+}
+
+// StartReportScheduler checks the report schedules every minute and prunes the report
+// archive every hour
+func StartReportScheduler() {
+	go func() {
+		pruneReportArchive(time.Now())
+		runDueReportSchedules(time.Now())
+
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		lastPrune := time.Now()
+		for now := range ticker.C {
+			runDueReportSchedules(now)
+			if now.Sub(lastPrune) >= time.Hour {
+				pruneReportArchive(now)
+				lastPrune = now
+			}
+		}
+	}()
+}
+
+// loadReportSchedule loads the schedule of the id route parameter, or writes an error response
+func loadReportSchedule(c *fiber.Ctx) (*database.ReportSchedule, error) {
+	scheduleID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid schedule ID",
+		})
+	}
+
+	schedule, err := database.GetReportSchedule(scheduleID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report schedule not found",
+		})
+	}
+
+	return schedule, nil
+}
+
+// GetReportSchedulesAPI lists the report schedules
+func GetReportSchedulesAPI(c *fiber.Ctx) error {
+	schedules, err := database.GetReportSchedules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load report schedules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"schedules": schedules,
+	})
+}
+
+// CreateReportScheduleAPI creates a report schedule. Type defaults to weekly, range to
+// previous_week and format to csv.
+func CreateReportScheduleAPI(c *fiber.Ctx) error {
+	var req ReportScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	schedule := database.ReportSchedule{
+		Type:    database.ReportTypeWeekly,
+		Range:   database.ReportRangePreviousWeek,
+		Format:  "csv",
+		Enabled: true,
+	}
+	req.apply(&schedule)
+	if err := database.CreateReportSchedule(&schedule); err != nil {
+		return scheduleErrorResponse(c, err, "Failed to create report schedule")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityReportSchedule, schedule.ID, nil, schedule)
+
+	return c.Status(fiber.StatusCreated).JSON(schedule)
+}
+
+// UpdateReportScheduleAPI edits a report schedule
+func UpdateReportScheduleAPI(c *fiber.Ctx) error {
+	schedule, err := loadReportSchedule(c)
+	if schedule == nil {
+		return err
+	}
+
+	var req ReportScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *schedule
+	req.apply(schedule)
+	if err := database.UpdateReportSchedule(schedule); err != nil {
+		return scheduleErrorResponse(c, err, "Failed to update report schedule")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityReportSchedule, schedule.ID, before, schedule)
+
+	return c.JSON(schedule)
+}
+
+// DeleteReportScheduleAPI removes a report schedule
+func DeleteReportScheduleAPI(c *fiber.Ctx) error {
+	schedule, err := loadReportSchedule(c)
+	if schedule == nil {
+		return err
+	}
+
+	if err := database.DeleteReportSchedule(schedule.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete report schedule",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityReportSchedule, schedule.ID, schedule, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Report schedule deleted",
+	})
+}
+
+// RunReportScheduleAPI generates a schedule's report now without changing its next run
+func RunReportScheduleAPI(c *fiber.Ctx) error {
+	schedule, err := loadReportSchedule(c)
+	if schedule == nil {
+		return err
+	}
+
+	archived, err := runReportSchedule(*schedule, time.Now())
+	if err != nil {
+		utils.LogError("Error generating report %s: %v", schedule.Name, err)
+		if errors.Is(err, database.ErrInvalidReportSchedule) {
+			return scheduleErrorResponse(c, err, "Failed to generate report")
+		}
+		return reportErrorResponse(c, err, "Failed to generate report")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(archived)
+}
+
+// GetReportArchiveAPI lists archived reports, newest first, optionally filtered by
+// schedule_id and limited by limit (default 100)
+func GetReportArchiveAPI(c *fiber.Ctx) error {
+	var scheduleID uint
+	if scheduleParam := c.Query("schedule_id"); scheduleParam != "" {
+		id, err := strconv.ParseUint(scheduleParam, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid schedule_id",
+			})
+		}
+		scheduleID = uint(id)
+	}
+
+	limit := c.QueryInt("limit", reportArchiveLimit)
+	reports, err := database.GetArchivedReports(scheduleID, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load report archive",
+		})
+	}
+
+	retention := 0
+	if config.AppConfig != nil {
+		retention = config.AppConfig.ReportRetainDays
+	}
+	return c.JSON(fiber.Map{
+		"reports":        reports,
+		"retention_days": retention,
+	})
+}
+
+// loadArchivedReport loads the archived report of the id route parameter, or writes an
+// error response
+func loadArchivedReport(c *fiber.Ctx) (*database.ArchivedReport, error) {
+	reportID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid report ID",
+		})
+	}
+
+	report, err := database.GetArchivedReport(reportID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Archived report not found",
+		})
+	}
+
+	return report, nil
+}
+
+// DownloadArchivedReportAPI sends the file of an archived report
+func DownloadArchivedReportAPI(c *fiber.Ctx) error {
+	report, err := loadArchivedReport(c)
+	if report == nil {
+		return err
+	}
+
+	data, err := os.ReadFile(database.ArchivedReportPath(report))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Report file not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read report file",
+		})
+	}
+
+	c.Set("Content-Type", report.ContentType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", report.Filename))
+	return c.Send(data)
+}
+
+// DeleteArchivedReportAPI removes an archived report and its file
+func DeleteArchivedReportAPI(c *fiber.Ctx) error {
+	report, err := loadArchivedReport(c)
+	if report == nil {
+		return err
+	}
+
+	if err := database.DeleteArchivedReport(report); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete archived report",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityArchivedReport, report.ID, report, nil)
+
+	return c.JSON(fiber.Map{
+		"message": "Archived report deleted",
+	})
+}
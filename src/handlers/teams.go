@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/services"
+	"sports-excitement-team-management/src/utils"
+)
+
+// TeamRequest is the request body for creating or editing a team
+type TeamRequest struct {
+	Name          *string  `json:"name"`
+	Description   *string  `json:"description"`
+	WeeklyTarget  *float64 `json:"weekly_target"`  // 0 for the sum of the members' contracts
+	MonthlyTarget *float64 `json:"monthly_target"` // 0 for the sum of the members' contracts
+}
+
+// apply copies the provided fields of the request onto a team
+func (req TeamRequest) apply(team *database.Team) {
+	if req.Name != nil {
+		team.Name = *req.Name
+	}
+	if req.Description != nil {
+		team.Description = *req.Description
+	}
+	if req.WeeklyTarget != nil {
+		team.WeeklyTarget = *req.WeeklyTarget
+	}
+	if req.MonthlyTarget != nil {
+		team.MonthlyTarget = *req.MonthlyTarget
+	}
+}
+
+// TeamMembersRequest is the request body for adding users to a team
+type TeamMembersRequest struct {
+	UserIDs []uint `json:"user_ids"`
+}
+
+// teamErrorResponse maps team validation errors to an HTTP response
+func teamErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, database.ErrInvalidTeam) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// loadTeam loads the team referenced by the id route parameter
+func loadTeam(c *fiber.Ctx) (*database.Team, error) {
+	teamID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid team ID",
+		})
+	}
+
+	team, err := database.GetTeam(teamID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Team not found",
+		})
+	}
+
+	return team, nil
+}
+
+// teamSummaries returns the user summaries, limited to the members of the teams query
+// parameter (comma-separated names) if it is set
+func teamSummaries(c *fiber.Ctx) ([]database.UserSummary, error) {
+	summaries, err := database.GetUserSummaries()
+	if err != nil {
+		return nil, err
+	}
+
+	teams := database.SplitList(c.Query("teams"))
+	if len(teams) == 0 {
+		return summaries, nil
+	}
+
+	userIDs, err := database.TeamUserIDs(teams)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[uint]bool, len(userIDs))
+	for _, userID := range userIDs {
+		members[userID] = true
+	}
+
+	filtered := make([]database.UserSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if members[summary.UserID] {
+			filtered = append(filtered, summary)
+		}
+	}
+	return filtered, nil
+}
+
+// GetTeamsAPI lists teams with their members
+func GetTeamsAPI(c *fiber.Ctx) error {
+	teams, err := database.GetTeams()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load teams",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"teams": teams,
+	})
+}
+
+// GetTeamAnalyticsAPI returns the dashboard analytics of every team's active members.
+// Teams with their own targets report completion against them instead of the sum of
+// the members' contracts.
+func GetTeamAnalyticsAPI(c *fiber.Ctx) error {
+	teams, err := database.GetTeams()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load teams",
+		})
+	}
+	summaries, err := database.GetUserSummaries()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load analytics data",
+		})
+	}
+
+	byUser := make(map[uint]database.UserSummary, len(summaries))
+	for _, summary := range summaries {
+		byUser[summary.UserID] = summary
+	}
+
+	results := make([]fiber.Map, 0, len(teams))
+	for _, team := range teams {
+		var members []database.UserSummary
+		for _, member := range team.Members {
+			if summary, ok := byUser[member.UserID]; ok {
+				members = append(members, summary)
+			}
+		}
+
+		analytics := calculateAnalytics(members)
+		if team.WeeklyTarget > 0 {
+			analytics["weekly_target"] = team.WeeklyTarget
+			analytics["weekly_completion"] = analytics["total_weekly_hours"].(float64) / team.WeeklyTarget * 100
+		}
+		if team.MonthlyTarget > 0 {
+			analytics["monthly_target"] = team.MonthlyTarget
+			analytics["monthly_completion"] = analytics["total_monthly_hours"].(float64) / team.MonthlyTarget * 100
+		}
+		analytics["team_id"] = team.ID
+		analytics["team"] = team.Name
+		results = append(results, analytics)
+	}
+
+	return c.JSON(fiber.Map{
+		"teams": results,
+	})
+}
+
+// CreateTeamAPI adds a team
+func CreateTeamAPI(c *fiber.Ctx) error {
+	var req TeamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var team database.Team
+	req.apply(&team)
+	if err := database.CreateTeam(&team); err != nil {
+		return teamErrorResponse(c, err, "Failed to create team")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityTeam, team.ID, nil, team)
+
+	return c.Status(fiber.StatusCreated).JSON(team)
+}
+
+// UpdateTeamAPI edits a team. Renaming a team renames it on its users, holiday
+// calendars, compliance rule sets and report schedules.
+func UpdateTeamAPI(c *fiber.Ctx) error {
+	team, err := loadTeam(c)
+	if team == nil {
+		return err
+	}
+
+	var req TeamRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *team
+	req.apply(team)
+	if err := database.UpdateTeam(team, before.Name); err != nil {
+		return teamErrorResponse(c, err, "Failed to update team")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityTeam, team.ID, before, team)
+
+	return c.JSON(team)
+}
+
+// DeleteTeamAPI removes a team and its memberships
+func DeleteTeamAPI(c *fiber.Ctx) error {
+	team, err := loadTeam(c)
+	if team == nil {
+		return err
+	}
+
+	if err := database.DeleteTeam(team); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete team",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityTeam, team.ID, team, nil)
+	for _, member := range team.Members {
+		broadcastUserChange(member.UserID)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Team deleted",
+	})
+}
+
+// AddTeamMembersAPI adds users to a team
+func AddTeamMembersAPI(c *fiber.Ctx) error {
+	team, err := loadTeam(c)
+	if team == nil {
+		return err
+	}
+
+	var req TeamMembersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *team
+	if err := database.AddTeamMembers(team, req.UserIDs); err != nil {
+		return teamErrorResponse(c, err, "Failed to add team members")
+	}
+
+	updated, err := database.GetTeam(team.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load team",
+		})
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityTeam, team.ID, before, updated)
+
+	return c.JSON(updated)
+}
+
+// RemoveTeamMemberAPI removes a user from a team
+func RemoveTeamMemberAPI(c *fiber.Ctx) error {
+	team, err := loadTeam(c)
+	if team == nil {
+		return err
+	}
+
+	userID, err := parseIDParam(c, "userId")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	before := *team
+	if err := database.RemoveTeamMember(team, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User is not a member of this team",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove team member",
+		})
+	}
+
+	updated, err := database.GetTeam(team.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load team",
+		})
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityTeam, team.ID, before, updated)
+	broadcastUserChange(userID)
+
+	return c.JSON(updated)
+}
+
+// SyncTeamsAPI syncs teams from the Slack user groups and team channels now
+func SyncTeamsAPI(c *fiber.Ctx) error {
+	if !services.TeamSyncEnabled() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Slack team sync is not enabled. Set SLACK_USERGROUP_SYNC or SLACK_TEAM_CHANNELS",
+		})
+	}
+	slackService := services.GetGlobalSlackService()
+	if slackService == nil || config.AppConfig == nil || config.AppConfig.SlackBotToken == "" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Slack is not configured",
+		})
+	}
+
+	result, err := slackService.SyncTeams()
+	if err != nil {
+		utils.LogError("Error syncing teams from Slack: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to sync teams from Slack",
+		})
+	}
+	if hub := services.GetGlobalHub(); hub != nil {
+		hub.BroadcastAnalyticsUpdate()
+	}
+
+	return c.JSON(result)
+}
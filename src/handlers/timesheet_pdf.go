@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// Layout of printable timesheets, in points
+const (
+	pdfMargin    = 40.0
+	pdfRowHeight = 13.0
+	pdfFontSize  = 8.5
+	pdfFooter    = 30.0 // Space kept free for the page footer
+)
+
+// pdfColumn is a column of the timesheet table
+type pdfColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+// timesheetColumns are the columns of the timesheet table; they add up to the page width
+// between the margins
+var timesheetColumns = []pdfColumn{
+	{"Date", 62, false},
+	{"Time", 62, false},
+	{"Category", 80, false},
+	{"Note", 171, false},
+	{"Hours", 40, true},
+	{"Net", 40, true},
+	{"Required", 60, true},
+}
+
+// timesheetPDF lays out monthly timesheets page by page
+type timesheetPDF struct {
+	doc   *utils.PDFDocument
+	sheet *database.MonthlyTimesheet
+	y     float64
+}
+
+// userDisplayName returns the real name of a user, or the Slack name without one
+func userDisplayName(user database.User) string {
+	if user.RealName != "" {
+		return user.RealName
+	}
+	return user.Name
+}
+
+// newPage starts a page. Continuation pages repeat the user and month, and the table
+// header if the table continues.
+func (p *timesheetPDF) newPage(continued, table bool) {
+	p.doc.AddPage()
+	p.y = pdfMargin
+	if !continued {
+		return
+	}
+
+	p.doc.SetGray(0.4)
+	p.doc.Text(pdfMargin, p.y+8, pdfFontSize, false,
+		fmt.Sprintf("%s - %s (continued)", userDisplayName(p.sheet.User), p.sheet.Month.Format("January 2006")))
+	p.doc.SetGray(0)
+	p.y += 16
+	if table {
+		p.tableHeader()
+	}
+}
+
+// ensure starts a new page unless height fits above the footer
+func (p *timesheetPDF) ensure(height float64, table bool) {
+	if p.y+height > utils.PDFPageHeight-pdfMargin-pdfFooter {
+		p.newPage(true, table)
+	}
+}
+
+// row draws one table row; empty values leave their cell blank
+func (p *timesheetPDF) row(bold bool, values ...string) {
+	x := pdfMargin
+	for i, column := range timesheetColumns {
+		if i < len(values) && values[i] != "" {
+			text := utils.PDFFitText(values[i], column.width-4, pdfFontSize, bold)
+			if column.right {
+				p.doc.TextRight(x+column.width-2, p.y+9.5, pdfFontSize, bold, text)
+			} else {
+				p.doc.Text(x+2, p.y+9.5, pdfFontSize, bold, text)
+			}
+		}
+		x += column.width
+	}
+	p.y += pdfRowHeight
+}
+
+// tableHeader draws the shaded header row of the timesheet table
+func (p *timesheetPDF) tableHeader() {
+	p.doc.SetGray(0.88)
+	p.doc.Rect(pdfMargin, p.y, utils.PDFPageWidth-2*pdfMargin, pdfRowHeight+1, true)
+	p.doc.SetGray(0)
+	titles := make([]string, len(timesheetColumns))
+	for i, column := range timesheetColumns {
+		titles[i] = column.title
+	}
+	p.y += 1
+	p.row(true, titles...)
+}
+
+// header draws the title, the user and the summary of hours
+func (p *timesheetPDF) header() {
+	sheet := p.sheet
+	p.doc.Text(pdfMargin, p.y+16, 18, true, "Timesheet")
+	p.doc.TextRight(utils.PDFPageWidth-pdfMargin, p.y+16, 12, true, sheet.Month.Format("January 2006"))
+	p.y += 32
+
+	p.doc.Text(pdfMargin, p.y+10, 11, true, userDisplayName(sheet.User))
+	p.y += 14
+	details := sheet.User.Email
+	if sheet.User.Team != "" {
+		details += " - Team " + sheet.User.Team
+	}
+	p.doc.SetGray(0.4)
+	p.doc.Text(pdfMargin, p.y+9, 9, false, details)
+	p.doc.SetGray(0)
+	p.y += 20
+
+	summary := [][2]string{
+		{"Required", formatHours(sheet.RequiredHours)},
+		{"Tracked", formatHours(sheet.GrossHours)},
+		{"Break deduction", formatHours(sheet.BreakHours)},
+		{"Net", formatHours(sheet.NetHours)},
+		{"Balance", fmt.Sprintf("%+.2f", sheet.BalanceHours)},
+	}
+	if sheet.Rounding.Enabled() {
+		summary = append(summary, [2]string{"Rounded", formatHours(sheet.RoundedHours)})
+	}
+
+	width := (utils.PDFPageWidth - 2*pdfMargin) / float64(len(summary))
+	p.doc.SetGray(0.95)
+	p.doc.Rect(pdfMargin, p.y, utils.PDFPageWidth-2*pdfMargin, 34, true)
+	for i, item := range summary {
+		x := pdfMargin + float64(i)*width + 8
+		p.doc.SetGray(0.4)
+		p.doc.Text(x, p.y+12, 7.5, false, item[0])
+		p.doc.SetGray(0)
+		p.doc.Text(x, p.y+27, 12, true, item[1])
+	}
+	p.y += 46
+}
+
+// days draws the table of days with their entries, absences and holidays
+func (p *timesheetPDF) days() {
+	p.tableHeader()
+
+	for _, day := range p.sheet.Days {
+		var lines [][]string
+		for _, entry := range day.Entries {
+			start := entry.StartTime.Local()
+			end, endLabel := time.Now(), "now"
+			if entry.EndTime != nil {
+				end = *entry.EndTime
+				endLabel = end.Local().Format("15:04")
+			}
+			note := entry.Note
+			if note == "" {
+				note = entry.StatusText
+			}
+			lines = append(lines, []string{"", start.Format("15:04") + "-" + endLabel, entry.Status, note,
+				formatHours(end.Sub(entry.StartTime).Hours())})
+		}
+		if day.BreakHours > 0 {
+			lines = append(lines, []string{"", "", "Break deduction", "Unrecorded break", formatHours(-day.BreakHours)})
+		}
+
+		var labels []string
+		if day.Holiday != "" {
+			labels = append(labels, "Holiday: "+day.Holiday)
+		}
+		if day.Absence != "" {
+			label := strings.ToUpper(day.Absence[:1]) + day.Absence[1:]
+			if day.AbsenceShare < 1 {
+				label += " (half day)"
+			}
+			labels = append(labels, label)
+		}
+		if len(labels) > 0 {
+			lines = append([][]string{{"", "", strings.Join(labels, ", ")}}, lines...)
+		}
+		if len(lines) == 0 {
+			lines = [][]string{{}}
+		}
+
+		p.ensure(float64(len(lines))*pdfRowHeight, true)
+		lines[0] = append(lines[0], make([]string, len(timesheetColumns)-len(lines[0]))...)
+		lines[0][0] = day.Date.Format("Mon 02 Jan")
+		if day.GrossHours > 0 || day.RequiredHours > 0 {
+			lines[0][5] = formatHours(day.NetHours)
+			lines[0][6] = formatHours(day.RequiredHours)
+		}
+
+		offDay := day.RequiredHours == 0 && len(day.Entries) == 0
+		if offDay {
+			p.doc.SetGray(0.55)
+		}
+		for _, line := range lines {
+			p.row(false, line...)
+		}
+		p.doc.SetGray(0.85)
+		p.doc.Line(pdfMargin, p.y, utils.PDFPageWidth-pdfMargin, p.y, 0.3)
+		p.doc.SetGray(0)
+	}
+
+	p.ensure(pdfRowHeight+4, true)
+	p.doc.Line(pdfMargin, p.y, utils.PDFPageWidth-pdfMargin, p.y, 0.8)
+	p.y += 2
+	p.row(true, "Total", "", "", "", formatHours(p.sheet.GrossHours), formatHours(p.sheet.NetHours), formatHours(p.sheet.RequiredHours))
+	p.y += 10
+}
+
+// absences lists the approved absences overlapping the month
+func (p *timesheetPDF) absences() {
+	if len(p.sheet.Absences) == 0 {
+		return
+	}
+
+	p.ensure(2*pdfRowHeight+6, false)
+	p.doc.Text(pdfMargin, p.y+10, 10, true, "Absences")
+	p.y += 16
+	for _, absence := range p.sheet.Absences {
+		p.ensure(pdfRowHeight, false)
+		text := fmt.Sprintf("%s%s: %s to %s", strings.ToUpper(absence.Type[:1]), absence.Type[1:],
+			absence.StartDate.Format("2006-01-02"), absence.EndDate.Format("2006-01-02"))
+		if absence.StartHalfDay || absence.EndHalfDay {
+			text += " (half days)"
+		}
+		if absence.Note != "" {
+			text += " - " + absence.Note
+		}
+		p.doc.Text(pdfMargin+2, p.y+9.5, pdfFontSize, false, utils.PDFFitText(text, utils.PDFPageWidth-2*pdfMargin, pdfFontSize, false))
+		p.y += pdfRowHeight
+	}
+	p.y += 10
+}
+
+// approval draws the weekly sign-off states and the signature block
+func (p *timesheetPDF) approval() {
+	p.ensure(float64(len(p.sheet.Approvals))*pdfRowHeight+110, false)
+	p.doc.Text(pdfMargin, p.y+10, 10, true, "Approval")
+	p.y += 16
+
+	var approver string
+	var approvedAt time.Time
+	for _, approval := range p.sheet.Approvals {
+		status := approval.Status
+		if status == "" {
+			status = "no timesheet"
+		}
+		text := fmt.Sprintf("Week of %s: %s", approval.WeekStart.Format("2006-01-02"), status)
+		if approval.ReviewedAt != nil && approval.Status == database.TimesheetStatusApproved {
+			text += fmt.Sprintf(" by %s on %s", approval.ReviewedBy, approval.ReviewedAt.Local().Format("2006-01-02"))
+			if approval.ReviewedAt.After(approvedAt) {
+				approver, approvedAt = approval.ReviewedBy, *approval.ReviewedAt
+			}
+		}
+		p.doc.Text(pdfMargin+2, p.y+9.5, pdfFontSize, false, text)
+		p.y += pdfRowHeight
+	}
+	p.y += 14
+
+	width := (utils.PDFPageWidth - 2*pdfMargin - 40) / 2
+	for i, label := range []string{"Employee / contractor", "Approved by"} {
+		x := pdfMargin + float64(i)*(width+40)
+		if i == 1 && p.sheet.Approved && approver != "" {
+			p.doc.Text(x, p.y+30, 10, false, fmt.Sprintf("%s, %s", approver, approvedAt.Local().Format("2006-01-02")))
+		}
+		p.doc.Line(x, p.y+36, x+width, p.y+36, 0.6)
+		p.doc.SetGray(0.4)
+		p.doc.Text(x, p.y+46, 7.5, false, label+" - name, date, signature")
+		p.doc.SetGray(0)
+	}
+	p.y += 56
+}
+
+// renderTimesheetsPDF renders monthly timesheets into one document, each starting on a new page
+func renderTimesheetsPDF(sheets []database.MonthlyTimesheet) ([]byte, error) {
+	title := "Timesheets"
+	if len(sheets) == 1 {
+		title = fmt.Sprintf("Timesheet %s %s", userDisplayName(sheets[0].User), sheets[0].Month.Format("January 2006"))
+	}
+	doc := utils.NewPDFDocument(title)
+
+	for i := range sheets {
+		layout := &timesheetPDF{doc: doc, sheet: &sheets[i]}
+		layout.newPage(false, false)
+		layout.header()
+		layout.days()
+		layout.absences()
+		layout.approval()
+	}
+	if len(sheets) == 0 {
+		doc.AddPage()
+		doc.Text(pdfMargin, pdfMargin+10, 10, false, "No timesheets in this period")
+	}
+
+	generated := time.Now().Format("2006-01-02 15:04")
+	for page := 0; page < doc.PageCount(); page++ {
+		doc.SetPage(page)
+		doc.SetGray(0.5)
+		footerY := utils.PDFPageHeight - pdfMargin + 10
+		doc.Text(pdfMargin, footerY, 7.5, false, "Generated "+generated)
+		doc.TextRight(utils.PDFPageWidth-pdfMargin, footerY, 7.5, false, fmt.Sprintf("Page %d of %d", page+1, doc.PageCount()))
+		doc.SetGray(0)
+	}
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetUserMonthlyTimesheetAPI returns a user's printable timesheet for a month
+// (month=YYYY-MM, default: the current month) as PDF, or as JSON with format=json
+func GetUserMonthlyTimesheetAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	month := time.Now()
+	if monthParam := c.Query("month"); monthParam != "" {
+		month, err = time.ParseInLocation("2006-01", monthParam, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid month format. Use YYYY-MM",
+			})
+		}
+	}
+
+	format := c.Query("format", "pdf")
+	if format != "pdf" && format != "json" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid format. Use pdf or json",
+		})
+	}
+
+	sheet, err := database.GetMonthlyTimesheet(userID, month, time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load timesheet",
+		})
+	}
+	if format == "json" {
+		return c.JSON(sheet)
+	}
+
+	data, err := renderTimesheetsPDF([]database.MonthlyTimesheet{*sheet})
+	if err != nil {
+		utils.LogError("Error rendering timesheet PDF: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to render timesheet",
+		})
+	}
+
+	c.Set("Content-Type", pdfContentType)
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=timesheet_%s_%s.pdf", sheet.User.Name, sheet.Month.Format("2006-01")))
+	return c.Send(data)
+}
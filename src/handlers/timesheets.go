@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/services"
+	"sports-excitement-team-management/src/utils"
+)
+
+// TimesheetRequest is the request body for generating timesheets
+type TimesheetRequest struct {
+	UserID uint   `json:"user_id"` // Omit to generate drafts for all active users
+	Week   string `json:"week"`    // Any date within the week (YYYY-MM-DD), defaults to the current week
+}
+
+// TimesheetActionRequest is the request body for timesheet state changes and comments
+type TimesheetActionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// parseWeekParam parses an optional YYYY-MM-DD date and returns the Monday of its week
+func parseWeekParam(week string) (time.Time, error) {
+	if week == "" {
+		return database.WeekStartOf(time.Now()), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", week, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return database.WeekStartOf(date), nil
+}
+
+// notifyTimesheetStatus sends the timesheet owner a Slack DM about a state change
+func notifyTimesheetStatus(timesheet *database.Timesheet, comment string) {
+	slackService := services.GetGlobalSlackService()
+	if slackService == nil {
+		return
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, timesheet.UserID).Error; err != nil {
+		utils.LogError("Error loading user %d for timesheet notification: %v", timesheet.UserID, err)
+		return
+	}
+
+	text := fmt.Sprintf("Your timesheet for the week of %s (%.2f h tracked) is now *%s*.",
+		timesheet.WeekStart.Format("Jan 02, 2006"), timesheet.TotalHours, timesheet.Status)
+	if comment != "" {
+		text += "\n> " + comment
+	}
+
+	if err := slackService.SendDirectMessage(user.SlackUserID, text); err != nil {
+		utils.LogError("Error sending timesheet notification to %s: %v", user.Name, err)
+	}
+}
+
+// GetTimesheetsAPI lists timesheets filtered by user_id, week and status
+func GetTimesheetsAPI(c *fiber.Ctx) error {
+	filter := database.TimesheetFilter{
+		UserID: uint(c.QueryInt("user_id", 0)),
+		Status: c.Query("status"),
+	}
+
+	if week := c.Query("week"); week != "" {
+		weekStart, err := parseWeekParam(week)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid week format. Use YYYY-MM-DD",
+			})
+		}
+		filter.WeekStart = &weekStart
+	}
+
+	timesheets, err := database.GetTimesheets(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load timesheets",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"timesheets": timesheets,
+	})
+}
+
+// GetTimesheetAPI returns a single timesheet with its comments
+func GetTimesheetAPI(c *fiber.Ctx) error {
+	timesheetID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid timesheet ID",
+		})
+	}
+
+	timesheet, err := database.GetTimesheet(timesheetID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Timesheet not found",
+		})
+	}
+
+	return c.JSON(timesheet)
+}
+
+// CreateTimesheetsAPI generates draft timesheets for a week from the weekly report
+func CreateTimesheetsAPI(c *fiber.Ctx) error {
+	var req TimesheetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	weekStart, err := parseWeekParam(req.Week)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid week format. Use YYYY-MM-DD",
+		})
+	}
+
+	userIDs := []uint{req.UserID}
+	if req.UserID == 0 {
+		userIDs = nil
+		if err := database.DB.Model(&database.User{}).Where("is_active = ?", true).Pluck("id", &userIDs).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to load users",
+			})
+		}
+	}
+
+	var timesheets []database.Timesheet
+	for _, userID := range userIDs {
+		timesheet, err := database.GetOrCreateTimesheet(userID, weekStart)
+		if err != nil {
+			utils.LogError("Error creating timesheet for user %d: %v", userID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to create timesheets",
+			})
+		}
+		timesheets = append(timesheets, *timesheet)
+	}
+
+	return c.JSON(fiber.Map{
+		"timesheets": timesheets,
+		"week_start": weekStart.Format("2006-01-02"),
+	})
+}
+
+// TimesheetTransitionHandler returns a handler that moves a timesheet to the given state
+func TimesheetTransitionHandler(status string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		timesheetID, err := parseIDParam(c, "id")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid timesheet ID",
+			})
+		}
+
+		var req TimesheetActionRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid request body",
+				})
+			}
+		}
+		req.Comment = strings.TrimSpace(req.Comment)
+
+		if status == database.TimesheetStatusRejected && req.Comment == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "A comment is required when rejecting a timesheet",
+			})
+		}
+
+		timesheet, err := database.GetTimesheet(timesheetID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Timesheet not found",
+			})
+		}
+
+		adminID, username := currentAdmin(c)
+		before := *timesheet
+
+		if err := database.TransitionTimesheet(timesheet, status, adminID); err != nil {
+			if errors.Is(err, database.ErrInvalidTimesheetTransition) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": fmt.Sprintf("Cannot change timesheet from %s to %s", before.Status, status),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update timesheet",
+			})
+		}
+
+		if req.Comment != "" {
+			if _, err := database.AddTimesheetComment(timesheet.ID, adminID, username, status, req.Comment); err != nil {
+				utils.LogError("Error saving timesheet comment: %v", err)
+			}
+		}
+
+		recordAudit(c, database.AuditActionUpdate, database.AuditEntityTimesheet, timesheet.ID, before, timesheet)
+		go notifyTimesheetStatus(timesheet, req.Comment)
+
+		timesheet, err = database.GetTimesheet(timesheet.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to load timesheet",
+			})
+		}
+
+		return c.JSON(timesheet)
+	}
+}
+
+// AddTimesheetCommentAPI adds a comment to a timesheet without changing its state
+func AddTimesheetCommentAPI(c *fiber.Ctx) error {
+	timesheetID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid timesheet ID",
+		})
+	}
+
+	var req TimesheetActionRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Comment) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "comment is required",
+		})
+	}
+
+	timesheet, err := database.GetTimesheet(timesheetID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Timesheet not found",
+		})
+	}
+
+	adminID, username := currentAdmin(c)
+	comment, err := database.AddTimesheetComment(timesheet.ID, adminID, username, timesheet.Status, strings.TrimSpace(req.Comment))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save comment",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(comment)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/services"
+	"sports-excitement-team-management/src/utils"
+)
+
+// TrackingRuleRequest is the request body for creating or editing a tracking rule
+type TrackingRuleRequest struct {
+	Kind   *string `json:"kind"`   // usergroup, channel, email_domain, guest or user
+	Value  *string `json:"value"`  // User group ID, channel ID, domain, or Slack user ID or email
+	Action *string `json:"action"` // include (default) or exclude
+	Note   *string `json:"note"`
+}
+
+// apply copies the provided fields of the request onto a tracking rule
+func (req TrackingRuleRequest) apply(rule *database.TrackingRule) {
+	if req.Kind != nil {
+		rule.Kind = *req.Kind
+	}
+	if req.Value != nil {
+		rule.Value = *req.Value
+	}
+	if req.Action != nil {
+		rule.Action = *req.Action
+	}
+	if req.Note != nil {
+		rule.Note = *req.Note
+	}
+}
+
+// trackingRuleErrorResponse maps tracking rule validation errors to an HTTP response
+func trackingRuleErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, database.ErrInvalidTrackingRule) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// loadTrackingRule loads the tracking rule referenced by the id route parameter
+func loadTrackingRule(c *fiber.Ctx) (*database.TrackingRule, error) {
+	ruleID, err := parseIDParam(c, "id")
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tracking rule ID",
+		})
+	}
+
+	rule, err := database.GetTrackingRule(ruleID)
+	if err != nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Tracking rule not found",
+		})
+	}
+
+	return rule, nil
+}
+
+// applyTrackingRules re-syncs users from Slack in the background so that a rule change
+// creates the newly tracked users and deactivates the excluded ones
+func applyTrackingRules() {
+	slackService := services.GetGlobalSlackService()
+	if slackService == nil || config.AppConfig == nil || config.AppConfig.SlackBotToken == "" {
+		return
+	}
+
+	go func() {
+		if err := slackService.SyncUsers(); err != nil {
+			utils.LogError("Error syncing users after a tracking rule change: %v", err)
+		}
+	}()
+}
+
+// GetTrackingRulesAPI lists the tracking rules
+func GetTrackingRulesAPI(c *fiber.Ctx) error {
+	rules, err := database.GetTrackingRules()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load tracking rules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"rules": rules,
+	})
+}
+
+// CreateTrackingRuleAPI adds a tracking rule
+func CreateTrackingRuleAPI(c *fiber.Ctx) error {
+	var req TrackingRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	var rule database.TrackingRule
+	req.apply(&rule)
+	if err := database.CreateTrackingRule(&rule); err != nil {
+		return trackingRuleErrorResponse(c, err, "Failed to create tracking rule")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityTrackingRule, rule.ID, nil, rule)
+	applyTrackingRules()
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+// UpdateTrackingRuleAPI edits a tracking rule
+func UpdateTrackingRuleAPI(c *fiber.Ctx) error {
+	rule, err := loadTrackingRule(c)
+	if rule == nil {
+		return err
+	}
+
+	var req TrackingRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *rule
+	req.apply(rule)
+	if err := database.UpdateTrackingRule(rule); err != nil {
+		return trackingRuleErrorResponse(c, err, "Failed to update tracking rule")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityTrackingRule, rule.ID, before, rule)
+	applyTrackingRules()
+
+	return c.JSON(rule)
+}
+
+// DeleteTrackingRuleAPI removes a tracking rule
+func DeleteTrackingRuleAPI(c *fiber.Ctx) error {
+	rule, err := loadTrackingRule(c)
+	if rule == nil {
+		return err
+	}
+
+	if err := database.DeleteTrackingRule(rule.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete tracking rule",
+		})
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityTrackingRule, rule.ID, rule, nil)
+	applyTrackingRules()
+
+	return c.JSON(fiber.Map{
+		"message": "Tracking rule deleted",
+	})
+}
@@ -0,0 +1,394 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/services"
+)
+
+// TimeEntryRequest is the request body for creating or editing a time entry
+type TimeEntryRequest struct {
+	StartTime *time.Time `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	Category  *string    `json:"category"`
+	Note      *string    `json:"note"`
+	ProjectID *uint      `json:"project_id"` // 0 removes the entry from its project
+}
+
+// entryProjectID returns the project an entry is booked on by a request, nil for 0
+func (req TimeEntryRequest) entryProjectID() *uint {
+	if req.ProjectID == nil || *req.ProjectID == 0 {
+		return nil
+	}
+	return req.ProjectID
+}
+
+// parseIDParam parses a numeric route parameter
+func parseIDParam(c *fiber.Ctx, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Params(name), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// timeEntryErrorResponse maps time entry validation errors to an HTTP response
+func timeEntryErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, database.ErrInvalidTimeRange), errors.Is(err, database.ErrInvalidProject):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, database.ErrTimeEntryOverlap):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, database.ErrPeriodLocked):
+		return c.Status(fiber.StatusLocked).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fallback,
+		})
+	}
+}
+
+// broadcastUserChange notifies dashboard clients that a user's data changed
+func broadcastUserChange(userID uint) {
+	if hub := services.GetGlobalHub(); hub != nil {
+		hub.BroadcastUserUpdate(userID)
+	}
+}
+
+// GetUserDetails returns detailed information about a specific user
+func GetUserDetails(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user database.User
+	result := database.DB.Preload("TimeEntries").First(&user, userID)
+	if result.Error != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	return c.JSON(user)
+}
+
+// UserUpdateRequest is the request body for editing a tracked user
+type UserUpdateRequest struct {
+	IsActive        *bool   `json:"is_active"`
+	Country         *string `json:"country"`          // ISO country code, selects holiday calendars
+	Team            *string `json:"team"`             // Primary team name, selects holiday calendars
+	PersonnelNumber *string `json:"personnel_number"` // Employee number in the payroll system
+}
+
+// UpdateUserAPI edits the admin-managed fields of a tracked user
+func UpdateUserAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	var req UserUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := user
+	if req.IsActive != nil {
+		user.IsActive = *req.IsActive
+		user.InactiveBy = ""
+		if !user.IsActive {
+			user.InactiveBy = database.UserInactiveByAdmin
+		}
+	}
+	if req.Country != nil {
+		user.Country = strings.ToUpper(strings.TrimSpace(*req.Country))
+	}
+	if req.Team != nil {
+		user.Team = strings.TrimSpace(*req.Team)
+		if strings.Contains(user.Team, ",") {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Team name must not contain commas",
+			})
+		}
+	}
+	if req.PersonnelNumber != nil {
+		user.PersonnelNo = strings.TrimSpace(*req.PersonnelNumber)
+	}
+
+	if err := database.DB.Save(&user).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update user",
+		})
+	}
+	if user.Team != before.Team {
+		if err := database.SyncPrimaryTeam(&user, before.Team); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update team membership",
+			})
+		}
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityUser, user.ID, before, user)
+	broadcastUserChange(user.ID)
+
+	return c.JSON(user)
+}
+
+// GetUserTimeEntries returns a user's time entries, optionally limited by from/to dates
+func GetUserTimeEntries(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	// Default to the last 30 days
+	to := time.Now().AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -31)
+
+	if fromParam := c.Query("from"); fromParam != "" {
+		from, err = time.ParseInLocation("2006-01-02", fromParam, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from format. Use YYYY-MM-DD",
+			})
+		}
+	}
+	if toParam := c.Query("to"); toParam != "" {
+		to, err = time.ParseInLocation("2006-01-02", toParam, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid to format. Use YYYY-MM-DD",
+			})
+		}
+		to = to.AddDate(0, 0, 1) // Include the whole end day
+	}
+
+	entries, err := database.GetUserTimeEntries(userID, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load time entries",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": entries,
+		"from":    from.Format("2006-01-02"),
+		"to":      to.AddDate(0, 0, -1).Format("2006-01-02"),
+	})
+}
+
+// GetUserTimelineAPI returns a user's status changes and time entries on one day
+// (default: today) as ordered timeline segments
+func GetUserTimelineAPI(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	date := time.Now()
+	if dateParam := c.Query("date"); dateParam != "" {
+		date, err = time.ParseInLocation("2006-01-02", dateParam, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid date format. Use YYYY-MM-DD",
+			})
+		}
+	}
+
+	timeline, err := database.GetUserTimeline(user.ID, date, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load timeline",
+		})
+	}
+
+	return c.JSON(timeline)
+}
+
+// CreateUserTimeEntry creates a manual time entry for a user
+func CreateUserTimeEntry(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var user database.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	var req TimeEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.StartTime == nil || req.EndTime == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "start_time and end_time are required",
+		})
+	}
+
+	category, note := "", ""
+	if req.Category != nil {
+		category = *req.Category
+	}
+	if req.Note != nil {
+		note = *req.Note
+	}
+
+	entry, err := database.CreateManualTimeEntry(user.ID, *req.StartTime, *req.EndTime, category, note, req.entryProjectID())
+	if err != nil {
+		return timeEntryErrorResponse(c, err, "Failed to create time entry")
+	}
+
+	recordAudit(c, database.AuditActionCreate, database.AuditEntityTimeEntry, entry.ID, nil, entry)
+	broadcastUserChange(user.ID)
+
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// UpdateUserTimeEntry edits an existing time entry of a user
+func UpdateUserTimeEntry(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	entryID, err := parseIDParam(c, "entryId")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid entry ID",
+		})
+	}
+
+	entry, err := database.GetTimeEntry(entryID)
+	if err != nil || entry.UserID != userID {
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to load time entry",
+			})
+		}
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Time entry not found",
+		})
+	}
+
+	var req TimeEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	before := *entry
+	// Only a changed start or end makes an entry manual; a Slack entry keeps its
+	// source when just its note, category or project is edited
+	rangeChanged := (req.StartTime != nil && !req.StartTime.Equal(entry.StartTime)) ||
+		(req.EndTime != nil && (entry.EndTime == nil || !req.EndTime.Equal(*entry.EndTime)))
+
+	if req.StartTime != nil {
+		entry.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		entry.EndTime = req.EndTime
+	}
+	if req.Category != nil && *req.Category != "" {
+		entry.Status = *req.Category
+	}
+	if req.Note != nil {
+		entry.Note = *req.Note
+	}
+	if req.ProjectID != nil {
+		entry.ProjectID = req.entryProjectID()
+	}
+	if rangeChanged {
+		entry.Source = database.TimeEntrySourceManual
+	}
+
+	if err := database.UpdateTimeEntry(entry); err != nil {
+		return timeEntryErrorResponse(c, err, "Failed to update time entry")
+	}
+
+	recordAudit(c, database.AuditActionUpdate, database.AuditEntityTimeEntry, entry.ID, before, entry)
+	broadcastUserChange(userID)
+
+	return c.JSON(entry)
+}
+
+// DeleteUserTimeEntry removes a time entry of a user
+func DeleteUserTimeEntry(c *fiber.Ctx) error {
+	userID, err := parseIDParam(c, "id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	entryID, err := parseIDParam(c, "entryId")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid entry ID",
+		})
+	}
+
+	entry, err := database.GetTimeEntry(entryID)
+	if err != nil || entry.UserID != userID {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Time entry not found",
+		})
+	}
+
+	if err := database.DeleteTimeEntry(entry.ID); err != nil {
+		return timeEntryErrorResponse(c, err, "Failed to delete time entry")
+	}
+
+	recordAudit(c, database.AuditActionDelete, database.AuditEntityTimeEntry, entry.ID, entry, nil)
+	broadcastUserChange(userID)
+
+	return c.JSON(fiber.Map{
+		"message": "Time entry deleted",
+	})
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"sports-excitement-team-management/src/database"
+)
+
+func TestUpdateUserTimeEntry(t *testing.T) {
+	openTestDB(t)
+	user := createTestUser(t, "U1")
+	now := time.Now()
+
+	app := fiber.New()
+	app.Put("/api/users/:id/entries/:entryId", UpdateUserTimeEntry)
+	update := func(t *testing.T, entry *database.TimeEntry, body string) (int, database.TimeEntry) {
+		t.Helper()
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/users/%d/entries/%d", user.ID, entry.ID), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		var saved database.TimeEntry
+		if resp.StatusCode == fiber.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&saved); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+		}
+		return resp.StatusCode, saved
+	}
+
+	t.Run("note of a Slack entry keeps its source", func(t *testing.T) {
+		entry := createClosedEntry(t, user.ID, now.Add(-48*time.Hour), now.Add(-46*time.Hour))
+		database.DB.Model(entry).Update("source", database.TimeEntrySourceSlack)
+
+		status, saved := update(t, entry, `{"note": "Training", "category": "Meeting"}`)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d, want 200", status)
+		}
+		if saved.Source != database.TimeEntrySourceSlack || saved.Note != "Training" || saved.Status != "Meeting" {
+			t.Errorf("saved source %q, note %q, category %q", saved.Source, saved.Note, saved.Status)
+		}
+	})
+
+	t.Run("unchanged times keep the source", func(t *testing.T) {
+		entry := createClosedEntry(t, user.ID, now.Add(-72*time.Hour), now.Add(-70*time.Hour))
+		database.DB.Model(entry).Update("source", database.TimeEntrySourceSlack)
+
+		body := fmt.Sprintf(`{"start_time": %q, "end_time": %q, "note": "Same"}`,
+			entry.StartTime.Format(time.RFC3339Nano), entry.EndTime.Format(time.RFC3339Nano))
+		if status, saved := update(t, entry, body); status != fiber.StatusOK || saved.Source != database.TimeEntrySourceSlack {
+			t.Errorf("status %d, source %q, want 200 and slack", status, saved.Source)
+		}
+	})
+
+	t.Run("changed end makes a Slack entry manual", func(t *testing.T) {
+		entry := createClosedEntry(t, user.ID, now.Add(-24*time.Hour), now.Add(-22*time.Hour))
+		database.DB.Model(entry).Update("source", database.TimeEntrySourceSlack)
+
+		body := fmt.Sprintf(`{"end_time": %q}`, entry.EndTime.Add(time.Hour).Format(time.RFC3339Nano))
+		status, saved := update(t, entry, body)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d, want 200", status)
+		}
+		if saved.Source != database.TimeEntrySourceManual || saved.Duration != int64((3*time.Hour).Seconds()) {
+			t.Errorf("saved source %q, duration %d", saved.Source, saved.Duration)
+		}
+	})
+
+	t.Run("running entry can be edited without end time", func(t *testing.T) {
+		entry := database.TimeEntry{UserID: user.ID, StartTime: now.Add(-time.Hour), Status: "Working", Source: database.TimeEntrySourceSlack}
+		if err := database.DB.Create(&entry).Error; err != nil {
+			t.Fatalf("creating entry: %v", err)
+		}
+
+		status, saved := update(t, &entry, `{"note": "Match day"}`)
+		if status != fiber.StatusOK {
+			t.Fatalf("status = %d, want 200", status)
+		}
+		if saved.EndTime != nil || saved.Note != "Match day" || saved.Source != database.TimeEntrySourceSlack {
+			t.Errorf("saved end %v, note %q, source %q; want still running", saved.EndTime, saved.Note, saved.Source)
+		}
+
+		// A start after now cannot be valid for a running entry
+		body := fmt.Sprintf(`{"start_time": %q}`, now.Add(time.Hour).Format(time.RFC3339))
+		if status, _ := update(t, &entry, body); status != fiber.StatusBadRequest {
+			t.Errorf("future start: status = %d, want 400", status)
+		}
+	})
+}
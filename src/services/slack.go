@@ -1,7 +1,10 @@
 package services
 
 import (
+	"bytes"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/slack-go/slack"
@@ -16,6 +19,26 @@ import (
 type SlackService struct {
 	client       *slack.Client
 	socketClient *socketmode.Client
+
+	// Compliance warnings already sent, keyed by user, rule and day
+	complianceWarnings map[string]bool
+
+	// Tracking rules with the resolved members of their user groups and channels
+	scope      *database.TrackingScope
+	scopeMutex sync.RWMutex
+}
+
+// Global Slack service instance for use by handlers
+var globalSlackService *SlackService
+
+// SetGlobalSlackService sets the global Slack service instance
+func SetGlobalSlackService(service *SlackService) {
+	globalSlackService = service
+}
+
+// GetGlobalSlackService returns the global Slack service instance
+func GetGlobalSlackService() *SlackService {
+	return globalSlackService
 }
 
 func NewSlackService() *SlackService {
@@ -91,8 +114,32 @@ func (s *SlackService) processSlackEvent(evt socketmode.Event) {
 		switch ev := innerEvent.Data.(type) {
 		case *slackevents.UserStatusChangedEvent:
 			utils.LogVerbose("User status changed event: %+v", ev)
+			if !s.tracksEventUser(&ev.User) {
+				utils.LogVerbose("Ignoring status change of untracked user %s", ev.User.ID)
+				return
+			}
 			s.handleUserStatusChanged(&ev.User)
 
+		case *slackevents.SubteamCreatedEvent:
+			s.handleSubteamUpdated(ev.Subteam)
+
+		case *slackevents.SubteamUpdatedEvent:
+			utils.LogVerbose("User group updated event: %s", ev.Subteam.ID)
+			s.scopeMembersChanged(database.TrackingRuleUserGroup, ev.Subteam.ID)
+			s.handleSubteamUpdated(ev.Subteam)
+
+		case *slackevents.SubteamMembersChangedEvent:
+			s.scopeMembersChanged(database.TrackingRuleUserGroup, ev.SubteamID)
+			s.handleSubteamMembersChanged(ev)
+
+		case *slackevents.MemberJoinedChannelEvent:
+			s.scopeMembersChanged(database.TrackingRuleChannel, ev.Channel)
+			s.handleChannelMembership(ev.Channel, ev.User, true)
+
+		case *slackevents.MemberLeftChannelEvent:
+			s.scopeMembersChanged(database.TrackingRuleChannel, ev.Channel)
+			s.handleChannelMembership(ev.Channel, ev.User, false)
+
 			// Note: Commenting out UserChangeEvent to avoid duplicate processing
 			// UserChangeEvent includes status changes which are already handled by UserStatusChangedEvent
 			/*
@@ -149,6 +196,9 @@ func (s *SlackService) handleUserStatusChanged(user *slackevents.User) {
 			utils.LogError("Error ending time entry for offline user: %v", err)
 		}
 
+		// An absence status still applies while the user is offline
+		s.syncAbsenceFromStatus(dbUser, &userInfo.Profile)
+
 		// Create status record for offline state
 		s.processUserStatusChange(dbUser, "", "offline", false)
 		return
@@ -173,6 +223,8 @@ func (s *SlackService) handleUserStatusChanged(user *slackevents.User) {
 		utils.LogError("Error updating user last activity: %v", err)
 	}
 
+	s.syncAbsenceFromStatus(dbUser, &userInfo.Profile)
+
 	// Process status change with presence validation
 	s.processUserStatusChange(dbUser, userInfo.Profile.StatusEmoji, userInfo.Profile.StatusText, true)
 }
@@ -278,8 +330,103 @@ func (s *SlackService) processUserStatusChange(dbUser *database.User, statusEmoj
 	}
 }
 
-// SyncUsers synchronizes all users from Slack to the database
+// syncAbsenceFromStatus records an absence when the user's Slack status marks them
+// as on vacation or sick. The status expiration, if set, decides the last day.
+func (s *SlackService) syncAbsenceFromStatus(dbUser *database.User, profile *slack.UserProfile) {
+	absenceType := s.absenceTypeForStatus(profile.StatusEmoji, profile.StatusText)
+	if absenceType == "" {
+		return
+	}
+
+	today := time.Now()
+	lastDay := today
+	if profile.StatusExpiration > 0 {
+		// Statuses usually expire at midnight, which belongs to the following day
+		lastDay = time.Unix(int64(profile.StatusExpiration)-1, 0)
+	}
+
+	absence, err := database.RecordSlackAbsence(dbUser.ID, absenceType, today, lastDay, config.AppConfig.AbsenceAutoApprove)
+	if err != nil {
+		utils.LogError("Error recording %s absence for user %s: %v", absenceType, dbUser.Name, err)
+		return
+	}
+	if absence != nil {
+		utils.LogInfo("Recorded %s absence for user %s from %s to %s", absenceType, dbUser.Name,
+			absence.StartDate.Format("2006-01-02"), absence.EndDate.Format("2006-01-02"))
+	}
+}
+
+// absenceTypeForStatus returns the absence type a status indicates, or an empty string
+func (s *SlackService) absenceTypeForStatus(statusEmoji, statusText string) string {
+	statusText = strings.ToLower(statusText)
+	statusEmoji = strings.ToLower(statusEmoji)
+
+	sickKeywords := []string{"sick", "ill", "doctor"}
+	sickEmojis := []string{":face_with_thermometer:", ":sick:", ":sneezing_face:", ":mask:"}
+	vacationKeywords := []string{"vacation", "holiday", "holidays", "pto"}
+	vacationEmojis := []string{":palm_tree:", ":desert_island:", ":beach_with_umbrella:"}
+
+	for _, emoji := range sickEmojis {
+		if strings.Contains(statusEmoji, emoji) {
+			return database.AbsenceTypeSick
+		}
+	}
+	for _, emoji := range vacationEmojis {
+		if strings.Contains(statusEmoji, emoji) {
+			return database.AbsenceTypeVacation
+		}
+	}
+	for _, word := range strings.FieldsFunc(statusText, func(r rune) bool {
+		return !('a' <= r && r <= 'z')
+	}) {
+		for _, keyword := range sickKeywords {
+			if word == keyword {
+				return database.AbsenceTypeSick
+			}
+		}
+		for _, keyword := range vacationKeywords {
+			if word == keyword {
+				return database.AbsenceTypeVacation
+			}
+		}
+	}
+	if strings.Contains(statusText, "on leave") {
+		return database.AbsenceTypeVacation
+	}
+
+	return ""
+}
+
+// SendDirectMessage sends a direct message from the bot to a Slack user
+func (s *SlackService) SendDirectMessage(slackUserID, text string) error {
+	_, _, err := s.client.PostMessage(slackUserID, slack.MsgOptionText(text, false))
+	return err
+}
+
+// UploadFile uploads a file from the bot to a Slack channel (by ID) and returns the file ID
+func (s *SlackService) UploadFile(channelID, filename, title, comment string, data []byte) (string, error) {
+	file, err := s.client.UploadFileV2(slack.UploadFileV2Parameters{
+		Channel:        channelID,
+		Filename:       filename,
+		Title:          title,
+		InitialComment: comment,
+		Reader:         bytes.NewReader(data),
+		FileSize:       len(data),
+	})
+	if err != nil {
+		return "", err
+	}
+	return file.ID, nil
+}
+
+// SyncUsers synchronizes all tracked users from Slack to the database. Users outside
+// the tracking scope get no user row; existing ones are deactivated, and reactivated
+// once they are back in scope.
 func (s *SlackService) SyncUsers() error {
+	if err := s.RefreshTrackingScope(); err != nil {
+		utils.LogError("Error loading tracking rules: %v", err)
+	}
+
 	users, err := s.client.GetUsers()
 	if err != nil {
 		return err
@@ -294,6 +441,16 @@ func (s *SlackService) SyncUsers() error {
 			continue
 		}
 
+		if !s.tracks(slackCandidate(&user)) {
+			stopped, err := database.StopTrackingUser(user.ID)
+			if err != nil {
+				utils.LogError("Error deactivating untracked user %s: %v", user.Name, err)
+			} else if stopped != nil {
+				utils.LogInfo("Deactivated user %s, who is outside the tracking scope", user.Name)
+			}
+			continue
+		}
+
 		// Create or update user in database
 		_, err := database.CreateOrUpdateUser(
 			user.ID,
@@ -306,6 +463,13 @@ func (s *SlackService) SyncUsers() error {
 			utils.LogError("Error syncing user %s: %v", user.Name, err)
 			continue
 		}
+
+		resumed, err := database.ResumeTrackingUser(user.ID)
+		if err != nil {
+			utils.LogError("Error reactivating user %s: %v", user.Name, err)
+		} else if resumed != nil {
+			utils.LogInfo("Reactivated user %s, who is back in the tracking scope", user.Name)
+		}
 	}
 
 	utils.LogVerbose("Synced %d users from Slack", len(users))
@@ -409,6 +573,9 @@ func (s *SlackService) StartWithInitialSync() {
 		utils.LogError("Error during initial user sync: %v", err)
 	}
 
+	// Sync team members from Slack user groups and team channels
+	s.startTeamSync()
+
 	// NOTE: Removed periodic status checking to avoid conflicts with real-time events
 	// Real-time events via WebSocket will handle all status changes
 	utils.LogInfo("Real-time status tracking enabled via WebSocket events")
@@ -423,6 +590,16 @@ func (s *SlackService) StartWithInitialSync() {
 		}
 	}()
 
+	// Warn working users in Slack before they reach a compliance limit
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.checkComplianceWarnings()
+		}
+	}()
+
 	go s.Start()
 }
 
@@ -457,3 +634,75 @@ func (s *SlackService) updateActiveDurations() {
 
 	utils.LogVerbose("Updated %d active time entries", len(activeEntries))
 }
+
+// checkComplianceWarnings sends a Slack DM to users who are working and close to their
+// daily hour limit or to the point where a break becomes mandatory. Each warning is
+// sent at most once per user, rule and day.
+func (s *SlackService) checkComplianceWarnings() {
+	var activeEntries []database.TimeEntry
+	if err := database.DB.Preload("User").Where("end_time IS NULL").Find(&activeEntries).Error; err != nil {
+		utils.LogError("Error fetching active time entries for compliance warnings: %v", err)
+		return
+	}
+
+	if s.complianceWarnings == nil {
+		s.complianceWarnings = make(map[string]bool)
+	}
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	for _, entry := range activeEntries {
+		if entry.User == nil {
+			continue
+		}
+
+		status, err := database.GetComplianceStatus(entry.UserID, now)
+		if err != nil {
+			utils.LogError("Error checking compliance status for user %d: %v", entry.UserID, err)
+			continue
+		}
+		rules := status.Rules
+		if rules.WarnBeforeMins <= 0 {
+			continue
+		}
+		lead := time.Duration(rules.WarnBeforeMins) * time.Minute
+
+		var warnings []string
+		if limit := time.Duration(rules.MaxDailyHours * float64(time.Hour)); limit > 0 && status.WorkedToday+lead >= limit {
+			warnings = append(warnings, database.ComplianceRuleMaxDaily)
+		}
+		breakAfter := time.Duration(rules.BreakAfterHours * float64(time.Hour))
+		minBreak := time.Duration(rules.MinBreakMinutes) * time.Minute
+		if breakAfter > 0 && status.BreakToday < minBreak && status.WorkedToday+lead >= breakAfter {
+			warnings = append(warnings, database.ComplianceRuleBreak)
+		}
+
+		for _, rule := range warnings {
+			key := fmt.Sprintf("%d:%s:%s", entry.UserID, rule, today)
+			if s.complianceWarnings[key] {
+				continue
+			}
+			s.complianceWarnings[key] = true
+
+			text := fmt.Sprintf("You have worked %.1fh today. ", status.WorkedToday.Hours())
+			if rule == database.ComplianceRuleMaxDaily {
+				text += fmt.Sprintf("The daily limit is %.1fh, please plan to stop soon.", rules.MaxDailyHours)
+			} else {
+				text += fmt.Sprintf("A break of at least %d minutes is required after %.1fh of work.", rules.MinBreakMinutes, rules.BreakAfterHours)
+			}
+
+			if err := s.SendDirectMessage(entry.User.SlackUserID, text); err != nil {
+				utils.LogError("Error sending compliance warning to %s: %v", entry.User.Name, err)
+				continue
+			}
+			utils.LogInfo("Sent %s compliance warning to %s", rule, entry.User.Name)
+		}
+	}
+
+	// Forget warnings from previous days
+	for key := range s.complianceWarnings {
+		if !strings.HasSuffix(key, today) {
+			delete(s.complianceWarnings, key)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"gorm.io/gorm"
+
+	"sports-excitement-team-management/src/config"
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// teamChannels returns the IDs of the channels whose members are synced as teams
+func teamChannels() []string {
+	if config.AppConfig == nil {
+		return nil
+	}
+	var channels []string
+	for _, channel := range strings.Split(config.AppConfig.SlackTeamChannels, ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// isTeamChannel reports whether a channel's members are synced as a team
+func isTeamChannel(channelID string) bool {
+	for _, channel := range teamChannels() {
+		if channel == channelID {
+			return true
+		}
+	}
+	return false
+}
+
+// userGroupSyncEnabled reports whether teams are synced from Slack user groups
+func userGroupSyncEnabled() bool {
+	return config.AppConfig != nil && config.AppConfig.SlackUserGroupSync
+}
+
+// TeamSyncEnabled reports whether any teams are synced from Slack
+func TeamSyncEnabled() bool {
+	return userGroupSyncEnabled() || len(teamChannels()) > 0
+}
+
+// TeamSyncResult counts the changes of a Slack team sync
+type TeamSyncResult struct {
+	Teams   int `json:"teams"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// SyncTeams syncs the members of the enabled Slack user groups and of the configured
+// team channels into teams. Users must be synced first; Slack users without a tracked
+// user are skipped.
+func (s *SlackService) SyncTeams() (*TeamSyncResult, error) {
+	result := &TeamSyncResult{}
+
+	if userGroupSyncEnabled() {
+		groups, err := s.client.GetUserGroups(slack.GetUserGroupsOptionIncludeUsers(true))
+		if err != nil {
+			return result, err
+		}
+		for _, group := range groups {
+			if group.DateDelete > 0 {
+				continue // Disabled groups keep their last members
+			}
+			team, err := database.LinkSlackTeam(database.SlackTeamUserGroup, group.ID, group.Name, group.Description)
+			if err != nil {
+				utils.LogError("Error linking Slack user group %s to a team: %v", group.Name, err)
+				continue
+			}
+			s.applyTeamMembers(team, group.Users, result)
+		}
+	}
+
+	for _, channelID := range teamChannels() {
+		channel, err := s.client.GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channelID})
+		if err != nil {
+			utils.LogError("Error getting Slack channel %s: %v", channelID, err)
+			continue
+		}
+		members, err := s.channelMembers(channelID)
+		if err != nil {
+			utils.LogError("Error getting members of Slack channel %s: %v", channel.Name, err)
+			continue
+		}
+		team, err := database.LinkSlackTeam(database.SlackTeamChannel, channelID, channel.Name, channel.Purpose.Value)
+		if err != nil {
+			utils.LogError("Error linking Slack channel %s to a team: %v", channel.Name, err)
+			continue
+		}
+		s.applyTeamMembers(team, members, result)
+	}
+
+	utils.LogVerbose("Synced %d teams from Slack: %d members added, %d removed", result.Teams, result.Added, result.Removed)
+	return result, nil
+}
+
+// applyTeamMembers sets the Slack-synced members of a team and counts the changes
+func (s *SlackService) applyTeamMembers(team *database.Team, slackIDs []string, result *TeamSyncResult) {
+	added, removed, err := database.SetSlackTeamMembers(team, slackIDs)
+	if err != nil {
+		utils.LogError("Error syncing members of team %s: %v", team.Name, err)
+		return
+	}
+	result.Teams++
+	result.Added += added
+	result.Removed += removed
+}
+
+// channelMembers returns the Slack user IDs of all members of a channel
+func (s *SlackService) channelMembers(channelID string) ([]string, error) {
+	var members []string
+	params := &slack.GetUsersInConversationParameters{ChannelID: channelID, Limit: 1000}
+	for {
+		page, cursor, err := s.client.GetUsersInConversation(params)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+		if cursor == "" {
+			return members, nil
+		}
+		params.Cursor = cursor
+	}
+}
+
+// startTeamSync syncs teams from Slack now and then every TeamSyncMinutes
+func (s *SlackService) startTeamSync() {
+	if !TeamSyncEnabled() {
+		return
+	}
+
+	interval := time.Duration(config.AppConfig.TeamSyncMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	go func() {
+		for {
+			if _, err := s.SyncTeams(); err != nil {
+				utils.LogError("Error syncing teams from Slack: %v", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// teamMembershipChanged refreshes the dashboards after Slack changed team members
+func teamMembershipChanged(changed int) {
+	if changed == 0 {
+		return
+	}
+	if hub := GetGlobalHub(); hub != nil {
+		hub.BroadcastAnalyticsUpdate()
+	}
+}
+
+// handleSubteamUpdated syncs the team of a user group that was created or changed
+func (s *SlackService) handleSubteamUpdated(subteam slackevents.SubTeam) {
+	if !userGroupSyncEnabled() || subteam.DateDelete > 0 {
+		return
+	}
+
+	team, err := database.LinkSlackTeam(database.SlackTeamUserGroup, subteam.ID, subteam.Name, subteam.Description)
+	if err != nil {
+		utils.LogError("Error linking Slack user group %s to a team: %v", subteam.Name, err)
+		return
+	}
+
+	members := subteam.Users
+	if len(members) == 0 && subteam.UserCount > 0 {
+		// The event may omit the users of large groups
+		if members, err = s.client.GetUserGroupMembers(subteam.ID); err != nil {
+			utils.LogError("Error getting members of Slack user group %s: %v", subteam.Name, err)
+			return
+		}
+	}
+
+	added, removed, err := database.SetSlackTeamMembers(team, members)
+	if err != nil {
+		utils.LogError("Error syncing members of team %s: %v", team.Name, err)
+		return
+	}
+	teamMembershipChanged(added + removed)
+}
+
+// handleSubteamMembersChanged applies the members added to and removed from a user group
+func (s *SlackService) handleSubteamMembersChanged(event *slackevents.SubteamMembersChangedEvent) {
+	if !userGroupSyncEnabled() {
+		return
+	}
+
+	team, err := database.GetSlackTeam(database.SlackTeamUserGroup, event.SubteamID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogError("Error loading team of Slack user group %s: %v", event.SubteamID, err)
+		}
+		return
+	}
+
+	added, err := database.AddSlackTeamMembers(team, event.AddedUsers)
+	if err != nil {
+		utils.LogError("Error adding members to team %s: %v", team.Name, err)
+	}
+	removed, err := database.RemoveSlackTeamMembers(team, event.RemovedUsers)
+	if err != nil {
+		utils.LogError("Error removing members from team %s: %v", team.Name, err)
+	}
+	teamMembershipChanged(added + removed)
+}
+
+// handleChannelMembership adds a user who joined a team channel to its team, or removes
+// a user who left it
+func (s *SlackService) handleChannelMembership(channelID, slackUserID string, joined bool) {
+	if !isTeamChannel(channelID) {
+		return
+	}
+
+	team, err := database.GetSlackTeam(database.SlackTeamChannel, channelID)
+	if errors.Is(err, gorm.ErrRecordNotFound) && joined {
+		// The channel has not been synced yet; a full sync creates the team
+		result, err := s.SyncTeams()
+		if err != nil {
+			utils.LogError("Error syncing teams from Slack: %v", err)
+		}
+		teamMembershipChanged(result.Added + result.Removed)
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.LogError("Error loading team of Slack channel %s: %v", channelID, err)
+		}
+		return
+	}
+
+	var changed int
+	if joined {
+		changed, err = database.AddSlackTeamMembers(team, []string{slackUserID})
+	} else {
+		changed, err = database.RemoveSlackTeamMembers(team, []string{slackUserID})
+	}
+	if err != nil {
+		utils.LogError("Error updating members of team %s: %v", team.Name, err)
+		return
+	}
+	teamMembershipChanged(changed)
+}
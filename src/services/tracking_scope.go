@@ -0,0 +1,129 @@
+package services
+
+import (
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+
+	"sports-excitement-team-management/src/database"
+	"sports-excitement-team-management/src/utils"
+)
+
+// slackCandidate returns the tracking candidate of a Slack user
+func slackCandidate(user *slack.User) database.TrackingCandidate {
+	return database.TrackingCandidate{
+		SlackUserID: user.ID,
+		Email:       user.Profile.Email,
+		Guest:       user.IsRestricted || user.IsUltraRestricted,
+	}
+}
+
+// RefreshTrackingScope reloads the tracking rules and the members of the user groups
+// and channels they refer to. If members cannot be loaded, the previously loaded
+// members of that group or channel are kept.
+func (s *SlackService) RefreshTrackingScope() error {
+	rules, err := database.GetTrackingRules()
+	if err != nil {
+		return err
+	}
+
+	s.scopeMutex.RLock()
+	previous := s.scope
+	s.scopeMutex.RUnlock()
+
+	scope := &database.TrackingScope{Rules: rules, Members: make(map[string]map[string]bool)}
+	for _, rule := range rules {
+		if rule.Kind != database.TrackingRuleUserGroup && rule.Kind != database.TrackingRuleChannel {
+			continue
+		}
+		key := database.ScopeMembersKey(rule.Kind, rule.Value)
+		if _, loaded := scope.Members[key]; loaded {
+			continue
+		}
+
+		var members []string
+		if rule.Kind == database.TrackingRuleUserGroup {
+			members, err = s.client.GetUserGroupMembers(rule.Value)
+		} else {
+			members, err = s.channelMembers(rule.Value)
+		}
+		if err != nil {
+			utils.LogError("Error getting members of Slack %s %s for tracking rules: %v", rule.Kind, rule.Value, err)
+			if previous != nil {
+				scope.Members[key] = previous.Members[key]
+			}
+			continue
+		}
+
+		scope.Members[key] = make(map[string]bool, len(members))
+		for _, member := range members {
+			scope.Members[key][member] = true
+		}
+	}
+
+	s.scopeMutex.Lock()
+	s.scope = scope
+	s.scopeMutex.Unlock()
+	return nil
+}
+
+// tracks reports whether a Slack user is inside the tracking scope
+func (s *SlackService) tracks(candidate database.TrackingCandidate) bool {
+	s.scopeMutex.RLock()
+	defer s.scopeMutex.RUnlock()
+	return s.scope.Tracks(candidate)
+}
+
+// tracksEventUser reports whether the user of a Slack event is inside the tracking
+// scope. Events carry no email address; it is loaded from the Slack API if a rule
+// needs it, or taken from the stored user if the API fails, so that no status change
+// is lost to an API error.
+func (s *SlackService) tracksEventUser(user *slackevents.User) bool {
+	s.scopeMutex.RLock()
+	hasRules, needsEmail := s.scope != nil && len(s.scope.Rules) > 0, s.scope.NeedsEmail()
+	s.scopeMutex.RUnlock()
+	if !hasRules {
+		return true
+	}
+
+	candidate := database.TrackingCandidate{
+		SlackUserID: user.ID,
+		Guest:       user.IsRestricted || user.IsUltraRestricted,
+	}
+	if needsEmail {
+		userInfo, err := s.client.GetUserInfo(user.ID)
+		if err == nil {
+			candidate = slackCandidate(userInfo)
+		} else {
+			utils.LogError("Error getting user info of %s for tracking rules, using the stored email: %v", user.ID, err)
+			if email, err := database.StoredUserEmail(user.ID); err != nil {
+				utils.LogError("Error loading the stored email of %s: %v", user.ID, err)
+			} else {
+				candidate.Email = email
+			}
+		}
+	}
+	return s.tracks(candidate)
+}
+
+// scopeMembersChanged reloads the tracking scope and re-syncs users when the members of
+// a user group or channel that a tracking rule refers to change
+func (s *SlackService) scopeMembersChanged(kind, id string) {
+	s.scopeMutex.RLock()
+	referenced := false
+	if s.scope != nil {
+		for _, rule := range s.scope.Rules {
+			if rule.Kind == kind && rule.Value == id {
+				referenced = true
+				break
+			}
+		}
+	}
+	s.scopeMutex.RUnlock()
+	if !referenced {
+		return
+	}
+
+	if err := s.SyncUsers(); err != nil {
+		utils.LogError("Error syncing users after a tracking scope change: %v", err)
+	}
+}
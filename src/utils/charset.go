@@ -0,0 +1,26 @@
+package utils
+
+// windows1252 maps the characters of Windows-1252 outside Latin-1 to their codes
+var windows1252 = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// EncodeWindows1252 converts UTF-8 text to Windows-1252, replacing characters it
+// cannot represent by "?"
+func EncodeWindows1252(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r < 0x80, r >= 0xa0 && r <= 0xff:
+			encoded = append(encoded, byte(r))
+		case windows1252[r] != 0:
+			encoded = append(encoded, windows1252[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five-field cron expression (minute, hour, day of month,
+// month, day of week) evaluated in local time
+type CronSchedule struct {
+	minutes, hours, days, months, weekdays uint64
+
+	// Like cron, a day matches either field when both day of month and day of week are restricted
+	anyDay, anyWeekday bool
+}
+
+// cronAliases are the supported shorthand schedules
+var cronAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 1",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// cronNames are the names accepted in the month and day of week fields
+var cronNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseCron parses a cron expression such as "0 7 * * mon" or "30 6 1 * *". Fields
+// accept *, numbers, names (jan-dec, sun-sat), ranges, lists and steps (*/15, 1-5/2);
+// @hourly, @daily, @weekly, @monthly and @yearly are accepted as shorthands.
+func ParseCron(expr string) (*CronSchedule, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	if alias, ok := cronAliases[expr]; ok {
+		expr = alias
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	schedule := &CronSchedule{anyDay: fields[2] == "*", anyWeekday: fields[4] == "*"}
+	var err error
+	if schedule.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if schedule.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if schedule.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if schedule.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if schedule.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1 // 7 is Sunday as well
+	}
+	return schedule, nil
+}
+
+// parseCronField returns the bit set of values selected by a comma-separated field
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		low, high := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = parseCronValue(bounds[0], min, max); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(bounds[1], min, max); err != nil {
+				return 0, err
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			value, err := parseCronValue(part, min, max)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if step == 1 {
+				high = value
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a number or name within [min, max]
+func parseCronValue(value string, min, max int) (int, error) {
+	number, ok := cronNames[value]
+	if !ok {
+		var err error
+		if number, err = strconv.Atoi(value); err != nil {
+			return 0, fmt.Errorf("invalid value %q", value)
+		}
+	}
+	if number < min || number > max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", value, min, max)
+	}
+	return number, nil
+}
+
+// matchesDay reports whether the schedule runs on the day of t
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// everyHour reports whether the hour field selects all hours
+func (s *CronSchedule) everyHour() bool {
+	return s.hours == 1<<24-1
+}
+
+// skippedHourMatches reports whether a daylight saving gap before after skipped a wall
+// clock hour of its day that the schedule runs in. before is the time the search moved
+// on from.
+func (s *CronSchedule) skippedHourMatches(before, after time.Time) bool {
+	if s.months&(1<<uint(after.Month())) == 0 || !s.matchesDay(after) {
+		return false
+	}
+	first := 0
+	if after.Year() == before.Year() && after.YearDay() == before.YearDay() {
+		first = before.Hour() + 1
+	}
+	for hour := first; hour < after.Hour(); hour++ {
+		if s.hours&(1<<uint(hour)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// repeatedWallTime reports whether the wall clock time of t already occurred an hour
+// earlier, when clocks are turned back at the end of daylight saving time
+func repeatedWallTime(t time.Time) bool {
+	earlier := t.Add(-time.Hour)
+	return earlier.Day() == t.Day() && earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute()
+}
+
+// Next returns the first time after t at which the schedule runs, or the zero time if
+// it never does (e.g. "0 0 31 2 *"). Like cron, a schedule with fixed hours runs once
+// when clocks are turned back, and when a wall clock hour it runs in is skipped as
+// clocks go forward, it runs at the end of the gap instead. Schedules running every
+// hour follow the actual hours.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.In(time.Local).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		previous := t
+		switch {
+		case s.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.Local)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.Local)
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.Local)
+			if repeatedWallTime(t) {
+				t = t.Add(-time.Hour) // Start with the first of the repeated hours
+			}
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		case !s.everyHour() && repeatedWallTime(t):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+		if !s.everyHour() && s.skippedHourMatches(previous, t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
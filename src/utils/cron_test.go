@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// useLocation runs a test with time.Local set to a named time zone
+func useLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	local := time.Local
+	time.Local = location
+	t.Cleanup(func() { time.Local = local })
+	return location
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"* * * foo *",
+		"@often",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	location := useLocation(t, "Europe/Berlin")
+	// Times are local unless they carry an offset, which tells repeated hours apart
+	at := func(value string) time.Time {
+		layout := "2006-01-02 15:04"
+		if len(value) > len(layout) {
+			layout += " -0700"
+		}
+		parsed, err := time.ParseInLocation(layout, value, location)
+		if err != nil {
+			t.Fatalf("parsing %s: %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want []string // Successive runs; empty if the schedule never runs
+	}{
+		{name: "every minute", expr: "* * * * *", from: "2026-01-05 10:00", want: []string{"2026-01-05 10:01", "2026-01-05 10:02"}},
+		{name: "minute step", expr: "*/15 * * * *", from: "2026-01-05 10:07", want: []string{"2026-01-05 10:15", "2026-01-05 10:30", "2026-01-05 10:45", "2026-01-05 11:00"}},
+		{name: "range step", expr: "0 8-18/4 * * *", from: "2026-01-05 09:00", want: []string{"2026-01-05 12:00", "2026-01-05 16:00", "2026-01-06 08:00"}},
+		{name: "value step", expr: "0 20/2 * * *", from: "2026-01-05 19:00", want: []string{"2026-01-05 20:00", "2026-01-05 22:00", "2026-01-06 20:00"}},
+		{name: "list", expr: "0,30 7,12 * * *", from: "2026-01-05 07:00", want: []string{"2026-01-05 07:30", "2026-01-05 12:00", "2026-01-05 12:30", "2026-01-06 07:00"}},
+		{name: "weekday names", expr: "0 7 * * mon-fri", from: "2026-01-09 08:00", want: []string{"2026-01-12 07:00", "2026-01-13 07:00"}},
+		{name: "month names", expr: "0 0 1 jan,jul *", from: "2026-02-01 00:00", want: []string{"2026-07-01 00:00", "2027-01-01 00:00"}},
+		{name: "7 is sunday", expr: "0 9 * * 7", from: "2026-01-05 00:00", want: []string{"2026-01-11 09:00", "2026-01-18 09:00"}},
+		{name: "day of month or weekday", expr: "0 6 13 * fri", from: "2026-02-01 00:00", want: []string{"2026-02-06 06:00", "2026-02-13 06:00", "2026-02-20 06:00", "2026-02-27 06:00", "2026-03-06 06:00", "2026-03-13 06:00"}},
+		{name: "day of month with any weekday", expr: "0 6 13 * *", from: "2026-02-01 00:00", want: []string{"2026-02-13 06:00", "2026-03-13 06:00"}},
+		{name: "weekday with any day of month", expr: "0 6 * * fri", from: "2026-02-01 00:00", want: []string{"2026-02-06 06:00", "2026-02-13 06:00"}},
+		{name: "alias", expr: "@weekly", from: "2026-01-06 00:00", want: []string{"2026-01-12 00:00"}},
+		{name: "31st skips short months", expr: "0 0 31 * *", from: "2026-01-31 00:00", want: []string{"2026-03-31 00:00", "2026-05-31 00:00"}},
+		{name: "leap day", expr: "0 0 29 2 *", from: "2026-01-01 00:00", want: []string{"2028-02-29 00:00"}},
+		{name: "february 31st never runs", expr: "0 0 31 2 *", from: "2026-01-01 00:00"},
+		{name: "april 31st never runs", expr: "0 0 31 apr *", from: "2026-01-01 00:00"},
+		{name: "skipped hour runs when clocks go forward", expr: "30 2 * * *", from: "2026-03-28 12:00", want: []string{"2026-03-29 03:00", "2026-03-30 02:30"}},
+		{name: "hour before the skipped hour", expr: "30 1,2 * * *", from: "2026-03-29 00:00", want: []string{"2026-03-29 01:30", "2026-03-29 03:00", "2026-03-30 01:30"}},
+		{name: "hourly schedule skips the missing hour", expr: "30 * * * *", from: "2026-03-29 01:00", want: []string{"2026-03-29 01:30", "2026-03-29 03:30"}},
+		{name: "repeated hour runs once", expr: "30 2 * * *", from: "2026-10-25 00:00", want: []string{"2026-10-25 02:30 +0200", "2026-10-26 02:30"}},
+		{name: "repeated hour after a run in the hour before", expr: "30 1,2 * * *", from: "2026-10-25 00:00", want: []string{"2026-10-25 01:30", "2026-10-25 02:30 +0200", "2026-10-26 01:30"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+
+			next := at(tt.from).Add(30 * time.Second)
+			if len(tt.want) == 0 {
+				if next = schedule.Next(next); !next.IsZero() {
+					t.Errorf("Next = %s, want never", next)
+				}
+				return
+			}
+			for _, want := range tt.want {
+				next = schedule.Next(next)
+				if !next.Equal(at(want)) {
+					t.Fatalf("Next = %s, want %s", next, at(want))
+				}
+			}
+		})
+	}
+}
+
+func TestCronNextHourlyAcrossRepeatedHour(t *testing.T) {
+	location := useLocation(t, "Europe/Berlin")
+	schedule, err := ParseCron("@hourly")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+
+	// Clocks go back from 03:00 CEST to 02:00 CET; hourly runs follow the actual hours
+	next := time.Date(2026, 10, 25, 0, 30, 0, 0, location)
+	var runs []time.Time
+	for i := 0; i < 4; i++ {
+		next = schedule.Next(next)
+		runs = append(runs, next)
+	}
+	for i := 1; i < len(runs); i++ {
+		if gap := runs[i].Sub(runs[i-1]); gap != time.Hour {
+			t.Errorf("run %d at %s is %s after the previous run, want 1h", i, runs[i], gap)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package utils
+
+import "strings"
+
+// slackEmoji maps common Slack status emoji shortcodes to their Unicode characters
+var slackEmoji = map[string]string{
+	"computer": "💻", "laptop": "💻", "desktop_computer": "🖥️", "keyboard": "⌨️",
+	"coffee": "☕", "construction": "🚧", "wrench": "🔧", "hammer": "🔨",
+	"gear": "⚙️", "bulb": "💡", "pencil": "📝", "pencil2": "✏️", "memo": "📝",
+	"house": "🏠", "house_with_garden": "🏡", "office": "🏢", "calendar": "📆",
+	"spiral_calendar_pad": "🗓️", "date": "📅", "phone": "☎️", "telephone_receiver": "📞",
+	"headphones": "🎧", "speech_balloon": "💬", "busts_in_silhouette": "👥",
+	"car": "🚗", "bus": "🚌", "train": "🚆", "airplane": "✈️", "palm_tree": "🌴",
+	"face_with_thermometer": "🤒", "thermometer": "🌡️", "pill": "💊",
+	"hamburger": "🍔", "fork_and_knife": "🍴", "knife_fork_plate": "🍽️",
+	"sleeping": "😴", "zzz": "💤", "no_entry": "⛔", "no_entry_sign": "🚫",
+	"red_circle": "🔴", "large_green_circle": "🟢", "white_check_mark": "✅",
+	"books": "📚", "mag": "🔍", "chart_with_upwards_trend": "📈", "rocket": "🚀",
+	"baby": "👶", "beach_with_umbrella": "🏖️", "christmas_tree": "🎄",
+}
+
+// SlackEmoji converts a Slack emoji shortcode such as ":coffee:" to its Unicode
+// character. Unknown and custom emoji are returned unchanged.
+func SlackEmoji(code string) string {
+	name := strings.Trim(code, ":")
+	if i := strings.Index(name, "::"); i >= 0 {
+		name = name[:i] // Drop skin tone modifiers such as ":wave::skin-tone-2:"
+	}
+	if emoji, ok := slackEmoji[name]; ok {
+		return emoji
+	}
+	return code
+}
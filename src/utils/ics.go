@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ICSEvent is a VEVENT of an iCalendar file
+type ICSEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time // Exclusive; for all-day events the day after the last day
+	AllDay      bool
+}
+
+// ParseICS reads the VEVENTs of an iCalendar (RFC 5545) stream. Recurrence rules
+// are not expanded; holiday calendars normally list each occurrence explicitly.
+func ParseICS(r io.Reader) ([]ICSEvent, error) {
+	lines, err := unfoldICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, errors.New("not an iCalendar file")
+	}
+
+	var events []ICSEvent
+	var current *ICSEvent
+	for _, line := range lines {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &ICSEvent{}
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				continue
+			}
+			if current.Start.IsZero() {
+				return nil, fmt.Errorf("event %q has no DTSTART", current.Summary)
+			}
+			if current.End.IsZero() {
+				// Without DTEND an all-day event lasts one day, a timed event is instantaneous
+				current.End = current.Start
+				if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescapeICSText(value)
+		case name == "DESCRIPTION":
+			current.Description = unescapeICSText(value)
+		case name == "DTSTART":
+			current.Start, current.AllDay, err = parseICSTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DTSTART %q: %w", value, err)
+			}
+		case name == "DTEND":
+			current.End, _, err = parseICSTime(params, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DTEND %q: %w", value, err)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// unfoldICSLines reads content lines, joining folded continuation lines
+func unfoldICSLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitICSLine splits "NAME;PARAM=X:value" into its upper-cased name, parameters and value
+func splitICSLine(line string) (string, map[string]string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params := make(map[string]string)
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+// parseICSTime parses a DATE or DATE-TIME value; dates are returned as local midnight
+func parseICSTime(params map[string]string, value string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// unescapeICSText reverses the TEXT escaping of RFC 5545
+func unescapeICSText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+// escapeICSText applies the TEXT escaping of RFC 5545
+func escapeICSText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(value)
+}
+
+// writeICSLine writes a content line, folding it after 75 octets without splitting
+// UTF-8 characters
+func writeICSLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// WriteICS writes events as an iCalendar (RFC 5545) calendar. Timed events are written
+// in UTC; name is shown by calendar apps that subscribe to the calendar.
+func WriteICS(w io.Writer, name string, events []ICSEvent) error {
+	buf := bufio.NewWriter(w)
+	writeICSLine(buf, "BEGIN:VCALENDAR")
+	writeICSLine(buf, "VERSION:2.0")
+	writeICSLine(buf, "PRODID:-//Time Tracker//Time Tracker//EN")
+	writeICSLine(buf, "CALSCALE:GREGORIAN")
+	writeICSLine(buf, "METHOD:PUBLISH")
+	writeICSLine(buf, "X-WR-CALNAME:"+escapeICSText(name))
+	writeICSLine(buf, "REFRESH-INTERVAL;VALUE=DURATION:PT15M")
+	writeICSLine(buf, "X-PUBLISHED-TTL:PT15M")
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, event := range events {
+		writeICSLine(buf, "BEGIN:VEVENT")
+		writeICSLine(buf, "UID:"+event.UID)
+		writeICSLine(buf, "DTSTAMP:"+stamp)
+		if event.AllDay {
+			writeICSLine(buf, "DTSTART;VALUE=DATE:"+event.Start.Format("20060102"))
+			writeICSLine(buf, "DTEND;VALUE=DATE:"+event.End.Format("20060102"))
+		} else {
+			writeICSLine(buf, "DTSTART:"+event.Start.UTC().Format("20060102T150405Z"))
+			writeICSLine(buf, "DTEND:"+event.End.UTC().Format("20060102T150405Z"))
+		}
+		writeICSLine(buf, "SUMMARY:"+escapeICSText(event.Summary))
+		if event.Description != "" {
+			writeICSLine(buf, "DESCRIPTION:"+escapeICSText(event.Description))
+		}
+		writeICSLine(buf, "TRANSP:TRANSPARENT")
+		writeICSLine(buf, "END:VEVENT")
+	}
+
+	writeICSLine(buf, "END:VCALENDAR")
+	return buf.Flush()
+}
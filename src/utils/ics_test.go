@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// icsCalendar wraps content lines in a calendar with CRLF line endings
+func icsCalendar(lines ...string) string {
+	return strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR", ""), "\r\n")
+}
+
+func TestParseICS(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone Europe/Berlin not available: %v", err)
+	}
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name string
+		ics  string
+		want []ICSEvent
+	}{
+		{
+			name: "all-day event",
+			ics: icsCalendar("BEGIN:VEVENT", "UID:new-year", "DTSTART;VALUE=DATE:20260101", "DTEND;VALUE=DATE:20260102",
+				"SUMMARY:Neujahr", "END:VEVENT"),
+			want: []ICSEvent{{UID: "new-year", Summary: "Neujahr", Start: day(2026, 1, 1), End: day(2026, 1, 2), AllDay: true}},
+		},
+		{
+			name: "all-day event without DTEND lasts one day",
+			ics:  icsCalendar("BEGIN:VEVENT", "DTSTART;VALUE=DATE:20261003", "SUMMARY:Tag der Deutschen Einheit", "END:VEVENT"),
+			want: []ICSEvent{{Summary: "Tag der Deutschen Einheit", Start: day(2026, 10, 3), End: day(2026, 10, 4), AllDay: true}},
+		},
+		{
+			name: "date without VALUE parameter",
+			ics:  icsCalendar("BEGIN:VEVENT", "DTSTART:20261225", "DTEND:20261227", "SUMMARY:Christmas", "END:VEVENT"),
+			want: []ICSEvent{{Summary: "Christmas", Start: day(2026, 12, 25), End: day(2026, 12, 27), AllDay: true}},
+		},
+		{
+			name: "timed event in UTC",
+			ics:  icsCalendar("BEGIN:VEVENT", "DTSTART:20260302T090000Z", "DTEND:20260302T103000Z", "SUMMARY:Standup", "END:VEVENT"),
+			want: []ICSEvent{{Summary: "Standup", Start: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), End: time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)}},
+		},
+		{
+			name: "timed event with TZID",
+			ics: icsCalendar("BEGIN:VEVENT", "DTSTART;TZID=Europe/Berlin:20260302T090000", "DTEND;TZID=\"Europe/Berlin\":20260302T100000",
+				"SUMMARY:Standup", "END:VEVENT"),
+			want: []ICSEvent{{Summary: "Standup", Start: time.Date(2026, 3, 2, 9, 0, 0, 0, berlin), End: time.Date(2026, 3, 2, 10, 0, 0, 0, berlin)}},
+		},
+		{
+			name: "timed event without DTEND is instantaneous",
+			ics:  icsCalendar("BEGIN:VEVENT", "DTSTART:20260302T090000Z", "SUMMARY:Deadline", "END:VEVENT"),
+			want: []ICSEvent{{Summary: "Deadline", Start: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), End: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}},
+		},
+		{
+			name: "folded lines",
+			ics: icsCalendar("BEGIN:VEVENT", "UID:folded-", " uid@example.com", "DTSTART;VALUE=DATE:20260406",
+				"SUMMARY:Oster", " montag", "DESCRIPTION:Gesetzlicher Feiertag in ", "\tallen Bundesländern", "END:VEVENT"),
+			want: []ICSEvent{{UID: "folded-uid@example.com", Summary: "Ostermontag", Description: "Gesetzlicher Feiertag in allen Bundesländern",
+				Start: day(2026, 4, 6), End: day(2026, 4, 7), AllDay: true}},
+		},
+		{
+			name: "folded parameter",
+			ics:  icsCalendar("BEGIN:VEVENT", "DTSTART;VALU", " E=DATE:20260501", "SUMMARY:Tag der Arbeit", "END:VEVENT"),
+			want: []ICSEvent{{Summary: "Tag der Arbeit", Start: day(2026, 5, 1), End: day(2026, 5, 2), AllDay: true}},
+		},
+		{
+			name: "escaped text",
+			ics:  icsCalendar("BEGIN:VEVENT", "DTSTART;VALUE=DATE:20260101", `SUMMARY:Holiday\, observed\; office closed`, `DESCRIPTION:Line one\nLine two \\ end`, "END:VEVENT"),
+			want: []ICSEvent{{Summary: "Holiday, observed; office closed", Description: "Line one\nLine two \\ end",
+				Start: day(2026, 1, 1), End: day(2026, 1, 2), AllDay: true}},
+		},
+		{
+			name: "byte order mark and LF line endings",
+			ics:  "\ufeffBEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20260101\nSUMMARY:Neujahr\nEND:VEVENT\nEND:VCALENDAR\n",
+			want: []ICSEvent{{Summary: "Neujahr", Start: day(2026, 1, 1), End: day(2026, 1, 2), AllDay: true}},
+		},
+		{
+			name: "properties outside events are ignored",
+			ics: icsCalendar("X-WR-CALNAME:Holidays", "BEGIN:VTIMEZONE", "DTSTART:19701025T030000", "END:VTIMEZONE",
+				"BEGIN:VEVENT", "DTSTART;VALUE=DATE:20260101", "SUMMARY:Neujahr", "END:VEVENT"),
+			want: []ICSEvent{{Summary: "Neujahr", Start: day(2026, 1, 1), End: day(2026, 1, 2), AllDay: true}},
+		},
+		{
+			name: "no events",
+			ics:  icsCalendar(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ParseICS(strings.NewReader(tt.ics))
+			if err != nil {
+				t.Fatalf("ParseICS: %v", err)
+			}
+			if len(events) != len(tt.want) {
+				t.Fatalf("ParseICS returned %d events, want %d: %+v", len(events), len(tt.want), events)
+			}
+			for i, want := range tt.want {
+				got := events[i]
+				if got.UID != want.UID || got.Summary != want.Summary || got.Description != want.Description || got.AllDay != want.AllDay {
+					t.Errorf("event %d = %+v, want %+v", i, got, want)
+				}
+				if !got.Start.Equal(want.Start) || !got.End.Equal(want.End) {
+					t.Errorf("event %d runs %s to %s, want %s to %s", i, got.Start, got.End, want.Start, want.End)
+				}
+			}
+		})
+	}
+}
+
+func TestParseICSErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ics  string
+	}{
+		{name: "empty", ics: ""},
+		{name: "not a calendar", ics: "Subject,Start Date\nNeujahr,2026-01-01\n"},
+		{name: "event without DTSTART", ics: icsCalendar("BEGIN:VEVENT", "SUMMARY:Neujahr", "END:VEVENT")},
+		{name: "invalid date", ics: icsCalendar("BEGIN:VEVENT", "DTSTART;VALUE=DATE:20261301", "END:VEVENT")},
+		{name: "invalid date-time", ics: icsCalendar("BEGIN:VEVENT", "DTSTART:20260101T250000Z", "END:VEVENT")},
+		{name: "invalid DTEND", ics: icsCalendar("BEGIN:VEVENT", "DTSTART:20260101", "DTEND:tomorrow", "END:VEVENT")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if events, err := ParseICS(strings.NewReader(tt.ics)); err == nil {
+				t.Errorf("ParseICS returned %+v, want an error", events)
+			}
+		})
+	}
+}
+
+func TestWriteICSRoundTrip(t *testing.T) {
+	event := ICSEvent{
+		UID:         "entry-1@example.com",
+		Summary:     strings.Repeat("Überstunden, Teamevent; ", 8),
+		Description: "Zeile eins\nZeile zwei",
+		Start:       time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		End:         time.Date(2026, 3, 2, 17, 30, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, "Zeiterfassung", []ICSEvent{event}); err != nil {
+		t.Fatalf("WriteICS: %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line of %d octets is not folded: %q", len(line), line)
+		}
+	}
+
+	events, err := ParseICS(&buf)
+	if err != nil {
+		t.Fatalf("ParseICS: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("ParseICS returned %d events, want 1", len(events))
+	}
+	got := events[0]
+	if got.UID != event.UID || got.Summary != event.Summary || got.Description != event.Description ||
+		!got.Start.Equal(event.Start) || !got.End.Equal(event.End) {
+		t.Errorf("read back %+v, want %+v", got, event)
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A4 page size in points
+const (
+	PDFPageWidth  = 595.28
+	PDFPageHeight = 841.89
+)
+
+// PDFDocument is a minimal PDF writer for printable reports. Text uses the standard
+// Helvetica fonts, which every viewer provides, so no fonts are embedded; characters
+// outside Windows-1252 are replaced by "?". Coordinates are in points from the top
+// left corner of the page.
+type PDFDocument struct {
+	title string
+	pages []*bytes.Buffer
+	page  int
+}
+
+// NewPDFDocument creates an empty document with the given title
+func NewPDFDocument(title string) *PDFDocument {
+	return &PDFDocument{title: title}
+}
+
+// AddPage starts a new page and makes it the current page
+func (d *PDFDocument) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.page = len(d.pages) - 1
+}
+
+// PageCount returns the number of pages
+func (d *PDFDocument) PageCount() int {
+	return len(d.pages)
+}
+
+// SetPage makes an existing page (zero-based) the current page, e.g. to add footers
+func (d *PDFDocument) SetPage(page int) {
+	if page >= 0 && page < len(d.pages) {
+		d.page = page
+	}
+}
+
+// content returns the content stream of the current page, adding a page if there is none
+func (d *PDFDocument) content() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[d.page]
+}
+
+// pdfNumber formats a coordinate or size
+func pdfNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+// SetGray sets the gray level (0 black, 1 white) of subsequent text, lines and fills
+func (d *PDFDocument) SetGray(gray float64) {
+	fmt.Fprintf(d.content(), "%s g %s G\n", pdfNumber(gray), pdfNumber(gray))
+}
+
+// Text draws text with its baseline at y
+func (d *PDFDocument) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.content(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		font, pdfNumber(size), pdfNumber(x), pdfNumber(PDFPageHeight-y), pdfEscape(pdfEncode(text)))
+}
+
+// TextRight draws text right-aligned at x
+func (d *PDFDocument) TextRight(x, y, size float64, bold bool, text string) {
+	d.Text(x-PDFTextWidth(text, size, bold), y, size, bold, text)
+}
+
+// Line draws a line between two points
+func (d *PDFDocument) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.content(), "%s w %s %s m %s %s l S\n", pdfNumber(width),
+		pdfNumber(x1), pdfNumber(PDFPageHeight-y1), pdfNumber(x2), pdfNumber(PDFPageHeight-y2))
+}
+
+// Rect draws a rectangle with its top left corner at (x, y), filled or outlined
+func (d *PDFDocument) Rect(x, y, width, height float64, fill bool) {
+	operator := "S"
+	if fill {
+		operator = "f"
+	}
+	fmt.Fprintf(d.content(), "0.5 w %s %s %s %s re %s\n",
+		pdfNumber(x), pdfNumber(PDFPageHeight-y-height), pdfNumber(width), pdfNumber(height), operator)
+}
+
+// Write writes the document
+func (d *PDFDocument) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-5 are fixed; each page adds a page object and its content stream
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (Time Tracker) /CreationDate (D:%s) >>",
+		pdfEscape(pdfEncode(d.title)), time.Now().Format("20060102150405")))
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNumber(PDFPageWidth), pdfNumber(PDFPageHeight), 7+2*i))
+
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		if _, err := writer.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfEncode converts UTF-8 text to Windows-1252 for the standard fonts, replacing
+// control characters
+func pdfEncode(text string) string {
+	return string(EncodeWindows1252(strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return ' '
+		case r < 0x20 || r == 0x7f:
+			return '?'
+		}
+		return r
+	}, text)))
+}
+
+// pdfEscape escapes a string for a PDF literal
+func pdfEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text)
+}
+
+// Glyph widths of the printable ASCII characters (32-126) in 1/1000 em
+var (
+	helveticaWidths = [95]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// PDFTextWidth returns the width of text in points. Characters outside ASCII are
+// measured with an average width.
+func PDFTextWidth(text string, size float64, bold bool) float64 {
+	widths := &helveticaWidths
+	if bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range text {
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// PDFFitText shortens text with "..." so that it fits into width
+func PDFFitText(text string, width, size float64, bold bool) string {
+	if PDFTextWidth(text, size, bold) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && PDFTextWidth(string(runes)+"...", size, bold) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "..."
+}
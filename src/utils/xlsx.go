@@ -0,0 +1,346 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cell styles, indexes into the cellXfs of the generated styles.xml
+const (
+	xlsxStyleDefault = iota
+	xlsxStyleHeader
+	xlsxStyleNumber
+	xlsxStyleDate
+	xlsxStyleDateTime
+	xlsxStyleTotalLabel
+	xlsxStyleTotalNumber
+)
+
+// XLSXWorkbook is a minimal Office Open XML spreadsheet writer producing typed string,
+// number and date cells, a bold frozen header row and bold total rows
+type XLSXWorkbook struct {
+	sheets []*XLSXSheet
+}
+
+// XLSXSheet is a worksheet of an XLSXWorkbook
+type XLSXSheet struct {
+	name   string
+	rows   [][]xlsxCell
+	widths []int
+	header bool
+}
+
+type xlsxCell struct {
+	value interface{}
+	style int
+}
+
+// NewXLSXWorkbook creates an empty workbook
+func NewXLSXWorkbook() *XLSXWorkbook {
+	return &XLSXWorkbook{}
+}
+
+// AddSheet appends a worksheet. Names are truncated to Excel's 31 characters and
+// characters Excel does not allow are replaced.
+func (w *XLSXWorkbook) AddSheet(name string) *XLSXSheet {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if len([]rune(name)) > 31 {
+		name = string([]rune(name)[:31])
+	}
+	if name == "" {
+		name = fmt.Sprintf("Sheet%d", len(w.sheets)+1)
+	}
+
+	sheet := &XLSXSheet{name: name}
+	w.sheets = append(w.sheets, sheet)
+	return sheet
+}
+
+// AddHeader adds a bold header row; the first header row is frozen
+func (s *XLSXSheet) AddHeader(titles ...string) {
+	row := make([]xlsxCell, len(titles))
+	for i, title := range titles {
+		row[i] = xlsxCell{value: title, style: xlsxStyleHeader}
+	}
+	if len(s.rows) == 0 {
+		s.header = true
+	}
+	s.addRow(row)
+}
+
+// AddRow adds a row of values. Strings become text cells, numbers become numeric
+// cells with two decimals (integers without), time.Time values become date cells
+// (with the time of day unless it is midnight) and nil leaves the cell empty.
+func (s *XLSXSheet) AddRow(values ...interface{}) {
+	row := make([]xlsxCell, len(values))
+	for i, value := range values {
+		row[i] = xlsxCell{value: value, style: xlsxStyleFor(value)}
+	}
+	s.addRow(row)
+}
+
+// AddTotalRow adds a bold row, typically a label followed by the column totals
+func (s *XLSXSheet) AddTotalRow(values ...interface{}) {
+	row := make([]xlsxCell, len(values))
+	for i, value := range values {
+		style := xlsxStyleTotalLabel
+		if xlsxStyleFor(value) == xlsxStyleNumber {
+			style = xlsxStyleTotalNumber
+		}
+		row[i] = xlsxCell{value: value, style: style}
+	}
+	s.addRow(row)
+}
+
+// addRow appends a row and widens the columns to fit it
+func (s *XLSXSheet) addRow(row []xlsxCell) {
+	for i, cell := range row {
+		for len(s.widths) <= i {
+			s.widths = append(s.widths, 8)
+		}
+		width := len(xlsxDisplay(cell.value)) + 2
+		if width > 60 {
+			width = 60
+		}
+		if width > s.widths[i] {
+			s.widths[i] = width
+		}
+	}
+	s.rows = append(s.rows, row)
+}
+
+// xlsxStyleFor returns the default style of a value
+func xlsxStyleFor(value interface{}) int {
+	switch v := value.(type) {
+	case float32, float64:
+		return xlsxStyleNumber
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return xlsxStyleDate
+		}
+		return xlsxStyleDateTime
+	case *time.Time:
+		if v == nil {
+			return xlsxStyleDefault
+		}
+		return xlsxStyleFor(*v)
+	}
+	return xlsxStyleDefault
+}
+
+// xlsxDisplay approximates how a value is displayed, used for column widths
+func xlsxDisplay(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float32, float64:
+		return fmt.Sprintf("%.2f", v)
+	case time.Time:
+		return "2006-01-02 15:04"
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return "2006-01-02 15:04"
+	}
+	return fmt.Sprint(value)
+}
+
+// xlsxColumn returns the column letters of a zero-based column index
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xlsxSerial converts a time to an Excel date serial in its own time zone
+func xlsxSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return wall.Sub(epoch).Hours() / 24
+}
+
+// xlsxEscape escapes text for XML element content
+func xlsxEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// cellXML renders a single cell
+func (c xlsxCell) cellXML(ref string) string {
+	value := c.value
+	if t, ok := value.(*time.Time); ok {
+		if t == nil {
+			value = nil
+		} else {
+			value = *t
+		}
+	}
+
+	var number float64
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if v == "" {
+			return ""
+		}
+		return fmt.Sprintf(`<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, c.style, xlsxEscape(v))
+	case bool:
+		flag := 0
+		if v {
+			flag = 1
+		}
+		return fmt.Sprintf(`<c r="%s" s="%d" t="b"><v>%d</v></c>`, ref, c.style, flag)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		number = xlsxSerial(v)
+	case int:
+		number = float64(v)
+	case int64:
+		number = float64(v)
+	case uint:
+		number = float64(v)
+	case float32:
+		number = float64(v)
+	case float64:
+		number = v
+	default:
+		return fmt.Sprintf(`<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, c.style, xlsxEscape(fmt.Sprint(v)))
+	}
+	if math.IsNaN(number) || math.IsInf(number, 0) {
+		return ""
+	}
+	return fmt.Sprintf(`<c r="%s" s="%d"><v>%s</v></c>`, ref, c.style, strconv.FormatFloat(number, 'f', -1, 64))
+}
+
+// sheetXML renders a worksheet
+func (s *XLSXSheet) sheetXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if s.header {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	if len(s.widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range s.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString(`</cols>`)
+	}
+	b.WriteString(`<sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			b.WriteString(cell.cellXML(fmt.Sprintf("%s%d", xlsxColumn(c), r+1)))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9E1F2"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="7">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>` +
+	`<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="2" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+// Write writes the workbook as an .xlsx file
+func (w *XLSXWorkbook) Write(out io.Writer) error {
+	if len(w.sheets) == 0 {
+		w.AddSheet("Sheet1")
+	}
+
+	var contentTypes, workbook, workbookRels strings.Builder
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	workbookRels.WriteString(xml.Header)
+	workbookRels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, sheet := range w.sheets {
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxAttr(sheet.name), i+1, i+1)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xml.Header + xlsxStyles},
+	}
+	for i, sheet := range w.sheets {
+		files = append(files, struct {
+			name    string
+			content string
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.sheetXML()})
+	}
+
+	zipWriter := zip.NewWriter(out)
+	for _, file := range files {
+		writer, err := zipWriter.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(writer, file.content); err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
+
+// xlsxAttr escapes text for an XML attribute value
+func xlsxAttr(text string) string {
+	return strings.ReplaceAll(xlsxEscape(text), `"`, "&quot;")
+}